@@ -0,0 +1,370 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+// ConversionAnnotation records the fields of a v1beta1 KustomizationAutoDeployer
+// that can't be represented in v1alpha1, so that they are restored when the
+// deployer is converted back to v1beta1.
+const ConversionAnnotation = "flux.gitops.pro/v1beta1-fields"
+
+// v1beta1Fields are the fields recorded in the ConversionAnnotation.
+type v1beta1Fields struct {
+	KustomizationNamespace string                    `json:"kustomizationNamespace,omitempty"`
+	ConcurrencyGroup       string                    `json:"concurrencyGroup,omitempty"`
+	FailurePolicy          *v1beta1.FailurePolicy    `json:"failurePolicy,omitempty"`
+	Gates                  *gatesSummary             `json:"gates,omitempty"`
+	BreakGlass             *v1beta1.BreakGlassStatus `json:"breakGlass,omitempty"`
+}
+
+// gatesSummary is the part of the v1beta1 GatesStatus that is not in the list
+// of gates.
+type gatesSummary struct {
+	Open          bool         `json:"open"`
+	Commit        string       `json:"commit,omitempty"`
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+}
+
+var _ conversion.Convertible = &KustomizationAutoDeployer{}
+
+// ConvertTo converts this KustomizationAutoDeployer to the Hub version.
+func (src *KustomizationAutoDeployer) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1beta1.KustomizationAutoDeployer)
+	if !ok {
+		return fmt.Errorf("unsupported conversion to %T", dstRaw)
+	}
+	in := src.DeepCopy()
+
+	var fields v1beta1Fields
+	if raw, ok := in.GetAnnotations()[ConversionAnnotation]; ok {
+		if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+			return fmt.Errorf("failed to parse the %s annotation: %w", ConversionAnnotation, err)
+		}
+		delete(in.Annotations, ConversionAnnotation)
+	}
+
+	dst.ObjectMeta = in.ObjectMeta
+	dst.Spec = v1beta1.KustomizationAutoDeployerSpec{
+		KustomizationRef: meta.NamespacedObjectReference{
+			Name:      in.Spec.KustomizationRef.Name,
+			Namespace: fields.KustomizationNamespace,
+		},
+		Interval:     in.Spec.Interval,
+		CommitLimit:  in.Spec.CommitLimit,
+		GatesTimeout: in.Spec.GatesTimeout,
+		Strategy: v1beta1.DeploymentStrategy{
+			TargetCommit: in.Spec.TargetCommit,
+			PinnedCommit: in.Spec.PinnedCommit,
+		},
+		CleanupPolicy:    v1beta1.CleanupPolicy(in.Spec.CleanupPolicy),
+		Suspend:          in.Spec.Suspend,
+		ConcurrencyGroup: fields.ConcurrencyGroup,
+	}
+	if fields.FailurePolicy != nil {
+		dst.Spec.FailurePolicy = *fields.FailurePolicy
+	}
+	if in.Spec.Gates != nil {
+		dst.Spec.Gates = make([]v1beta1.KustomizationGate, len(in.Spec.Gates))
+		for i := range in.Spec.Gates {
+			in.Spec.Gates[i].ConvertTo(&dst.Spec.Gates[i])
+		}
+	}
+
+	dst.Status = v1beta1.KustomizationAutoDeployerStatus{
+		LatestCommit:       in.Status.LatestCommit,
+		LastAdvancedTime:   in.Status.LastAdvancedTime,
+		AdvancedTimes:      in.Status.AdvancedTimes,
+		ObservedGeneration: in.Status.ObservedGeneration,
+		Conditions:         in.Status.Conditions,
+		DORA:               convertDORAToHub(in.Status.DORA),
+		BreakGlass:         fields.BreakGlass,
+	}
+	if len(in.Status.Gates) > 0 || fields.Gates != nil {
+		results := convertGateStatusesToHub(in.Status.Gates)
+		dst.Status.Gates = &v1beta1.GatesStatus{Open: allGatesOpen(results), Results: results}
+		if fields.Gates != nil {
+			dst.Status.Gates.Open = fields.Gates.Open
+			dst.Status.Gates.Commit = fields.Gates.Commit
+			dst.Status.Gates.LastCheckTime = fields.Gates.LastCheckTime
+		}
+	}
+	if in.Status.Callbacks != nil {
+		dst.Status.Callbacks = make([]v1beta1.CallbackStatus, len(in.Status.Callbacks))
+		for i, callback := range in.Status.Callbacks {
+			dst.Status.Callbacks[i] = v1beta1.CallbackStatus{
+				Gate:          callback.Gate,
+				ID:            callback.ID,
+				SecretHash:    callback.SecretHash,
+				Commit:        callback.Commit,
+				RequestedTime: callback.RequestedTime,
+				Result:        v1beta1.CallbackResult(callback.Result),
+				Message:       callback.Message,
+				CompletedTime: callback.CompletedTime,
+			}
+		}
+	}
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version to this version.
+//
+// The fields that can't be represented in this version are recorded in the
+// ConversionAnnotation.
+func (dst *KustomizationAutoDeployer) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1beta1.KustomizationAutoDeployer)
+	if !ok {
+		return fmt.Errorf("unsupported conversion from %T", srcRaw)
+	}
+	in := src.DeepCopy()
+
+	var fields v1beta1Fields
+	dst.ObjectMeta = in.ObjectMeta
+	dst.Spec = KustomizationAutoDeployerSpec{
+		KustomizationRef: meta.LocalObjectReference{Name: in.Spec.KustomizationRef.Name},
+		Interval:         in.Spec.Interval,
+		CommitLimit:      in.Spec.CommitLimit,
+		GatesTimeout:     in.Spec.GatesTimeout,
+		TargetCommit:     in.Spec.Strategy.TargetCommit,
+		PinnedCommit:     in.Spec.Strategy.PinnedCommit,
+		CleanupPolicy:    CleanupPolicy(in.Spec.CleanupPolicy),
+		Suspend:          in.Spec.Suspend,
+	}
+	fields.KustomizationNamespace = in.Spec.KustomizationRef.Namespace
+	fields.ConcurrencyGroup = in.Spec.ConcurrencyGroup
+	if in.Spec.FailurePolicy != (v1beta1.FailurePolicy{}) {
+		fields.FailurePolicy = &in.Spec.FailurePolicy
+	}
+	if in.Spec.Gates != nil {
+		dst.Spec.Gates = make([]KustomizationGate, len(in.Spec.Gates))
+		for i := range in.Spec.Gates {
+			dst.Spec.Gates[i].ConvertFrom(&in.Spec.Gates[i])
+		}
+	}
+
+	dst.Status = KustomizationAutoDeployerStatus{
+		LatestCommit:       in.Status.LatestCommit,
+		LastAdvancedTime:   in.Status.LastAdvancedTime,
+		AdvancedTimes:      in.Status.AdvancedTimes,
+		ObservedGeneration: in.Status.ObservedGeneration,
+		Conditions:         in.Status.Conditions,
+		DORA:               convertDORAFromHub(in.Status.DORA),
+	}
+	fields.BreakGlass = in.Status.BreakGlass
+	if gates := in.Status.Gates; gates != nil {
+		dst.Status.Gates = convertGateStatusesFromHub(gates.Results)
+		// The summary is only recorded if it can't be derived from the list
+		// of gates.
+		if gates.Open != allGatesOpen(gates.Results) || gates.Commit != "" || gates.LastCheckTime != nil || len(gates.Results) == 0 {
+			fields.Gates = &gatesSummary{Open: gates.Open, Commit: gates.Commit, LastCheckTime: gates.LastCheckTime}
+		}
+	}
+	if in.Status.Callbacks != nil {
+		dst.Status.Callbacks = make([]CallbackStatus, len(in.Status.Callbacks))
+		for i, callback := range in.Status.Callbacks {
+			dst.Status.Callbacks[i] = CallbackStatus{
+				Gate:          callback.Gate,
+				ID:            callback.ID,
+				SecretHash:    callback.SecretHash,
+				Commit:        callback.Commit,
+				RequestedTime: callback.RequestedTime,
+				Result:        CallbackResult(callback.Result),
+				Message:       callback.Message,
+				CompletedTime: callback.CompletedTime,
+			}
+		}
+	}
+
+	delete(dst.Annotations, ConversionAnnotation)
+	if fields != (v1beta1Fields{}) {
+		b, err := json.Marshal(fields)
+		if err != nil {
+			return fmt.Errorf("failed to record the %s annotation: %w", ConversionAnnotation, err)
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[ConversionAnnotation] = string(b)
+	}
+
+	return nil
+}
+
+// ConvertTo converts this KustomizationGate to the Hub version.
+func (src *KustomizationGate) ConvertTo(dst *v1beta1.KustomizationGate) {
+	in := src.DeepCopy()
+	*dst = v1beta1.KustomizationGate{
+		Name:        in.Name,
+		Timeout:     in.Timeout,
+		TemplateRef: in.TemplateRef,
+	}
+	if in.HealthCheck != nil {
+		dst.HealthCheck = &v1beta1.HealthCheck{URL: in.HealthCheck.URL, Interval: in.HealthCheck.Interval}
+	}
+	if in.Scheduled != nil {
+		dst.Scheduled = &v1beta1.ScheduledCheck{Open: in.Scheduled.Open, Close: in.Scheduled.Close}
+	}
+	if in.External != nil {
+		dst.External = &v1beta1.ExternalCheck{URL: in.External.URL, Parameters: in.External.Parameters, Interval: in.External.Interval}
+	}
+	if in.Callback != nil {
+		dst.Callback = &v1beta1.CallbackCheck{URL: in.Callback.URL, Timeout: in.Callback.Timeout}
+	}
+	if in.RateLimit != nil {
+		dst.RateLimit = &v1beta1.RateLimitCheck{Limit: in.RateLimit.Limit, Window: in.RateLimit.Window}
+	}
+	if in.SoakTime != nil {
+		dst.SoakTime = &v1beta1.SoakTimeCheck{Duration: in.SoakTime.Duration}
+	}
+	if in.Checks != nil {
+		dst.Checks = make([]v1beta1.GateCheck, len(in.Checks))
+		for i, check := range in.Checks {
+			dst.Checks[i] = v1beta1.GateCheck{Name: check.Name, Kind: check.Kind, Config: check.Config}
+		}
+	}
+}
+
+// ConvertFrom converts from the Hub version of the KustomizationGate to this
+// version.
+func (dst *KustomizationGate) ConvertFrom(src *v1beta1.KustomizationGate) {
+	in := src.DeepCopy()
+	*dst = KustomizationGate{
+		Name:        in.Name,
+		Timeout:     in.Timeout,
+		TemplateRef: in.TemplateRef,
+	}
+	if in.HealthCheck != nil {
+		dst.HealthCheck = &HealthCheck{URL: in.HealthCheck.URL, Interval: in.HealthCheck.Interval}
+	}
+	if in.Scheduled != nil {
+		dst.Scheduled = &ScheduledCheck{Open: in.Scheduled.Open, Close: in.Scheduled.Close}
+	}
+	if in.External != nil {
+		dst.External = &ExternalCheck{URL: in.External.URL, Parameters: in.External.Parameters, Interval: in.External.Interval}
+	}
+	if in.Callback != nil {
+		dst.Callback = &CallbackCheck{URL: in.Callback.URL, Timeout: in.Callback.Timeout}
+	}
+	if in.RateLimit != nil {
+		dst.RateLimit = &RateLimitCheck{Limit: in.RateLimit.Limit, Window: in.RateLimit.Window}
+	}
+	if in.SoakTime != nil {
+		dst.SoakTime = &SoakTimeCheck{Duration: in.SoakTime.Duration}
+	}
+	if in.Checks != nil {
+		dst.Checks = make([]GateCheck, len(in.Checks))
+		for i, check := range in.Checks {
+			dst.Checks[i] = GateCheck{Name: check.Name, Kind: check.Kind, Config: check.Config}
+		}
+	}
+}
+
+func convertGateStatusesToHub(in []GateStatus) []v1beta1.GateStatus {
+	if in == nil {
+		return nil
+	}
+	out := make([]v1beta1.GateStatus, len(in))
+	for i, gate := range in {
+		out[i] = v1beta1.GateStatus{Name: gate.Name, Open: gate.Open, Source: gate.Source}
+		if gate.Checks != nil {
+			out[i].Checks = make([]v1beta1.GateCheckStatus, len(gate.Checks))
+			for j, check := range gate.Checks {
+				out[i].Checks[j] = v1beta1.GateCheckStatus(check)
+			}
+		}
+	}
+
+	return out
+}
+
+func convertGateStatusesFromHub(in []v1beta1.GateStatus) []GateStatus {
+	if in == nil {
+		return nil
+	}
+	out := make([]GateStatus, len(in))
+	for i, gate := range in {
+		out[i] = GateStatus{Name: gate.Name, Open: gate.Open, Source: gate.Source}
+		if gate.Checks != nil {
+			out[i].Checks = make([]GateCheckStatus, len(gate.Checks))
+			for j, check := range gate.Checks {
+				out[i].Checks[j] = GateCheckStatus(check)
+			}
+		}
+	}
+
+	return out
+}
+
+func allGatesOpen(gates []v1beta1.GateStatus) bool {
+	for _, gate := range gates {
+		if !gate.Open {
+			return false
+		}
+	}
+
+	return true
+}
+
+func convertDORAToHub(in *DORAStatus) *v1beta1.DORAStatus {
+	if in == nil {
+		return nil
+	}
+	out := &v1beta1.DORAStatus{
+		LeadTimeForChanges: in.LeadTimeForChanges,
+		DeploymentsPerDay:  in.DeploymentsPerDay,
+		ChangeFailureRate:  in.ChangeFailureRate,
+		TimeToRestore:      in.TimeToRestore,
+	}
+	if in.RecentDeployments != nil {
+		out.RecentDeployments = make([]v1beta1.DeploymentRecord, len(in.RecentDeployments))
+		for i, record := range in.RecentDeployments {
+			out.RecentDeployments[i] = v1beta1.DeploymentRecord(record)
+		}
+	}
+
+	return out
+}
+
+func convertDORAFromHub(in *v1beta1.DORAStatus) *DORAStatus {
+	if in == nil {
+		return nil
+	}
+	out := &DORAStatus{
+		LeadTimeForChanges: in.LeadTimeForChanges,
+		DeploymentsPerDay:  in.DeploymentsPerDay,
+		ChangeFailureRate:  in.ChangeFailureRate,
+		TimeToRestore:      in.TimeToRestore,
+	}
+	if in.RecentDeployments != nil {
+		out.RecentDeployments = make([]DeploymentRecord, len(in.RecentDeployments))
+		for i, record := range in.RecentDeployments {
+			out.RecentDeployments[i] = DeploymentRecord(record)
+		}
+	}
+
+	return out
+}
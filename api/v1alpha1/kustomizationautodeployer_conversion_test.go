@@ -0,0 +1,157 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+
+	"github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+func FuzzKustomizationAutoDeployerConversion(f *testing.F) {
+	for seed := int64(0); seed < 200; seed++ {
+		f.Add(seed)
+	}
+
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		f.Fatal(err)
+	}
+	if err := v1beta1.AddToScheme(scheme); err != nil {
+		f.Fatal(err)
+	}
+	codecs := serializer.NewCodecFactory(scheme)
+
+	f.Fuzz(func(t *testing.T, seed int64) {
+		filler := fuzzer.FuzzerFor(metafuzzer.Funcs, rand.NewSource(seed), codecs)
+
+		t.Run("hub to spoke to hub", func(t *testing.T) {
+			hub := &v1beta1.KustomizationAutoDeployer{}
+			filler.Fill(hub)
+
+			spoke := &KustomizationAutoDeployer{}
+			if err := spoke.ConvertFrom(hub.DeepCopy()); err != nil {
+				t.Fatal(err)
+			}
+			restored := &v1beta1.KustomizationAutoDeployer{}
+			if err := spoke.ConvertTo(restored); err != nil {
+				t.Fatal(err)
+			}
+
+			if !apiequality.Semantic.DeepEqual(hub, restored) {
+				t.Fatalf("failed to round-trip:\n%s", cmp.Diff(hub, restored))
+			}
+		})
+
+		t.Run("spoke to hub to spoke", func(t *testing.T) {
+			spoke := &KustomizationAutoDeployer{}
+			filler.Fill(spoke)
+			delete(spoke.Annotations, ConversionAnnotation)
+
+			hub := &v1beta1.KustomizationAutoDeployer{}
+			if err := spoke.DeepCopy().ConvertTo(hub); err != nil {
+				t.Fatal(err)
+			}
+			restored := &KustomizationAutoDeployer{}
+			if err := restored.ConvertFrom(hub); err != nil {
+				t.Fatal(err)
+			}
+
+			if !apiequality.Semantic.DeepEqual(spoke, restored) {
+				t.Fatalf("failed to round-trip:\n%s", cmp.Diff(spoke, restored))
+			}
+		})
+	})
+}
+
+func TestConvertFrom_records_v1beta1_fields(t *testing.T) {
+	hub := &v1beta1.KustomizationAutoDeployer{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo-deployer", Namespace: "default"},
+		Spec: v1beta1.KustomizationAutoDeployerSpec{
+			KustomizationRef: meta.NamespacedObjectReference{Name: "test-kustomization", Namespace: "apps"},
+			Strategy:         v1beta1.DeploymentStrategy{TargetCommit: "abc123"},
+			FailurePolicy:    v1beta1.FailurePolicy{GateErrors: v1beta1.IgnoreGateErrorPolicy},
+		},
+	}
+
+	spoke := &KustomizationAutoDeployer{}
+	if err := spoke.ConvertFrom(hub); err != nil {
+		t.Fatal(err)
+	}
+
+	want := KustomizationAutoDeployerSpec{
+		KustomizationRef: meta.LocalObjectReference{Name: "test-kustomization"},
+		TargetCommit:     "abc123",
+	}
+	if diff := cmp.Diff(want, spoke.Spec); diff != "" {
+		t.Fatalf("failed to convert spec:\n%s", diff)
+	}
+	wantAnnotation := `{"kustomizationNamespace":"apps","failurePolicy":{"gateErrors":"Ignore"}}`
+	if v := spoke.Annotations[ConversionAnnotation]; v != wantAnnotation {
+		t.Fatalf("got annotation %q, want %q", v, wantAnnotation)
+	}
+}
+
+func TestConvertTo_summarises_gates(t *testing.T) {
+	spoke := &KustomizationAutoDeployer{
+		Status: KustomizationAutoDeployerStatus{
+			Gates: []GateStatus{
+				{Name: "business hours", Open: true},
+				{Name: "health", Open: false},
+			},
+		},
+	}
+
+	hub := &v1beta1.KustomizationAutoDeployer{}
+	if err := spoke.ConvertTo(hub); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &v1beta1.GatesStatus{
+		Open: false,
+		Results: []v1beta1.GateStatus{
+			{Name: "business hours", Open: true},
+			{Name: "health", Open: false},
+		},
+	}
+	if diff := cmp.Diff(want, hub.Status.Gates); diff != "" {
+		t.Fatalf("failed to convert gates:\n%s", diff)
+	}
+}
+
+func TestConvertTo_invalid_annotation(t *testing.T) {
+	spoke := &KustomizationAutoDeployer{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{ConversionAnnotation: "{"},
+		},
+	}
+
+	err := spoke.ConvertTo(&v1beta1.KustomizationAutoDeployer{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -21,40 +21,206 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"github.com/fluxcd/pkg/apis/meta"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in GatesStatus) DeepCopyInto(out *GatesStatus) {
-	{
-		in := &in
-		*out = make(GatesStatus, len(*in))
+func (in *CallbackCheck) DeepCopyInto(out *CallbackCheck) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CallbackCheck.
+func (in *CallbackCheck) DeepCopy() *CallbackCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(CallbackCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CallbackStatus) DeepCopyInto(out *CallbackStatus) {
+	*out = *in
+	in.RequestedTime.DeepCopyInto(&out.RequestedTime)
+	if in.CompletedTime != nil {
+		in, out := &in.CompletedTime, &out.CompletedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CallbackStatus.
+func (in *CallbackStatus) DeepCopy() *CallbackStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CallbackStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DORAStatus) DeepCopyInto(out *DORAStatus) {
+	*out = *in
+	if in.LeadTimeForChanges != nil {
+		in, out := &in.LeadTimeForChanges, &out.LeadTimeForChanges
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.DeploymentsPerDay != nil {
+		in, out := &in.DeploymentsPerDay, &out.DeploymentsPerDay
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.ChangeFailureRate != nil {
+		in, out := &in.ChangeFailureRate, &out.ChangeFailureRate
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.TimeToRestore != nil {
+		in, out := &in.TimeToRestore, &out.TimeToRestore
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RecentDeployments != nil {
+		in, out := &in.RecentDeployments, &out.RecentDeployments
+		*out = make([]DeploymentRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DORAStatus.
+func (in *DORAStatus) DeepCopy() *DORAStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DORAStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentRecord) DeepCopyInto(out *DeploymentRecord) {
+	*out = *in
+	if in.CommitTime != nil {
+		in, out := &in.CommitTime, &out.CommitTime
+		*out = (*in).DeepCopy()
+	}
+	in.DeployedTime.DeepCopyInto(&out.DeployedTime)
+	if in.RestoredTime != nil {
+		in, out := &in.RestoredTime, &out.RestoredTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentRecord.
+func (in *DeploymentRecord) DeepCopy() *DeploymentRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalCheck) DeepCopyInto(out *ExternalCheck) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
-			var outVal map[string]bool
-			if val == nil {
-				(*out)[key] = nil
-			} else {
-				inVal := (*in)[key]
-				in, out := &inVal, &outVal
-				*out = make(map[string]bool, len(*in))
-				for key, val := range *in {
-					(*out)[key] = val
-				}
-			}
-			(*out)[key] = outVal
+			(*out)[key] = val
 		}
 	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatesStatus.
-func (in GatesStatus) DeepCopy() GatesStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalCheck.
+func (in *ExternalCheck) DeepCopy() *ExternalCheck {
 	if in == nil {
 		return nil
 	}
-	out := new(GatesStatus)
+	out := new(ExternalCheck)
 	in.DeepCopyInto(out)
-	return *out
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GateCheck) DeepCopyInto(out *GateCheck) {
+	*out = *in
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GateCheck.
+func (in *GateCheck) DeepCopy() *GateCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(GateCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GateCheckStatus) DeepCopyInto(out *GateCheckStatus) {
+	*out = *in
+	in.LastCheckTime.DeepCopyInto(&out.LastCheckTime)
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	if in.NextCheckTime != nil {
+		in, out := &in.NextCheckTime, &out.NextCheckTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GateCheckStatus.
+func (in *GateCheckStatus) DeepCopy() *GateCheckStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GateCheckStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GateStatus) DeepCopyInto(out *GateStatus) {
+	*out = *in
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]GateCheckStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GateStatus.
+func (in *GateStatus) DeepCopy() *GateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GateStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -144,6 +310,11 @@ func (in *KustomizationAutoDeployerSpec) DeepCopyInto(out *KustomizationAutoDepl
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.GatesTimeout != nil {
+		in, out := &in.GatesTimeout, &out.GatesTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KustomizationAutoDeployerSpec.
@@ -159,6 +330,17 @@ func (in *KustomizationAutoDeployerSpec) DeepCopy() *KustomizationAutoDeployerSp
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KustomizationAutoDeployerStatus) DeepCopyInto(out *KustomizationAutoDeployerStatus) {
 	*out = *in
+	if in.LastAdvancedTime != nil {
+		in, out := &in.LastAdvancedTime, &out.LastAdvancedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.AdvancedTimes != nil {
+		in, out := &in.AdvancedTimes, &out.AdvancedTimes
+		*out = make([]v1.Time, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -168,22 +350,23 @@ func (in *KustomizationAutoDeployerStatus) DeepCopyInto(out *KustomizationAutoDe
 	}
 	if in.Gates != nil {
 		in, out := &in.Gates, &out.Gates
-		*out = make(GatesStatus, len(*in))
-		for key, val := range *in {
-			var outVal map[string]bool
-			if val == nil {
-				(*out)[key] = nil
-			} else {
-				inVal := (*in)[key]
-				in, out := &inVal, &outVal
-				*out = make(map[string]bool, len(*in))
-				for key, val := range *in {
-					(*out)[key] = val
-				}
-			}
-			(*out)[key] = outVal
+		*out = make([]GateStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Callbacks != nil {
+		in, out := &in.Callbacks, &out.Callbacks
+		*out = make([]CallbackStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.DORA != nil {
+		in, out := &in.DORA, &out.DORA
+		*out = new(DORAStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KustomizationAutoDeployerStatus.
@@ -199,6 +382,11 @@ func (in *KustomizationAutoDeployerStatus) DeepCopy() *KustomizationAutoDeployer
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KustomizationGate) DeepCopyInto(out *KustomizationGate) {
 	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	if in.HealthCheck != nil {
 		in, out := &in.HealthCheck, &out.HealthCheck
 		*out = new(HealthCheck)
@@ -209,6 +397,38 @@ func (in *KustomizationGate) DeepCopyInto(out *KustomizationGate) {
 		*out = new(ScheduledCheck)
 		**out = **in
 	}
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(ExternalCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Callback != nil {
+		in, out := &in.Callback, &out.Callback
+		*out = new(CallbackCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(RateLimitCheck)
+		**out = **in
+	}
+	if in.SoakTime != nil {
+		in, out := &in.SoakTime, &out.SoakTime
+		*out = new(SoakTimeCheck)
+		**out = **in
+	}
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]GateCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TemplateRef != nil {
+		in, out := &in.TemplateRef, &out.TemplateRef
+		*out = new(meta.LocalObjectReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KustomizationGate.
@@ -221,6 +441,22 @@ func (in *KustomizationGate) DeepCopy() *KustomizationGate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RateLimitCheck) DeepCopyInto(out *RateLimitCheck) {
+	*out = *in
+	out.Window = in.Window
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimitCheck.
+func (in *RateLimitCheck) DeepCopy() *RateLimitCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(RateLimitCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScheduledCheck) DeepCopyInto(out *ScheduledCheck) {
 	*out = *in
@@ -235,3 +471,19 @@ func (in *ScheduledCheck) DeepCopy() *ScheduledCheck {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SoakTimeCheck) DeepCopyInto(out *SoakTimeCheck) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SoakTimeCheck.
+func (in *SoakTimeCheck) DeepCopy() *SoakTimeCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(SoakTimeCheck)
+	in.DeepCopyInto(out)
+	return out
+}
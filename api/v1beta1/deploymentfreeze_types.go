@@ -0,0 +1,96 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// BreakGlassAnnotation is set on a KustomizationAutoDeployer to bypass
+	// DeploymentFreezes until the time in the annotation, in RFC3339 format.
+	BreakGlassAnnotation = "flux.gitops.pro/break-glass-until"
+
+	// BreakGlassUserAnnotation records the user that set the
+	// BreakGlassAnnotation, this is set by the admission webhook.
+	BreakGlassUserAnnotation = "flux.gitops.pro/break-glass-user"
+)
+
+// DeploymentFreezeSpec defines the desired state of DeploymentFreeze
+// +kubebuilder:validation:XValidation:rule="!has(self.start) || !has(self.end) || self.end > self.start",message="end must be after start"
+type DeploymentFreezeSpec struct {
+	// Start is when the freeze starts, the freeze starts when it is created
+	// if this is not set.
+	// +optional
+	Start *metav1.Time `json:"start,omitempty"`
+
+	// End is when the freeze ends, the freeze lasts until it is deleted if
+	// this is not set.
+	// +optional
+	End *metav1.Time `json:"end,omitempty"`
+
+	// Reason is recorded in the status and Events of the frozen deployers.
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Reason string `json:"reason"`
+
+	// NamespaceSelector selects the namespaces of the deployers that are
+	// frozen, an empty or missing selector selects all namespaces.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// DeployerSelector selects the deployers that are frozen by label, an
+	// empty or missing selector selects all deployers.
+	// +optional
+	DeployerSelector *metav1.LabelSelector `json:"deployerSelector,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+
+// DeploymentFreeze is the Schema for the deploymentfreezes API, the deployers
+// that it selects don't advance while the freeze is active.
+type DeploymentFreeze struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DeploymentFreezeSpec `json:"spec,omitempty"`
+}
+
+// ActiveAt returns true if the freeze is active at the time.
+func (in *DeploymentFreeze) ActiveAt(t time.Time) bool {
+	if in.Spec.Start != nil && t.Before(in.Spec.Start.Time) {
+		return false
+	}
+
+	return in.Spec.End == nil || t.Before(in.Spec.End.Time)
+}
+
+//+kubebuilder:object:root=true
+
+// DeploymentFreezeList contains a list of DeploymentFreeze
+type DeploymentFreezeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DeploymentFreeze `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DeploymentFreeze{}, &DeploymentFreezeList{})
+}
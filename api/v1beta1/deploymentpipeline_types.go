@@ -0,0 +1,178 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/fluxcd/pkg/apis/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// PromotedCommitAnnotation is set on the KustomizationAutoDeployer in a
+	// DeploymentPipeline stage by the pipeline controller, the deployer will
+	// not advance beyond this commit.
+	//
+	// An empty value indicates that no commit has been promoted to the stage
+	// yet.
+	PromotedCommitAnnotation = "pipelines.flux.gitops.pro/promoted-commit"
+
+	// PipelinePausedAnnotation is set on all KustomizationAutoDeployers in a
+	// DeploymentPipeline when the pipeline is suspended.
+	PipelinePausedAnnotation = "pipelines.flux.gitops.pro/paused"
+)
+
+// DeploymentPipelineFinalizer is added to DeploymentPipelines to remove the
+// pipeline annotations from the stage deployers when they are deleted.
+const DeploymentPipelineFinalizer = "pipelines.flux.gitops.pro/finalizer"
+
+const (
+	// PipelinePausedReason is set when the deployer is part of a suspended
+	// DeploymentPipeline.
+	PipelinePausedReason string = "PipelinePaused"
+
+	// WaitingForPromotionReason is set when the deployer has reached the
+	// commit promoted to it by a DeploymentPipeline.
+	WaitingForPromotionReason string = "WaitingForPromotion"
+
+	// FailedToLoadStageReason indicates that a stage in a DeploymentPipeline
+	// could not be loaded.
+	FailedToLoadStageReason string = "FailedToLoadStage"
+
+	// PipelineSuspendedReason is set on the DeploymentPipeline when it is
+	// suspended.
+	PipelineSuspendedReason string = "Suspended"
+
+	// PipelineReconciledReason is set on the DeploymentPipeline when all the
+	// stages have been processed.
+	PipelineReconciledReason string = "Reconciled"
+)
+
+// PipelineStage is an environment in a DeploymentPipeline.
+type PipelineStage struct {
+	// Name is a string used to identify the stage.
+	// +required
+	Name string `json:"name"`
+
+	// DeployerRef is the KustomizationAutoDeployer that deploys commits to
+	// this stage.
+	// +required
+	DeployerRef meta.LocalObjectReference `json:"deployerRef"`
+}
+
+// DeploymentPipelineSpec defines the desired state of DeploymentPipeline
+type DeploymentPipelineSpec struct {
+	// Stages are the ordered environments that commits are promoted through.
+	//
+	// A commit is promoted to a stage once it has been applied by the
+	// Kustomization of the previous stage.
+	// +kubebuilder:validation:MinItems=1
+	// +required
+	Stages []PipelineStage `json:"stages"`
+
+	// Suspend pauses the deployers in all stages of the pipeline.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// PipelineStageStatus is the observed state of a stage in a
+// DeploymentPipeline.
+type PipelineStageStatus struct {
+	// Name is the name of the stage.
+	Name string `json:"name"`
+
+	// Deployer is the name of the stage's KustomizationAutoDeployer.
+	// +optional
+	Deployer string `json:"deployer,omitempty"`
+
+	// LatestCommit is the latest commit requested by the stage's deployer.
+	// +optional
+	LatestCommit string `json:"latestCommit,omitempty"`
+
+	// AppliedCommit is the commit last applied by the stage's Kustomization.
+	// +optional
+	AppliedCommit string `json:"appliedCommit,omitempty"`
+
+	// PromotedCommit is the commit that the stage's deployer is allowed to
+	// advance to.
+	//
+	// This is not set for the first stage.
+	// +optional
+	PromotedCommit string `json:"promotedCommit,omitempty"`
+
+	// Message is a human readable description of the stage state.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PipelineCommitStatus records where a commit is in the pipeline.
+type PipelineCommitStatus struct {
+	// Commit is the commit ID.
+	Commit string `json:"commit"`
+
+	// Stages are the stages that have applied this commit.
+	Stages []string `json:"stages"`
+}
+
+// DeploymentPipelineStatus defines the observed state of DeploymentPipeline
+type DeploymentPipelineStatus struct {
+	// ObservedGeneration reflects the generation of the most recently observed
+	// DeploymentPipeline.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions holds the conditions for the DeploymentPipeline.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Stages contains the state of each stage in the pipeline.
+	// +optional
+	Stages []PipelineStageStatus `json:"stages,omitempty"`
+
+	// Commits records which stages each commit has been applied to.
+	// +optional
+	Commits []PipelineCommitStatus `json:"commits,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// DeploymentPipeline is the Schema for the deploymentpipelines API
+type DeploymentPipeline struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DeploymentPipelineSpec   `json:"spec,omitempty"`
+	Status DeploymentPipelineStatus `json:"status,omitempty"`
+}
+
+// SetConditions sets the status conditions on the object.
+func (in *DeploymentPipeline) SetConditions(conditions []metav1.Condition) {
+	in.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// DeploymentPipelineList contains a list of DeploymentPipeline
+type DeploymentPipelineList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DeploymentPipeline `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DeploymentPipeline{}, &DeploymentPipelineList{})
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeploymentPolicySpec defines the desired state of DeploymentPolicy
+type DeploymentPolicySpec struct {
+	// NamespaceSelector selects the namespaces of the deployers that the
+	// policy applies to, an empty or missing selector selects all namespaces.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// DeployerSelector selects the deployers that the policy applies to by
+	// label, an empty or missing selector selects all deployers.
+	// +optional
+	DeployerSelector *metav1.LabelSelector `json:"deployerSelector,omitempty"`
+
+	// Gates are checked for the selected deployers in addition to their own
+	// gates.
+	//
+	// The gates are identified as <policy name>/<gate name> in the status of
+	// the deployer.
+	//
+	// The gates can't reference a GateTemplate, templates are in the namespace
+	// of the deployer, and could replace the policy gates.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=16
+	// +kubebuilder:validation:XValidation:rule="self.all(g, self.exists_one(o, o.name == g.name))",message="gate names must be unique"
+	// +kubebuilder:validation:XValidation:rule="self.all(g, !has(g.templateRef))",message="gates in a DeploymentPolicy can't reference a GateTemplate"
+	// +required
+	Gates []KustomizationGate `json:"gates"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+
+// DeploymentPolicy is the Schema for the deploymentpolicies API, it adds
+// mandatory gates to the deployers that it selects.
+type DeploymentPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DeploymentPolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DeploymentPolicyList contains a list of DeploymentPolicy
+type DeploymentPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DeploymentPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DeploymentPolicy{}, &DeploymentPolicyList{})
+}
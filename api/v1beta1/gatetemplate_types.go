@@ -0,0 +1,56 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GateTemplateSpec defines the desired state of GateTemplate
+type GateTemplateSpec struct {
+	// Gates are checked for the deployers that reference the template.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=16
+	// +kubebuilder:validation:XValidation:rule="self.all(g, self.exists_one(o, o.name == g.name))",message="gate names must be unique"
+	// +kubebuilder:validation:XValidation:rule="self.all(g, !has(g.templateRef))",message="gates in a template cannot reference templates"
+	// +required
+	Gates []KustomizationGate `json:"gates"`
+}
+
+//+kubebuilder:object:root=true
+
+// GateTemplate is the Schema for the gatetemplates API, it defines gates that
+// can be referenced from the gates of deployers in the same namespace.
+type GateTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec GateTemplateSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GateTemplateList contains a list of GateTemplate
+type GateTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GateTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GateTemplate{}, &GateTemplateList{})
+}
@@ -0,0 +1,748 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// SuspendedCondition is True when the KustomizationAutoDeployer is
+	// suspended.
+	SuspendedCondition string = "Suspended"
+
+	// WaitingCondition is True when the KustomizationAutoDeployer is waiting
+	// for another deployer in its ConcurrencyGroup.
+	WaitingCondition string = "Waiting"
+
+	// SuspendedReason is set when the KustomizationAutoDeployer is suspended.
+	SuspendedReason string = "Suspended"
+
+	// ResumedReason is recorded when a suspended KustomizationAutoDeployer is
+	// resumed.
+	ResumedReason string = "Resumed"
+
+	// CleanupPolicyAppliedReason is recorded when the CleanupPolicy has been
+	// applied to the GitRepository of a deleted KustomizationAutoDeployer.
+	CleanupPolicyAppliedReason string = "CleanupPolicyApplied"
+
+	// GatesClosedReason is set when no commits will be applied because
+	// the gates are currently closed.
+	GatesClosedReason string = "GatesClosed"
+
+	// FailedToLoadKustomizationReason indicates that the referenced
+	// Kustomization could not be loaded.
+	FailedToLoadKustomizationReason string = "FailedToLoadKustomization"
+
+	// AccessDeniedReason indicates that the referenced Kustomization is in
+	// another namespace, and cross-namespace references are disabled.
+	AccessDeniedReason string = "AccessDenied"
+
+	// GitRepositoryNotPopulatedReason indicates that the GitRepository
+	// associated with the Kustomization has not updated successfully.
+	GitRepositoryNotPopulatedReason string = "GitRepositoryNotLoaded"
+
+	// RevisionsErrorReason is set when we couldn't list the revisions in the
+	// upstream repository.
+	RevisionsErrorReason string = "RevisionsError"
+
+	// CommitAdvancedReason is set when the GitRepository has been updated to
+	// the next commit.
+	CommitAdvancedReason string = "CommitAdvanced"
+
+	// UpToDateReason is set when the Kustomization has applied the HEAD
+	// commit.
+	UpToDateReason string = "UpToDate"
+
+	// CommitRequestedReason is set when the GitRepository has been updated to
+	// the next commit, and the commit has not been fetched.
+	CommitRequestedReason string = "CommitRequested"
+
+	// TargetCommitReachedReason is set when the Kustomization has applied the
+	// configured target commit.
+	TargetCommitReachedReason string = "TargetCommitReached"
+
+	// TargetCommitNotFoundReason is set when the configured target commit is
+	// not in the commits listed from the upstream repository.
+	TargetCommitNotFoundReason string = "TargetCommitNotFound"
+
+	// CommitPinnedReason is set when the GitRepository is pinned to the
+	// configured pinned commit.
+	CommitPinnedReason string = "CommitPinned"
+
+	// PinnedCommitRejectedReason is set when the configured pinned commit is
+	// not in the commits listed from the upstream repository at or before the
+	// commit applied by the Kustomization.
+	PinnedCommitRejectedReason string = "PinnedCommitRejected"
+
+	// BreakGlassUsedReason is recorded when a commit is advanced during a
+	// DeploymentFreeze because of the BreakGlassAnnotation.
+	BreakGlassUsedReason string = "BreakGlassUsed"
+
+	// WaitingForConcurrencyGroupReason is set when another deployer in the
+	// ConcurrencyGroup has a commit in flight.
+	WaitingForConcurrencyGroupReason string = "WaitingForConcurrencyGroup"
+)
+
+const (
+	// ConcurrencyGroupLabel is set on the Lease for a ConcurrencyGroup to the
+	// name of the group.
+	ConcurrencyGroupLabel = "flux.gitops.pro/concurrency-group"
+
+	// ConcurrencyCommitAnnotation is set on the Lease for a ConcurrencyGroup
+	// to the commit that the holder has in flight.
+	ConcurrencyCommitAnnotation = "flux.gitops.pro/commit"
+)
+
+// KustomizationAutoDeployerFinalizer is added to KustomizationAutoDeployers
+// to apply the CleanupPolicy when they are deleted.
+const KustomizationAutoDeployerFinalizer = "finalizers.flux.gitops.pro"
+
+// CleanupPolicy describes what happens to the GitRepository when a
+// KustomizationAutoDeployer is deleted.
+// +kubebuilder:validation:Enum=Retain;Unpin;LastVerified
+type CleanupPolicy string
+
+const (
+	// RetainCleanupPolicy leaves the GitRepository pinned to the last commit
+	// set by the deployer.
+	RetainCleanupPolicy CleanupPolicy = "Retain"
+
+	// UnpinCleanupPolicy clears the commit in the GitRepository so that Flux
+	// tracks the HEAD of the branch again.
+	UnpinCleanupPolicy CleanupPolicy = "Unpin"
+
+	// LastVerifiedCleanupPolicy pins the GitRepository to the commit last
+	// applied by the Kustomization.
+	LastVerifiedCleanupPolicy CleanupPolicy = "LastVerified"
+)
+
+// GateErrorPolicy describes how a gate check that fails with an error, or
+// does not complete within its timeout, is treated.
+// +kubebuilder:validation:Enum=Fail;Ignore
+type GateErrorPolicy string
+
+const (
+	// FailGateErrorPolicy closes the check until it succeeds.
+	FailGateErrorPolicy GateErrorPolicy = "Fail"
+
+	// IgnoreGateErrorPolicy opens the check, the error is recorded in the
+	// status of the check.
+	IgnoreGateErrorPolicy GateErrorPolicy = "Ignore"
+)
+
+// CallbackResult is the result of a callback requested by a Callback gate.
+// +kubebuilder:validation:Enum=Pending;Passed;Failed
+type CallbackResult string
+
+const (
+	// CallbackPending indicates that the callback has not been received.
+	CallbackPending CallbackResult = "Pending"
+
+	// CallbackPassed indicates that the external system passed the commit.
+	CallbackPassed CallbackResult = "Passed"
+
+	// CallbackFailed indicates that the external system failed the commit.
+	CallbackFailed CallbackResult = "Failed"
+)
+
+// CallbackStatus is a callback requested by a Callback gate.
+type CallbackStatus struct {
+	// Gate is the name of the gate that requested the callback.
+	Gate string `json:"gate"`
+
+	// ID correlates the callback with the request.
+	ID string `json:"id"`
+
+	// SecretHash is the hex encoded SHA-256 hash of the secret sent in the
+	// request, the callback is only accepted with the secret.
+	// +optional
+	SecretHash string `json:"secretHash,omitempty"`
+
+	// Commit is the candidate commit sent in the request.
+	Commit string `json:"commit"`
+
+	// RequestedTime is when the request was sent.
+	RequestedTime metav1.Time `json:"requestedTime"`
+
+	// Result is Pending until the callback is received.
+	Result CallbackResult `json:"result"`
+
+	// Message is the message provided with the callback.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// CompletedTime is when the callback was received.
+	// +optional
+	CompletedTime *metav1.Time `json:"completedTime,omitempty"`
+}
+
+// BreakGlassStatus records a commit that was advanced during a
+// DeploymentFreeze because of the BreakGlassAnnotation.
+type BreakGlassStatus struct {
+	// User is the user that set the BreakGlassAnnotation.
+	User string `json:"user"`
+
+	// ExpiresTime is when the BreakGlassAnnotation expires.
+	ExpiresTime metav1.Time `json:"expiresTime"`
+
+	// UsedTime is when the commit was advanced.
+	UsedTime metav1.Time `json:"usedTime"`
+
+	// Commit is the commit that was advanced.
+	Commit string `json:"commit"`
+
+	// Freezes are the names of the DeploymentFreezes that were bypassed.
+	Freezes []string `json:"freezes"`
+}
+
+// GateCheckStatus is the state of a check in a configured gate.
+type GateCheckStatus struct {
+	// Name is the kind of check, e.g. HealthCheck.
+	Name string `json:"name"`
+
+	// Open is true if the check is open.
+	Open bool `json:"open"`
+
+	// Message is a human readable reason for the state of the check.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastCheckTime is when the check was last made.
+	LastCheckTime metav1.Time `json:"lastCheckTime"`
+
+	// LastTransitionTime is when the check last changed between open and
+	// closed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// NextCheckTime is when the check will be made again, this is not set
+	// for checks that don't requeue.
+	//
+	// The result of the check is reused until this time, unless the
+	// candidate commit or the generation of the deployer changes.
+	// +optional
+	NextCheckTime *metav1.Time `json:"nextCheckTime,omitempty"`
+
+	// Commit is the candidate commit that was checked.
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
+	// ObservedGeneration is the generation of the deployer that was checked.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastError is the error from the last check, if it failed.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// GateStatus is the state of a configured gate in the auto deployer.
+type GateStatus struct {
+	// Name is the name of the configured gate.
+	Name string `json:"name"`
+
+	// Open is true if all the checks in the gate are open.
+	Open bool `json:"open"`
+
+	// Source is the resource that configured the gate, e.g.
+	// DeploymentPolicy/freeze-calendar, this is empty for gates configured in
+	// the deployer.
+	// +optional
+	Source string `json:"source,omitempty"`
+
+	// Checks contains the state of each check in the gate.
+	// +optional
+	Checks []GateCheckStatus `json:"checks,omitempty"`
+}
+
+// HealthCheck is a Gate that fetches a URL and is open if the requests are
+// successful.
+type HealthCheck struct {
+	// URL is a  generic catch-all, query the configured URL and if returns
+	// anything other than a 200 response, the check fails.
+	// +required
+	URL string `json:"url"`
+
+	// Interval at which to check the URL for updates, 0s checks the URL on
+	// every reconciliation.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +kubebuilder:validation:XValidation:rule="duration(self) == duration('0s') || duration(self) >= duration('1s')",message="interval must be 0s or at least 1s"
+	// +required
+	Interval metav1.Duration `json:"interval"`
+}
+
+// ScheduledCheck is a Gate that is open if the current time is between the open
+// and close times.
+// +kubebuilder:validation:XValidation:rule="self.open < self.close",message="close must be after open"
+type ScheduledCheck struct {
+	// hh:mm for the time to "open" the gate at.
+	// +kubebuilder:validation:Pattern="^([01][0-9]|2[0-3]):[0-5][0-9]$"
+	// +kubebuilder:validation:MaxLength=5
+	// +required
+	Open string `json:"open"`
+	// hh:mm for the time to "close" the gate at.
+	// +kubebuilder:validation:Pattern="^([01][0-9]|2[0-3]):[0-5][0-9]$"
+	// +kubebuilder:validation:MaxLength=5
+	// +required
+	Close string `json:"close"`
+}
+
+// ExternalCheck is a Gate that delegates the check to an out-of-process gate
+// server that implements the gate plugin protocol.
+type ExternalCheck struct {
+	// URL is the endpoint of the gate server, the check is POSTed to this URL.
+	// +required
+	URL string `json:"url"`
+
+	// Parameters are passed to the gate server with the check.
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// Interval at which to recheck the gate if the gate server does not
+	// suggest a requeue interval.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +kubebuilder:validation:XValidation:rule="duration(self) == duration('0s') || duration(self) >= duration('1s')",message="interval must be 0s or at least 1s"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// CallbackCheck is a Gate that POSTs the candidate commit to an external
+// system, and is open when the system calls back to the controller with a
+// passing result.
+type CallbackCheck struct {
+	// URL is the endpoint that the candidate commit is POSTed to.
+	// +required
+	URL string `json:"url"`
+
+	// Timeout is how long to wait for the callback before requesting another
+	// callback, defaults to 1h.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="timeout must be greater than 0s"
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+const (
+	// MaxRateLimit is the largest Limit of a RateLimitCheck, this is the
+	// number of AdvancedTimes kept in the status.
+	MaxRateLimit = 50
+
+	// MaxRateLimitWindow is the longest Window of a RateLimitCheck,
+	// AdvancedTimes older than this are removed from the status.
+	MaxRateLimitWindow = time.Hour * 168
+)
+
+// RateLimitCheck is a Gate that is open if fewer than Limit commits have been
+// advanced within the Window.
+type RateLimitCheck struct {
+	// Limit is the number of commits that can be advanced within the Window.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=50
+	// +required
+	Limit int32 `json:"limit"`
+
+	// Window is the sliding window that the advanced commits are counted in,
+	// e.g. 1h, this can be at most 168h.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s') && duration(self) <= duration('168h')",message="window must be greater than 0s and at most 168h"
+	// +required
+	Window metav1.Duration `json:"window"`
+}
+
+// SoakTimeCheck is a Gate that is open when the Kustomization has been Ready
+// on the current commit for the Duration.
+type SoakTimeCheck struct {
+	// Duration is how long the Kustomization must be Ready on the current
+	// commit before the next commit is advanced, e.g. 30m.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="duration must be greater than 0s"
+	// +required
+	Duration metav1.Duration `json:"duration"`
+}
+
+// GateCheck is a check in a gate configured by kind, several checks of the
+// same kind can be configured in a gate.
+type GateCheck struct {
+	// Name identifies the check in the status of the gate.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +required
+	Name string `json:"name"`
+
+	// Kind is the kind of check, e.g. HealthCheck.
+	// +kubebuilder:validation:MaxLength=63
+	// +required
+	Kind string `json:"kind"`
+
+	// Config is the configuration for the check, this has the same schema as
+	// the field for the kind of check in the gate, e.g. healthCheck.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +optional
+	Config *apiextensionsv1.JSON `json:"config,omitempty"`
+}
+
+// KustomizationGate describes a gate to be checked before updating to the
+// latest commit.
+// +kubebuilder:validation:XValidation:rule="[has(self.healthCheck), has(self.scheduled), has(self.external), has(self.callback), has(self.rateLimit), has(self.soakTime), has(self.templateRef), has(self.checks) && size(self.checks) > 0].filter(x, x).size() == 1",message="exactly one of healthCheck, scheduled, external, callback, rateLimit, soakTime, templateRef or checks must be set"
+type KustomizationGate struct {
+	// Name is a string used to identify the gate.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +required
+	Name string `json:"name"`
+
+	// Timeout is how long to wait for the checks in the gate, a check that
+	// does not complete within the timeout is closed, defaults to 30s.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="timeout must be greater than 0s"
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// HealthCheck is a generic URL checker.
+	// +optional
+	HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
+
+	// ScheduledCheck is a time-based gate.
+	// +optional
+	Scheduled *ScheduledCheck `json:"scheduled,omitempty"`
+
+	// External delegates the check to a gate server.
+	// +optional
+	External *ExternalCheck `json:"external,omitempty"`
+
+	// Callback waits for an external system to call back with the result.
+	// +optional
+	Callback *CallbackCheck `json:"callback,omitempty"`
+
+	// RateLimit limits how many commits are advanced within a window.
+	// +optional
+	RateLimit *RateLimitCheck `json:"rateLimit,omitempty"`
+
+	// SoakTime waits for the current commit to be Ready for a duration.
+	// +optional
+	SoakTime *SoakTimeCheck `json:"soakTime,omitempty"`
+
+	// Checks are checks configured by kind.
+	// +kubebuilder:validation:MaxItems=16
+	// +kubebuilder:validation:XValidation:rule="self.all(c, self.exists_one(o, o.name == c.name))",message="check names must be unique"
+	// +optional
+	Checks []GateCheck `json:"checks,omitempty"`
+
+	// TemplateRef is a GateTemplate in the namespace of the deployer, the
+	// gates in the template are checked in place of this gate, and are named
+	// <gate name>/<template gate name>.
+	//
+	// The Timeout of this gate is used for the template gates that don't
+	// have a Timeout.
+	// +optional
+	TemplateRef *meta.LocalObjectReference `json:"templateRef,omitempty"`
+}
+
+// DeploymentStrategy describes how the deployer advances the commit in the
+// GitRepository.
+type DeploymentStrategy struct {
+	// TargetCommit is a commit to stop advancing at, instead of the HEAD of
+	// the branch.
+	//
+	// This must be within the CommitLimit of the HEAD commit.
+	// +optional
+	TargetCommit string `json:"targetCommit,omitempty"`
+
+	// PinnedCommit forces the GitRepository to the commit, this can be used to
+	// roll back to an earlier commit.
+	//
+	// This must be within the CommitLimit of the HEAD commit, and at or before
+	// the commit applied by the Kustomization.
+	//
+	// When this is removed, the deployer resumes advancing one commit at a
+	// time from the pinned commit.
+	// +optional
+	PinnedCommit string `json:"pinnedCommit,omitempty"`
+}
+
+// FailurePolicy describes how the deployer handles failures.
+type FailurePolicy struct {
+	// GateErrors is applied to checks that fail with an error or time out,
+	// Fail closes the check, and Ignore opens it, defaults to Fail.
+	// +kubebuilder:default=Fail
+	// +optional
+	GateErrors GateErrorPolicy `json:"gateErrors,omitempty"`
+}
+
+// KustomizationAutoDeployerSpec defines the desired state of KustomizationAutoDeployer
+type KustomizationAutoDeployerSpec struct {
+	// The Kustomization resource to track and wait for new commits to be
+	// available.
+	//
+	// This will access the GitRepository that is used by the Kustomization.
+	//
+	// The namespace defaults to the namespace of the deployer, references to
+	// other namespaces can be disabled in the controller.
+	// +required
+	KustomizationRef meta.NamespacedObjectReference `json:"kustomizationRef"`
+
+	// Interval at which to check the GitRepository for updates.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('1s')",message="interval must be at least 1s"
+	// +required
+	Interval metav1.Duration `json:"interval"`
+
+	// CloneDepth limits the number of commits to get from the GitRepository.
+	//
+	// This is an optimisation for fetching commits.
+	//
+	// +kubebuilder:default=100
+	// +kubebuilder:validation:Minimum:=5
+	// +kubebuilder:validation:Maximum:=100
+	CommitLimit int `json:"commitLimit,omitempty"`
+
+	// Gates are the checks applied before advancing the commit in the
+	// GitRepository for the referenced Kustomization.
+	// +kubebuilder:validation:MaxItems=32
+	// +kubebuilder:validation:XValidation:rule="self.all(g, self.exists_one(o, o.name == g.name))",message="gate names must be unique"
+	// +optional
+	Gates []KustomizationGate `json:"gates,omitempty"`
+
+	// GatesTimeout is the total time allowed for checking all the gates,
+	// checks that do not complete within this time are closed, defaults to
+	// 2m.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="gatesTimeout must be greater than 0s"
+	// +optional
+	GatesTimeout *metav1.Duration `json:"gatesTimeout,omitempty"`
+
+	// Strategy configures how the commit is advanced.
+	// +optional
+	Strategy DeploymentStrategy `json:"strategy,omitempty"`
+
+	// FailurePolicy configures how failures are handled.
+	// +optional
+	FailurePolicy FailurePolicy `json:"failurePolicy,omitempty"`
+
+	// CleanupPolicy is applied to the GitRepository when the deployer is
+	// deleted.
+	//
+	// Retain leaves the GitRepository pinned to the last commit, Unpin clears
+	// the commit so that Flux tracks the branch HEAD, and LastVerified pins the
+	// GitRepository to the commit last applied by the Kustomization.
+	// +kubebuilder:default=Retain
+	// +optional
+	CleanupPolicy CleanupPolicy `json:"cleanupPolicy,omitempty"`
+
+	// Suspend tells the controller to stop advancing the commit in the
+	// GitRepository, it does not apply to already started reconciliations.
+	// Defaults to false.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// ConcurrencyGroup is the name of a group of deployers that advance one
+	// at a time, only one deployer in the group can have a commit in flight,
+	// that has been advanced but not applied by a Ready Kustomization.
+	//
+	// The group is coordinated with a Lease.
+	// +kubebuilder:validation:MaxLength=40
+	// +kubebuilder:validation:Pattern="^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	// +optional
+	ConcurrencyGroup string `json:"concurrencyGroup,omitempty"`
+}
+
+// DeploymentRecord is a commit that the KustomizationAutoDeployer has
+// observed the Kustomization applying, or failing to apply.
+type DeploymentRecord struct {
+	// Commit is the commit ID.
+	Commit string `json:"commit"`
+
+	// CommitTime is when the commit was made in git.
+	// +optional
+	CommitTime *metav1.Time `json:"commitTime,omitempty"`
+
+	// DeployedTime is when the Kustomization was observed applying, or
+	// failing to apply the commit.
+	DeployedTime metav1.Time `json:"deployedTime"`
+
+	// Failed is true if the Kustomization failed to apply the commit.
+	// +optional
+	Failed bool `json:"failed,omitempty"`
+
+	// RestoredTime is when a failed deployment was followed by a successful
+	// deployment.
+	// +optional
+	RestoredTime *metav1.Time `json:"restoredTime,omitempty"`
+}
+
+// DORAStatus summarises the DORA metrics for the recent deployments.
+type DORAStatus struct {
+	// LeadTimeForChanges is the mean time between a commit being made and the
+	// Kustomization applying it.
+	// +optional
+	LeadTimeForChanges *metav1.Duration `json:"leadTimeForChanges,omitempty"`
+
+	// DeploymentsPerDay is the mean number of successful deployments per
+	// day.
+	// +optional
+	DeploymentsPerDay *resource.Quantity `json:"deploymentsPerDay,omitempty"`
+
+	// ChangeFailureRate is the proportion of deployments that failed, from 0
+	// to 1.
+	// +optional
+	ChangeFailureRate *resource.Quantity `json:"changeFailureRate,omitempty"`
+
+	// TimeToRestore is the mean time between a failed deployment and the
+	// next successful deployment.
+	// +optional
+	TimeToRestore *metav1.Duration `json:"timeToRestore,omitempty"`
+
+	// RecentDeployments are the deployments that the metrics are calculated
+	// from, oldest first.
+	// +optional
+	RecentDeployments []DeploymentRecord `json:"recentDeployments,omitempty"`
+}
+
+// GatesStatus is the state of the gates from the last check.
+type GatesStatus struct {
+	// Open is true if all the gates were open.
+	Open bool `json:"open"`
+
+	// Commit is the candidate commit that the gates were checked for.
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
+	// LastCheckTime is when the gates were last checked.
+	// +optional
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+
+	// Results contains the state of each configured gate.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Results []GateStatus `json:"results,omitempty"`
+}
+
+// KustomizationAutoDeployerStatus defines the observed state of KustomizationAutoDeployer
+type KustomizationAutoDeployerStatus struct {
+	// LatestCommit is the latest commit processed by the Kustomization.
+	// +optional
+	LatestCommit string `json:"latestCommit,omitempty"`
+
+	// LastAdvancedTime is the time that the GitRepository was advanced to the
+	// LatestCommit, this is cleared when the Kustomization has applied it.
+	// +optional
+	LastAdvancedTime *metav1.Time `json:"lastAdvancedTime,omitempty"`
+
+	// AdvancedTimes are the times that the GitRepository was recently
+	// advanced, oldest first, these are counted by RateLimit gates.
+	// +optional
+	AdvancedTimes []metav1.Time `json:"advancedTimes,omitempty"`
+
+	// ObservedGeneration reflects the generation of the most recently observed
+	// KustomizationAutoDeployer.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions holds the conditions for the KustomizationAutoDeployer.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Gates contains the state of the gates from the last check.
+	// +optional
+	Gates *GatesStatus `json:"gates,omitempty"`
+
+	// Callbacks are the callbacks requested by Callback gates.
+	// +listType=map
+	// +listMapKey=gate
+	// +optional
+	Callbacks []CallbackStatus `json:"callbacks,omitempty"`
+
+	// BreakGlass records the last time that a DeploymentFreeze was bypassed
+	// with the BreakGlassAnnotation.
+	// +optional
+	BreakGlass *BreakGlassStatus `json:"breakGlass,omitempty"`
+
+	// DORA contains the DORA metrics for the deployer.
+	// +optional
+	DORA *DORAStatus `json:"dora,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+
+// KustomizationAutoDeployer is the Schema for the kustomizationautodeployers API
+type KustomizationAutoDeployer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KustomizationAutoDeployerSpec   `json:"spec,omitempty"`
+	Status KustomizationAutoDeployerStatus `json:"status,omitempty"`
+}
+
+// Hub marks this version as the conversion hub.
+func (*KustomizationAutoDeployer) Hub() {}
+
+// GateResults returns the state of each gate from the last check.
+func (in *KustomizationAutoDeployerStatus) GateResults() []GateStatus {
+	if in.Gates == nil {
+		return nil
+	}
+
+	return in.Gates.Results
+}
+
+// KustomizationKey returns the key for the deployer's Kustomization,
+// defaulting to the namespace of the deployer.
+func (in *KustomizationAutoDeployer) KustomizationKey() types.NamespacedName {
+	namespace := in.Spec.KustomizationRef.Namespace
+	if namespace == "" {
+		namespace = in.GetNamespace()
+	}
+
+	return types.NamespacedName{Namespace: namespace, Name: in.Spec.KustomizationRef.Name}
+}
+
+// SetConditions sets the status conditions on the object.
+func (in *KustomizationAutoDeployer) SetConditions(conditions []metav1.Condition) {
+	in.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// KustomizationAutoDeployerList contains a list of KustomizationAutoDeployer
+type KustomizationAutoDeployerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KustomizationAutoDeployer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KustomizationAutoDeployer{}, &KustomizationAutoDeployerList{})
+}
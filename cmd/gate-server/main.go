@@ -0,0 +1,47 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// gate-server is a reference gate server that implements the gate plugin
+// protocol.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/gateplugin"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/gateplugin/reference"
+)
+
+func main() {
+	var addr string
+	flag.StringVar(&addr, "listen-address", ":8080", "The address the gate server listens on.")
+	flag.Parse()
+
+	mux := http.NewServeMux()
+	mux.Handle("/check", gateplugin.Handler(reference.New().Check))
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Printf("gate server listening on %s", addr)
+	log.Fatal(server.ListenAndServe())
+}
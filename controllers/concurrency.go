@@ -0,0 +1,218 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+// concurrencyLeaseKey returns the key for the Lease that coordinates the
+// deployer's ConcurrencyGroup.
+//
+// The Lease is in the ConcurrencyGroupNamespace, or the namespace of the
+// deployer if this is not configured.
+func (r *KustomizationAutoDeployerReconciler) concurrencyLeaseKey(deployer *deployerv1.KustomizationAutoDeployer) client.ObjectKey {
+	namespace := r.ConcurrencyGroupNamespace
+	if namespace == "" {
+		namespace = deployer.GetNamespace()
+	}
+
+	return client.ObjectKey{Namespace: namespace, Name: "concurrency-group-" + deployer.Spec.ConcurrencyGroup}
+}
+
+// acquireConcurrencyGroup records the deployer as the holder of the Lease for
+// its ConcurrencyGroup with the commit that it is advancing to.
+//
+// If another deployer in the group holds the Lease, the Lease is not updated
+// and the identity of the holder and its commit are returned.
+//
+// The Lease is taken from holders that have been deleted, are no longer in
+// the group, or whose Kustomization has applied or failed to apply the commit
+// in the Lease. If ConcurrencyLeaseDuration is configured, the Lease is also
+// taken from holders that have not renewed it within the duration.
+func (r *KustomizationAutoDeployerReconciler) acquireConcurrencyGroup(ctx context.Context, deployer *deployerv1.KustomizationAutoDeployer, commit string) (string, string, error) {
+	key := r.concurrencyLeaseKey(deployer)
+	identity := client.ObjectKeyFromObject(deployer).String()
+	now := metav1.NewMicroTime(time.Now())
+	var duration *int32
+	if r.ConcurrencyLeaseDuration > 0 {
+		seconds := int32(r.ConcurrencyLeaseDuration.Seconds())
+		duration = &seconds
+	}
+
+	var lease coordinationv1.Lease
+	if err := r.Get(ctx, key, &lease); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return "", "", fmt.Errorf("failed to get Lease %s: %w", key, err)
+		}
+		lease = coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        key.Name,
+				Namespace:   key.Namespace,
+				Labels:      map[string]string{deployerv1.ConcurrencyGroupLabel: deployer.Spec.ConcurrencyGroup},
+				Annotations: map[string]string{deployerv1.ConcurrencyCommitAnnotation: commit},
+			},
+			Spec: coordinationv1.LeaseSpec{HolderIdentity: &identity, LeaseDurationSeconds: duration, AcquireTime: &now, RenewTime: &now},
+		}
+		if err := r.Create(ctx, &lease); err != nil {
+			return "", "", fmt.Errorf("failed to create Lease %s: %w", key, err)
+		}
+
+		return "", "", nil
+	}
+
+	if holder := leaseHolder(&lease); holder != "" && holder != identity && !r.leaseExpired(&lease, now.Time) {
+		holderCommit := lease.GetAnnotations()[deployerv1.ConcurrencyCommitAnnotation]
+		inFlight, err := r.commitInFlight(ctx, holder, deployer.Spec.ConcurrencyGroup, holderCommit)
+		if err != nil {
+			return "", "", err
+		}
+		if inFlight {
+			return holder, holderCommit, nil
+		}
+	}
+
+	// The update fails with a conflict if another deployer has updated the
+	// Lease since it was read.
+	if leaseHolder(&lease) != identity {
+		lease.Spec.AcquireTime = &now
+	}
+	lease.Spec.HolderIdentity = &identity
+	lease.Spec.LeaseDurationSeconds = duration
+	lease.Spec.RenewTime = &now
+	if lease.Annotations == nil {
+		lease.Annotations = map[string]string{}
+	}
+	lease.Annotations[deployerv1.ConcurrencyCommitAnnotation] = commit
+	if err := r.Update(ctx, &lease); err != nil {
+		return "", "", fmt.Errorf("failed to update Lease %s: %w", key, err)
+	}
+
+	return "", "", nil
+}
+
+// releaseConcurrencyGroup clears the holder of the Lease for the deployer's
+// ConcurrencyGroup if the deployer holds it, and the Kustomization is Ready
+// with the commit in the Lease applied, or the Kustomization is nil.
+//
+// The Kustomization is nil when the deployer will not advance the commit in
+// the Lease, e.g. it is deleted, suspended, paused or pinned, or it failed to
+// advance the GitRepository.
+func (r *KustomizationAutoDeployerReconciler) releaseConcurrencyGroup(ctx context.Context, deployer *deployerv1.KustomizationAutoDeployer, kustomization *kustomizev1.Kustomization) error {
+	if deployer.Spec.ConcurrencyGroup == "" {
+		return nil
+	}
+
+	key := r.concurrencyLeaseKey(deployer)
+	var lease coordinationv1.Lease
+	if err := r.Get(ctx, key, &lease); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if leaseHolder(&lease) != client.ObjectKeyFromObject(deployer).String() {
+		return nil
+	}
+
+	if kustomization != nil {
+		_, applied := parseRevision(kustomization.Status.LastAppliedRevision)
+		if applied != lease.GetAnnotations()[deployerv1.ConcurrencyCommitAnnotation] ||
+			!apimeta.IsStatusConditionTrue(kustomization.Status.Conditions, meta.ReadyCondition) {
+			return nil
+		}
+	}
+
+	lease.Spec.HolderIdentity = nil
+	delete(lease.Annotations, deployerv1.ConcurrencyCommitAnnotation)
+	if err := r.Update(ctx, &lease); err != nil {
+		return fmt.Errorf("failed to release Lease %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// commitInFlight returns true if the deployer identified by the holder exists,
+// is in the group, and its Kustomization has neither applied nor failed to
+// apply the commit.
+func (r *KustomizationAutoDeployerReconciler) commitInFlight(ctx context.Context, holder, group, commit string) (bool, error) {
+	namespace, name, ok := strings.Cut(holder, "/")
+	if !ok {
+		return false, nil
+	}
+
+	var deployer deployerv1.KustomizationAutoDeployer
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &deployer); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get the holder %s of the concurrency group %s: %w", holder, group, err)
+	}
+	if deployer.Spec.ConcurrencyGroup != group || !deployer.DeletionTimestamp.IsZero() {
+		return false, nil
+	}
+
+	var kustomization kustomizev1.Kustomization
+	if err := r.Get(ctx, deployer.KustomizationKey(), &kustomization); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get the Kustomization for the holder %s of the concurrency group %s: %w", holder, group, err)
+	}
+
+	ready := apimeta.FindStatusCondition(kustomization.Status.Conditions, meta.ReadyCondition)
+	if ready == nil {
+		return true, nil
+	}
+	if _, applied := parseRevision(kustomization.Status.LastAppliedRevision); applied == commit && ready.Status == metav1.ConditionTrue {
+		return false, nil
+	}
+	if _, attempted := parseRevision(kustomization.Status.LastAttemptedRevision); attempted == commit && ready.Status == metav1.ConditionFalse {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// leaseExpired returns true if ConcurrencyLeaseDuration is configured and the
+// Lease was not renewed within its duration, Leases without a renew time or
+// duration don't expire.
+func (r *KustomizationAutoDeployerReconciler) leaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if r.ConcurrencyLeaseDuration <= 0 || lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return false
+	}
+
+	return now.After(lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second))
+}
+
+func leaseHolder(lease *coordinationv1.Lease) string {
+	if lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+
+	return *lease.Spec.HolderIdentity
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+// freezeCheckName is the name of the check in the gates for DeploymentFreezes.
+const freezeCheckName = "DeploymentFreeze"
+
+// breakGlass is an unexpired BreakGlassAnnotation on a deployer.
+type breakGlass struct {
+	user    string
+	expires time.Time
+}
+
+// breakGlassFor returns the break glass for the deployer, or nil if the
+// annotation is not set or has expired.
+func breakGlassFor(deployer *deployerv1.KustomizationAutoDeployer, now time.Time) (*breakGlass, error) {
+	until, ok := deployer.GetAnnotations()[deployerv1.BreakGlassAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	expires, err := time.Parse(time.RFC3339, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the %s annotation: %w", deployerv1.BreakGlassAnnotation, err)
+	}
+	if !now.Before(expires) {
+		return nil, nil
+	}
+
+	user := deployer.GetAnnotations()[deployerv1.BreakGlassUserAnnotation]
+	if user == "" {
+		user = "unknown"
+	}
+
+	return &breakGlass{user: user, expires: expires}, nil
+}
+
+// activeFreezes returns the DeploymentFreezes that select the deployer and are
+// active, sorted by name.
+func (r *KustomizationAutoDeployerReconciler) activeFreezes(ctx context.Context, deployer *deployerv1.KustomizationAutoDeployer, now time.Time) ([]deployerv1.DeploymentFreeze, error) {
+	var list deployerv1.DeploymentFreezeList
+	if err := r.List(ctx, &list); err != nil {
+		return nil, fmt.Errorf("failed to list DeploymentFreezes: %w", err)
+	}
+
+	selection := &deployerSelection{client: r.Client, deployer: deployer}
+	active := []deployerv1.DeploymentFreeze{}
+	for _, freeze := range list.Items {
+		if !freeze.ActiveAt(now) {
+			continue
+		}
+		matches, err := selection.matches(ctx, freeze.Spec.NamespaceSelector, freeze.Spec.DeployerSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to match DeploymentFreeze %s: %w", freeze.GetName(), err)
+		}
+		if matches {
+			active = append(active, freeze)
+		}
+	}
+
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].GetName() < active[j].GetName()
+	})
+
+	return active, nil
+}
+
+// freezeGates returns a gate for each of the active freezes, the gates are
+// closed until the end of the freeze unless the freeze is bypassed by the
+// break glass.
+func freezeGates(deployer *deployerv1.KustomizationAutoDeployer, freezes []deployerv1.DeploymentFreeze, bg *breakGlass, candidate string, now time.Time) []deployerv1.GateStatus {
+	result := make([]deployerv1.GateStatus, 0, len(freezes))
+	for _, freeze := range freezes {
+		check := deployerv1.GateCheckStatus{
+			Name:               freezeCheckName,
+			LastCheckTime:      metav1.NewTime(now),
+			LastTransitionTime: metav1.NewTime(now),
+			Commit:             candidate,
+			ObservedGeneration: deployer.Generation,
+		}
+		switch {
+		case bg != nil:
+			check.Open = true
+			check.Message = fmt.Sprintf("bypassed by %s until %s: %s", bg.user, bg.expires.Format(time.RFC3339), freeze.Spec.Reason)
+		case freeze.Spec.End != nil:
+			check.Message = fmt.Sprintf("frozen until %s: %s", freeze.Spec.End.Format(time.RFC3339), freeze.Spec.Reason)
+			check.NextCheckTime = freeze.Spec.End.DeepCopy()
+		default:
+			check.Message = "frozen: " + freeze.Spec.Reason
+		}
+
+		name := "freeze/" + freeze.GetName()
+		for _, previous := range deployer.Status.GateResults() {
+			if previous.Name == name && len(previous.Checks) == 1 && previous.Checks[0].Open == check.Open {
+				check.LastTransitionTime = previous.Checks[0].LastTransitionTime
+			}
+		}
+
+		result = append(result, deployerv1.GateStatus{
+			Name:   name,
+			Source: "DeploymentFreeze/" + freeze.GetName(),
+			Open:   check.Open,
+			Checks: []deployerv1.GateCheckStatus{check},
+		})
+	}
+
+	return result
+}
+
+// freezeNames returns the names of the freezes.
+func freezeNames(freezes []deployerv1.DeploymentFreeze) []string {
+	names := make([]string, len(freezes))
+	for i := range freezes {
+		names[i] = freezes[i].GetName()
+	}
+
+	return names
+}
+
+// breakGlassEventMessage is the message for the Event recorded when a commit
+// is advanced during a freeze.
+func breakGlassEventMessage(status *deployerv1.BreakGlassStatus) string {
+	return fmt.Sprintf("commit %s advanced during DeploymentFreeze %s by %s, break glass expires at %s",
+		status.Commit, strings.Join(status.Freezes, ", "), status.User, status.ExpiresTime.Format(time.RFC3339))
+}
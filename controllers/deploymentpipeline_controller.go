@@ -0,0 +1,365 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+const (
+	pipelineDeployerIndexKey string = ".spec.stages.deployerRef"
+)
+
+// DeploymentPipelineReconciler reconciles a DeploymentPipeline object
+type DeploymentPipelineReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=flux.gitops.pro,resources=deploymentpipelines,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=flux.gitops.pro,resources=deploymentpipelines/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=flux.gitops.pro,resources=deploymentpipelines/finalizers,verbs=update
+
+// Reconcile promotes commits between the stages of a DeploymentPipeline.
+//
+// Each stage after the first is allowed to advance to the commit that the
+// previous stage's Kustomization has applied.
+func (r *DeploymentPipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var pipeline deployerv1.DeploymentPipeline
+	if err := r.Client.Get(ctx, req.NamespacedName, &pipeline); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !pipeline.ObjectMeta.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, &pipeline)
+	}
+
+	if !controllerutil.ContainsFinalizer(&pipeline, deployerv1.DeploymentPipelineFinalizer) {
+		patchHelper := client.MergeFrom(pipeline.DeepCopy())
+		controllerutil.AddFinalizer(&pipeline, deployerv1.DeploymentPipelineFinalizer)
+		if err := r.Patch(ctx, &pipeline, patchHelper); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to add finalizer: %w", err)
+		}
+	}
+
+	// The deployers of stages that have been removed from the pipeline are no
+	// longer paused or constrained by a promotion.
+	for _, name := range removedDeployers(&pipeline) {
+		if err := r.releaseDeployer(ctx, client.ObjectKey{Namespace: pipeline.GetNamespace(), Name: name}); err != nil {
+			logger.Error(err, "releasing removed deployer", "deployer", name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	stages := []deployerv1.PipelineStageStatus{}
+	// The first stage is not constrained by a promotion.
+	var promoted *string
+	for _, stage := range pipeline.Spec.Stages {
+		stageStatus, applied, err := r.reconcileStage(ctx, &pipeline, stage, promoted)
+		if err != nil {
+			logger.Error(err, "reconciling pipeline stage", "stage", stage.Name)
+			setPipelineReadiness(&pipeline, metav1.ConditionFalse, deployerv1.FailedToLoadStageReason, err.Error())
+			pipeline.Status.Stages = append(stages, deployerv1.PipelineStageStatus{Name: stage.Name, Deployer: stage.DeployerRef.Name, Message: err.Error()})
+			if err := r.patchStatus(ctx, req, pipeline.Status); err != nil {
+				logger.Error(err, "failed to update pipeline status")
+			}
+			return ctrl.Result{}, err
+		}
+		stages = append(stages, stageStatus)
+		promoted = &applied
+	}
+
+	pipeline.Status.Stages = stages
+	pipeline.Status.Commits = summariseCommits(stages)
+	if pipeline.Spec.Suspend {
+		setPipelineReadiness(&pipeline, metav1.ConditionFalse, deployerv1.PipelineSuspendedReason, "pipeline is suspended")
+	} else {
+		setPipelineReadiness(&pipeline, metav1.ConditionTrue, deployerv1.PipelineReconciledReason, fmt.Sprintf("%d stages reconciled", len(stages)))
+	}
+
+	if err := r.patchStatus(ctx, req, pipeline.Status); err != nil {
+		logger.Error(err, "failed to reconcile")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileStage updates the annotations on the stage's deployer and returns
+// the status of the stage and the commit applied by the stage's Kustomization.
+//
+// If promoted is nil the stage is not constrained.
+func (r *DeploymentPipelineReconciler) reconcileStage(ctx context.Context, pipeline *deployerv1.DeploymentPipeline, stage deployerv1.PipelineStage, promoted *string) (deployerv1.PipelineStageStatus, string, error) {
+	var deployer deployerv1.KustomizationAutoDeployer
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: pipeline.GetNamespace(), Name: stage.DeployerRef.Name}, &deployer); err != nil {
+		return deployerv1.PipelineStageStatus{}, "", fmt.Errorf("failed to load deployer %s for stage %s: %w", stage.DeployerRef.Name, stage.Name, err)
+	}
+
+	var kustomization kustomizev1.Kustomization
+	if err := r.Client.Get(ctx, deployer.KustomizationKey(), &kustomization); err != nil {
+		return deployerv1.PipelineStageStatus{}, "", fmt.Errorf("failed to load kustomizationRef %s for stage %s: %w", deployer.Spec.KustomizationRef, stage.Name, err)
+	}
+
+	stageStatus := deployerv1.PipelineStageStatus{
+		Name:          stage.Name,
+		Deployer:      deployer.GetName(),
+		LatestCommit:  deployer.Status.LatestCommit,
+		AppliedCommit: kustomization.Status.LastAppliedRevision,
+		Message:       "deploying",
+	}
+
+	patchHelper := client.MergeFrom(deployer.DeepCopy())
+	annotations := deployer.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	if pipeline.Spec.Suspend {
+		annotations[deployerv1.PipelinePausedAnnotation] = "true"
+		stageStatus.Message = "paused"
+	} else {
+		delete(annotations, deployerv1.PipelinePausedAnnotation)
+	}
+
+	if promoted != nil {
+		_, commitID := parseRevision(*promoted)
+		annotations[deployerv1.PromotedCommitAnnotation] = commitID
+		stageStatus.PromotedCommit = *promoted
+		if commitID == "" && !pipeline.Spec.Suspend {
+			stageStatus.Message = "waiting for the previous stage to apply a commit"
+		}
+	} else {
+		delete(annotations, deployerv1.PromotedCommitAnnotation)
+	}
+	deployer.SetAnnotations(annotations)
+
+	if err := r.Client.Patch(ctx, &deployer, patchHelper); err != nil {
+		return deployerv1.PipelineStageStatus{}, "", fmt.Errorf("failed to update deployer %s for stage %s: %w", stage.DeployerRef.Name, stage.Name, err)
+	}
+
+	return stageStatus, kustomization.Status.LastAppliedRevision, nil
+}
+
+// reconcileDelete removes the pipeline annotations from the stage deployers
+// and removes the finalizer from the pipeline.
+func (r *DeploymentPipelineReconciler) reconcileDelete(ctx context.Context, pipeline *deployerv1.DeploymentPipeline) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(pipeline, deployerv1.DeploymentPipelineFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	names := removedDeployers(pipeline)
+	for _, stage := range pipeline.Spec.Stages {
+		names = append(names, stage.DeployerRef.Name)
+	}
+	for _, name := range names {
+		if err := r.releaseDeployer(ctx, client.ObjectKey{Namespace: pipeline.GetNamespace(), Name: name}); err != nil {
+			logger.Error(err, "releasing deployer", "deployer", name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	patchHelper := client.MergeFrom(pipeline.DeepCopy())
+	controllerutil.RemoveFinalizer(pipeline, deployerv1.DeploymentPipelineFinalizer)
+	if err := r.Patch(ctx, pipeline, patchHelper); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to remove finalizer: %w", err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// releaseDeployer removes the pipeline annotations from a deployer, deployers
+// that don't exist are ignored.
+func (r *DeploymentPipelineReconciler) releaseDeployer(ctx context.Context, key client.ObjectKey) error {
+	var deployer deployerv1.KustomizationAutoDeployer
+	if err := r.Client.Get(ctx, key, &deployer); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	annotations := deployer.GetAnnotations()
+	_, paused := annotations[deployerv1.PipelinePausedAnnotation]
+	_, promoted := annotations[deployerv1.PromotedCommitAnnotation]
+	if !paused && !promoted {
+		return nil
+	}
+
+	patchHelper := client.MergeFrom(deployer.DeepCopy())
+	delete(annotations, deployerv1.PipelinePausedAnnotation)
+	delete(annotations, deployerv1.PromotedCommitAnnotation)
+	deployer.SetAnnotations(annotations)
+	if err := r.Client.Patch(ctx, &deployer, patchHelper); err != nil {
+		return fmt.Errorf("failed to update deployer %s: %w", key.Name, err)
+	}
+
+	return nil
+}
+
+// removedDeployers returns the deployers recorded in the status of the
+// pipeline that are not in any of its stages.
+func removedDeployers(pipeline *deployerv1.DeploymentPipeline) []string {
+	current := sets.New[string]()
+	for _, stage := range pipeline.Spec.Stages {
+		current.Insert(stage.DeployerRef.Name)
+	}
+
+	result := []string{}
+	for _, stage := range pipeline.Status.Stages {
+		if stage.Deployer != "" && !current.Has(stage.Deployer) {
+			result = append(result, stage.Deployer)
+		}
+	}
+
+	return result
+}
+
+func (r *DeploymentPipelineReconciler) patchStatus(ctx context.Context, req ctrl.Request, newStatus deployerv1.DeploymentPipelineStatus) error {
+	var pipeline deployerv1.DeploymentPipeline
+	if err := r.Get(ctx, req.NamespacedName, &pipeline); err != nil {
+		return err
+	}
+
+	patch := client.MergeFrom(pipeline.DeepCopy())
+	pipeline.Status = newStatus
+
+	return r.Status().Patch(ctx, &pipeline, patch)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DeploymentPipelineReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Index the DeploymentPipelines by the KustomizationAutoDeployers in their
+	// stages.
+	if err := mgr.GetCache().IndexField(
+		context.TODO(), &deployerv1.DeploymentPipeline{}, pipelineDeployerIndexKey, func(o client.Object) []string {
+			p, ok := o.(*deployerv1.DeploymentPipeline)
+			if !ok {
+				panic(fmt.Sprintf("Expected a DeploymentPipeline, got %T", o))
+			}
+
+			result := []string{}
+			for _, stage := range p.Spec.Stages {
+				result = append(result, fmt.Sprintf("%s/%s", p.GetNamespace(), stage.DeployerRef.Name))
+			}
+
+			return result
+		}); err != nil {
+		return fmt.Errorf("failed setting index fields for KustomizationAutoDeployers: %w", err)
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&deployerv1.DeploymentPipeline{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(
+			&deployerv1.KustomizationAutoDeployer{},
+			handler.EnqueueRequestsFromMapFunc(r.deployerToPipeline),
+		).
+		Watches(
+			&kustomizev1.Kustomization{},
+			handler.EnqueueRequestsFromMapFunc(r.kustomizationToPipeline),
+		).
+		Complete(r)
+}
+
+func (r *DeploymentPipelineReconciler) deployerToPipeline(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list deployerv1.DeploymentPipelineList
+
+	if err := r.List(ctx, &list, client.MatchingFields{
+		pipelineDeployerIndexKey: client.ObjectKeyFromObject(obj).String(),
+	}); err != nil {
+		return nil
+	}
+
+	result := []reconcile.Request{}
+	for _, v := range list.Items {
+		result = append(result, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&v)})
+	}
+
+	return result
+}
+
+func (r *DeploymentPipelineReconciler) kustomizationToPipeline(ctx context.Context, obj client.Object) []reconcile.Request {
+	// The deployers are indexed by the KustomizationAutoDeployerReconciler.
+	var list deployerv1.KustomizationAutoDeployerList
+	if err := r.List(ctx, &list, client.MatchingFields{
+		kustomizationIndexKey: client.ObjectKeyFromObject(obj).String(),
+	}); err != nil {
+		return nil
+	}
+
+	result := []reconcile.Request{}
+	for _, v := range list.Items {
+		result = append(result, r.deployerToPipeline(ctx, &v)...)
+	}
+
+	return result
+}
+
+// summariseCommits groups the stages by the commit they have applied, in
+// stage order.
+func summariseCommits(stages []deployerv1.PipelineStageStatus) []deployerv1.PipelineCommitStatus {
+	result := []deployerv1.PipelineCommitStatus{}
+	for _, stage := range stages {
+		if stage.AppliedCommit == "" {
+			continue
+		}
+
+		found := false
+		for i := range result {
+			if result[i].Commit == stage.AppliedCommit {
+				result[i].Stages = append(result[i].Stages, stage.Name)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			result = append(result, deployerv1.PipelineCommitStatus{Commit: stage.AppliedCommit, Stages: []string{stage.Name}})
+		}
+	}
+
+	return result
+}
+
+func setPipelineReadiness(pipeline *deployerv1.DeploymentPipeline, status metav1.ConditionStatus, reason, message string) {
+	pipeline.Status.ObservedGeneration = pipeline.ObjectMeta.Generation
+	newCondition := metav1.Condition{
+		Type:    meta.ReadyCondition,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+	apimeta.SetStatusCondition(&pipeline.Status.Conditions, newCondition)
+}
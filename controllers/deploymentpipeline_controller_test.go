@@ -0,0 +1,329 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/test"
+)
+
+func TestPipelineReconciliation(t *testing.T) {
+	testEnv := &envtest.Environment{
+		ErrorIfCRDPathMissing: true,
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			"testdata/crds",
+		},
+	}
+
+	cfg, err := testEnv.Start()
+	test.AssertNoError(t, err)
+	defer func() {
+		if err := testEnv.Stop(); err != nil {
+			t.Errorf("failed to stop the test environment: %s", err)
+		}
+	}()
+
+	scheme := runtime.NewScheme()
+	test.AssertNoError(t, clientgoscheme.AddToScheme(scheme))
+	test.AssertNoError(t, deployerv1.AddToScheme(scheme))
+	test.AssertNoError(t, kustomizev1.AddToScheme(scheme))
+	test.AssertNoError(t, sourcev1.AddToScheme(scheme))
+
+	k8sClient, err := client.New(cfg, client.Options{Scheme: scheme})
+	test.AssertNoError(t, err)
+
+	reconciler := &DeploymentPipelineReconciler{
+		Client: k8sClient,
+		Scheme: scheme,
+	}
+
+	t.Run("reconciling with missing deployer", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		pipeline := test.NewDeploymentPipeline(func(p *deployerv1.DeploymentPipeline) {
+			p.Spec.Stages = []deployerv1.PipelineStage{
+				{Name: "dev", DeployerRef: meta.LocalObjectReference{Name: "missing-deployer"}},
+			}
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, pipeline))
+		defer cleanupResource(t, k8sClient, pipeline)
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pipeline)})
+		test.AssertErrorMatch(t, "failed to load deployer missing-deployer for stage dev", err)
+
+		reload(t, k8sClient, pipeline)
+		assertPipelineCondition(t, pipeline, metav1.ConditionFalse, deployerv1.FailedToLoadStageReason)
+	})
+
+	t.Run("promoting applied commits to the next stage", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		devKustomization := test.NewKustomization(test.NewGitRepository(), func(k *kustomizev1.Kustomization) {
+			k.Name = "dev-kustomization"
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, devKustomization))
+		defer cleanupResource(t, k8sClient, devKustomization)
+		devKustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[2]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, devKustomization))
+
+		prodKustomization := test.NewKustomization(test.NewGitRepository(), func(k *kustomizev1.Kustomization) {
+			k.Name = "prod-kustomization"
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, prodKustomization))
+		defer cleanupResource(t, k8sClient, prodKustomization)
+		prodKustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, prodKustomization))
+
+		devDeployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Name = "dev-deployer"
+			d.Spec.KustomizationRef.Name = devKustomization.Name
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, devDeployer))
+		defer cleanupResource(t, k8sClient, devDeployer)
+
+		prodDeployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Name = "prod-deployer"
+			d.Spec.KustomizationRef.Name = prodKustomization.Name
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, prodDeployer))
+		defer cleanupResource(t, k8sClient, prodDeployer)
+
+		pipeline := test.NewDeploymentPipeline()
+		test.AssertNoError(t, k8sClient.Create(ctx, pipeline))
+		defer cleanupResource(t, k8sClient, pipeline)
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pipeline)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, devDeployer)
+		if _, ok := devDeployer.GetAnnotations()[deployerv1.PromotedCommitAnnotation]; ok {
+			t.Errorf("first stage deployer should not have a promoted commit, got %v", devDeployer.GetAnnotations())
+		}
+
+		reload(t, k8sClient, prodDeployer)
+		if v := prodDeployer.GetAnnotations()[deployerv1.PromotedCommitAnnotation]; v != test.CommitIDs[2] {
+			t.Errorf("got promoted commit %q, want %q", v, test.CommitIDs[2])
+		}
+
+		reload(t, k8sClient, pipeline)
+		assertPipelineCondition(t, pipeline, metav1.ConditionTrue, deployerv1.PipelineReconciledReason)
+		want := []deployerv1.PipelineStageStatus{
+			{
+				Name:          "dev",
+				Deployer:      "dev-deployer",
+				AppliedCommit: "main@sha1:" + test.CommitIDs[2],
+				Message:       "deploying",
+			},
+			{
+				Name:           "production",
+				Deployer:       "prod-deployer",
+				AppliedCommit:  "main@sha1:" + test.CommitIDs[4],
+				PromotedCommit: "main@sha1:" + test.CommitIDs[2],
+				Message:        "deploying",
+			},
+		}
+		if diff := cmp.Diff(want, pipeline.Status.Stages); diff != "" {
+			t.Errorf("failed to record stages status:\n%s", diff)
+		}
+	})
+
+	t.Run("suspending the pipeline pauses all stages", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		kustomization := test.NewKustomization(test.NewGitRepository())
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+
+		deployer := test.NewKustomizationAutoDeployer()
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		pipeline := test.NewDeploymentPipeline(func(p *deployerv1.DeploymentPipeline) {
+			p.Spec.Suspend = true
+			p.Spec.Stages = []deployerv1.PipelineStage{
+				{Name: "dev", DeployerRef: meta.LocalObjectReference{Name: deployer.Name}},
+			}
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, pipeline))
+		defer cleanupResource(t, k8sClient, pipeline)
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pipeline)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		if v := deployer.GetAnnotations()[deployerv1.PipelinePausedAnnotation]; v != "true" {
+			t.Errorf("deployer not paused, got annotations %v", deployer.GetAnnotations())
+		}
+
+		reload(t, k8sClient, pipeline)
+		assertPipelineCondition(t, pipeline, metav1.ConditionFalse, deployerv1.PipelineSuspendedReason)
+	})
+
+	t.Run("removing a stage releases the deployer", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		kustomization := test.NewKustomization(test.NewGitRepository())
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+
+		devDeployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Name = "dev-deployer"
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, devDeployer))
+		defer cleanupResource(t, k8sClient, devDeployer)
+
+		prodDeployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Name = "prod-deployer"
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, prodDeployer))
+		defer cleanupResource(t, k8sClient, prodDeployer)
+
+		pipeline := test.NewDeploymentPipeline(func(p *deployerv1.DeploymentPipeline) {
+			p.Spec.Suspend = true
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, pipeline))
+		defer cleanupResource(t, k8sClient, pipeline)
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pipeline)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, prodDeployer)
+		assertHasAnnotations(t, prodDeployer, deployerv1.PipelinePausedAnnotation, deployerv1.PromotedCommitAnnotation)
+
+		reload(t, k8sClient, pipeline)
+		pipeline.Spec.Stages = pipeline.Spec.Stages[:1]
+		test.AssertNoError(t, k8sClient.Update(ctx, pipeline))
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pipeline)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, prodDeployer)
+		assertHasAnnotations(t, prodDeployer)
+		reload(t, k8sClient, devDeployer)
+		assertHasAnnotations(t, devDeployer, deployerv1.PipelinePausedAnnotation)
+	})
+
+	t.Run("deleting the pipeline releases the deployers", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		kustomization := test.NewKustomization(test.NewGitRepository())
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+
+		devDeployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Name = "dev-deployer"
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, devDeployer))
+		defer cleanupResource(t, k8sClient, devDeployer)
+
+		prodDeployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Name = "prod-deployer"
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, prodDeployer))
+		defer cleanupResource(t, k8sClient, prodDeployer)
+
+		pipeline := test.NewDeploymentPipeline(func(p *deployerv1.DeploymentPipeline) {
+			p.Spec.Suspend = true
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, pipeline))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pipeline)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, pipeline)
+		if !controllerutil.ContainsFinalizer(pipeline, deployerv1.DeploymentPipelineFinalizer) {
+			t.Fatalf("failed to add the finalizer, got %v", pipeline.GetFinalizers())
+		}
+		test.AssertNoError(t, k8sClient.Delete(ctx, pipeline))
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pipeline)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, devDeployer)
+		assertHasAnnotations(t, devDeployer)
+		reload(t, k8sClient, prodDeployer)
+		assertHasAnnotations(t, prodDeployer)
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(pipeline), pipeline); !apierrors.IsNotFound(err) {
+			t.Errorf("pipeline was not deleted, got %v", err)
+		}
+	})
+}
+
+func TestSummariseCommits(t *testing.T) {
+	stages := []deployerv1.PipelineStageStatus{
+		{Name: "dev", AppliedCommit: "main@sha1:" + test.CommitIDs[0]},
+		{Name: "staging", AppliedCommit: "main@sha1:" + test.CommitIDs[1]},
+		{Name: "qa", AppliedCommit: "main@sha1:" + test.CommitIDs[1]},
+		{Name: "production"},
+	}
+
+	want := []deployerv1.PipelineCommitStatus{
+		{Commit: "main@sha1:" + test.CommitIDs[0], Stages: []string{"dev"}},
+		{Commit: "main@sha1:" + test.CommitIDs[1], Stages: []string{"staging", "qa"}},
+	}
+	if diff := cmp.Diff(want, summariseCommits(stages)); diff != "" {
+		t.Errorf("failed to summarise commits:\n%s", diff)
+	}
+}
+
+// assertHasAnnotations asserts that the pipeline annotations on the deployer
+// are the wanted annotations.
+func assertHasAnnotations(t *testing.T, kd *deployerv1.KustomizationAutoDeployer, want ...string) {
+	t.Helper()
+	got := []string{}
+	for _, k := range []string{deployerv1.PipelinePausedAnnotation, deployerv1.PromotedCommitAnnotation} {
+		if _, ok := kd.GetAnnotations()[k]; ok {
+			got = append(got, k)
+		}
+	}
+	if diff := cmp.Diff(want, got, cmpopts.EquateEmpty(), cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("got pipeline annotations %v:\n%s", kd.GetAnnotations(), diff)
+	}
+}
+
+func assertPipelineCondition(t *testing.T, p *deployerv1.DeploymentPipeline, status metav1.ConditionStatus, wantReason string) {
+	t.Helper()
+	for _, cond := range p.Status.Conditions {
+		if cond.Type != meta.ReadyCondition {
+			continue
+		}
+		if cond.Status != status {
+			t.Errorf("got Status %s, want %s", cond.Status, status)
+		}
+		if cond.Reason != wantReason {
+			t.Errorf("got Reason %s, want %s", cond.Reason, wantReason)
+		}
+		return
+	}
+	t.Fatalf("failed to find Ready condition in %#v", p.Status.Conditions)
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+// policiesFor returns the DeploymentPolicies that select the deployer, sorted
+// by name.
+func (r *KustomizationAutoDeployerReconciler) policiesFor(ctx context.Context, deployer *deployerv1.KustomizationAutoDeployer) ([]deployerv1.DeploymentPolicy, error) {
+	var list deployerv1.DeploymentPolicyList
+	if err := r.List(ctx, &list); err != nil {
+		return nil, fmt.Errorf("failed to list DeploymentPolicies: %w", err)
+	}
+
+	selection := &deployerSelection{client: r.Client, deployer: deployer}
+	selected := []deployerv1.DeploymentPolicy{}
+	for _, policy := range list.Items {
+		matches, err := selection.matches(ctx, policy.Spec.NamespaceSelector, policy.Spec.DeployerSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to match DeploymentPolicy %s: %w", policy.GetName(), err)
+		}
+		if matches {
+			selected = append(selected, policy)
+		}
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].GetName() < selected[j].GetName()
+	})
+
+	return selected, nil
+}
+
+// allAutoDeployers enqueues all the deployers when a cluster resource that
+// selects deployers e.g. a DeploymentPolicy changes.
+//
+// This includes the deployers that are no longer selected by the resource, so
+// that their status is updated.
+func (r *KustomizationAutoDeployerReconciler) allAutoDeployers(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list deployerv1.KustomizationAutoDeployerList
+	if err := r.List(ctx, &list); err != nil {
+		return nil
+	}
+
+	result := []reconcile.Request{}
+	for _, v := range list.Items {
+		result = append(result, reconcile.Request{NamespacedName: types.NamespacedName{Name: v.GetName(), Namespace: v.GetNamespace()}})
+	}
+
+	return result
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"math"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/fluxcd/pkg/apis/meta"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+// maxRecentDeployments is the number of deployments kept in the status to
+// calculate the DORA metrics from.
+const maxRecentDeployments = 20
+
+// recordDeployment adds a deployment to the deployer's recent deployments and
+// updates the DORA metrics.
+//
+// A successful deployment restores any earlier failed deployments, failed
+// deployments are only recorded once per commit.
+//
+// Returns true if the deployment was recorded.
+func recordDeployment(deployer *deployerv1.KustomizationAutoDeployer, deployment deployerv1.DeploymentRecord, now time.Time) bool {
+	if deployer.Status.DORA == nil {
+		deployer.Status.DORA = &deployerv1.DORAStatus{}
+	}
+	dora := deployer.Status.DORA
+
+	if n := len(dora.RecentDeployments); n > 0 {
+		last := dora.RecentDeployments[n-1]
+		if deployment.Failed && last.Failed && last.Commit == deployment.Commit {
+			return false
+		}
+	}
+
+	namespace, name := deployer.GetNamespace(), deployer.GetName()
+	if deployment.Failed {
+		deploymentsTotal.WithLabelValues(namespace, name, "failure").Inc()
+	} else {
+		deploymentsTotal.WithLabelValues(namespace, name, "success").Inc()
+		if deployment.CommitTime != nil {
+			leadTime.WithLabelValues(namespace, name).Observe(deployment.DeployedTime.Sub(deployment.CommitTime.Time).Seconds())
+		}
+		for i := range dora.RecentDeployments {
+			failed := &dora.RecentDeployments[i]
+			if failed.Failed && failed.RestoredTime == nil {
+				failed.RestoredTime = deployment.DeployedTime.DeepCopy()
+				timeToRestore.WithLabelValues(namespace, name).Observe(failed.RestoredTime.Sub(failed.DeployedTime.Time).Seconds())
+			}
+		}
+	}
+
+	dora.RecentDeployments = append(dora.RecentDeployments, deployment)
+	if n := len(dora.RecentDeployments); n > maxRecentDeployments {
+		dora.RecentDeployments = dora.RecentDeployments[n-maxRecentDeployments:]
+	}
+
+	summariseDORA(dora, now)
+	deploymentFrequency.WithLabelValues(namespace, name).Set(deploymentsPerDay(dora.RecentDeployments, now))
+	changeFailureRatio.WithLabelValues(namespace, name).Set(changeFailureRate(dora.RecentDeployments))
+
+	return true
+}
+
+// hasUnrestoredFailure returns true if there is a failed deployment that has
+// not been followed by a successful deployment.
+func hasUnrestoredFailure(dora *deployerv1.DORAStatus) bool {
+	if dora == nil {
+		return false
+	}
+	for _, deployment := range dora.RecentDeployments {
+		if deployment.Failed && deployment.RestoredTime == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// summariseDORA calculates the DORA metrics from the recent deployments.
+func summariseDORA(dora *deployerv1.DORAStatus, now time.Time) {
+	var leadTimes, restoreTimes []time.Duration
+	for _, deployment := range dora.RecentDeployments {
+		if !deployment.Failed && deployment.CommitTime != nil {
+			leadTimes = append(leadTimes, deployment.DeployedTime.Sub(deployment.CommitTime.Time))
+		}
+		if deployment.Failed && deployment.RestoredTime != nil {
+			restoreTimes = append(restoreTimes, deployment.RestoredTime.Sub(deployment.DeployedTime.Time))
+		}
+	}
+
+	dora.LeadTimeForChanges = meanDuration(leadTimes)
+	dora.TimeToRestore = meanDuration(restoreTimes)
+	dora.DeploymentsPerDay = milliQuantity(deploymentsPerDay(dora.RecentDeployments, now))
+	dora.ChangeFailureRate = milliQuantity(changeFailureRate(dora.RecentDeployments))
+}
+
+// deployedTime returns when the Kustomization applied, or failed to apply, the
+// commit that the deployer advanced to, from the last transition of its Ready
+// condition.
+//
+// If the condition hasn't transitioned since the deployer advanced, now is
+// returned.
+func deployedTime(kustomization *kustomizev1.Kustomization, advanced *metav1.Time, now time.Time) metav1.Time {
+	ready := apimeta.FindStatusCondition(kustomization.Status.Conditions, meta.ReadyCondition)
+	if ready == nil || ready.LastTransitionTime.IsZero() || (advanced != nil && ready.LastTransitionTime.Before(advanced)) {
+		return metav1.NewTime(now)
+	}
+
+	return ready.LastTransitionTime
+}
+
+// deploymentsPerDay is the number of successful deployments per day since the
+// oldest recent deployment, with a minimum period of a day.
+func deploymentsPerDay(deployments []deployerv1.DeploymentRecord, now time.Time) float64 {
+	if len(deployments) == 0 {
+		return 0
+	}
+
+	successful := 0
+	for _, deployment := range deployments {
+		if !deployment.Failed {
+			successful++
+		}
+	}
+
+	period := now.Sub(deployments[0].DeployedTime.Time)
+	if period < time.Hour*24 {
+		period = time.Hour * 24
+	}
+
+	return float64(successful) / period.Hours() * 24
+}
+
+func changeFailureRate(deployments []deployerv1.DeploymentRecord) float64 {
+	if len(deployments) == 0 {
+		return 0
+	}
+
+	failed := 0
+	for _, deployment := range deployments {
+		if deployment.Failed {
+			failed++
+		}
+	}
+
+	return float64(failed) / float64(len(deployments))
+}
+
+// milliQuantity rounds f to a Quantity with millis precision.
+func milliQuantity(f float64) *resource.Quantity {
+	return resource.NewMilliQuantity(int64(math.Round(f*1000)), resource.DecimalSI)
+}
+
+func meanDuration(durations []time.Duration) *metav1.Duration {
+	if len(durations) == 0 {
+		return nil
+	}
+
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+
+	return &metav1.Duration{Duration: (total / time.Duration(len(durations))).Round(time.Second)}
+}
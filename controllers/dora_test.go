@@ -0,0 +1,169 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/test"
+)
+
+func TestSummariseDORA(t *testing.T) {
+	now := time.Date(2023, time.May, 14, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *metav1.Time {
+		return &metav1.Time{Time: now.Add(-d)}
+	}
+
+	summaryTests := []struct {
+		name        string
+		deployments []deployerv1.DeploymentRecord
+		want        *deployerv1.DORAStatus
+	}{
+		{
+			name: "no deployments",
+			want: &deployerv1.DORAStatus{DeploymentsPerDay: quantity("0"), ChangeFailureRate: quantity("0")},
+		},
+		{
+			name: "successful deployments",
+			deployments: []deployerv1.DeploymentRecord{
+				{Commit: test.CommitIDs[2], CommitTime: at(time.Hour * 50), DeployedTime: *at(time.Hour * 48)},
+				{Commit: test.CommitIDs[1], CommitTime: at(time.Hour * 28), DeployedTime: *at(time.Hour * 24)},
+				{Commit: test.CommitIDs[0], DeployedTime: *at(time.Hour)},
+			},
+			want: &deployerv1.DORAStatus{
+				LeadTimeForChanges: &metav1.Duration{Duration: time.Hour * 3},
+				DeploymentsPerDay:  quantity("1.5"),
+				ChangeFailureRate:  quantity("0"),
+			},
+		},
+		{
+			name: "failed and restored deployments",
+			deployments: []deployerv1.DeploymentRecord{
+				{Commit: test.CommitIDs[2], CommitTime: at(time.Hour * 5), DeployedTime: *at(time.Hour * 4)},
+				{Commit: test.CommitIDs[1], DeployedTime: *at(time.Hour * 3), Failed: true, RestoredTime: at(time.Hour)},
+				{Commit: test.CommitIDs[0], CommitTime: at(time.Hour * 2), DeployedTime: *at(time.Hour)},
+				{Commit: test.CommitIDs[3], DeployedTime: *at(time.Minute), Failed: true},
+			},
+			want: &deployerv1.DORAStatus{
+				LeadTimeForChanges: &metav1.Duration{Duration: time.Hour},
+				DeploymentsPerDay:  quantity("2"),
+				ChangeFailureRate:  quantity("0.5"),
+				TimeToRestore:      &metav1.Duration{Duration: time.Hour * 2},
+			},
+		},
+	}
+
+	for _, tt := range summaryTests {
+		t.Run(tt.name, func(t *testing.T) {
+			dora := &deployerv1.DORAStatus{RecentDeployments: tt.deployments}
+			summariseDORA(dora, now)
+			dora.RecentDeployments = nil
+
+			if diff := cmp.Diff(tt.want, dora, cmp.Comparer(quantityEqual)); diff != "" {
+				t.Errorf("failed to summarise deployments:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDeployedTime(t *testing.T) {
+	now := time.Date(2023, time.May, 14, 9, 0, 0, 0, time.UTC)
+	advanced := metav1.NewTime(now.Add(-time.Hour))
+	readyAt := func(d time.Duration) *kustomizev1.Kustomization {
+		k := &kustomizev1.Kustomization{}
+		k.Status.Conditions = []metav1.Condition{
+			{Type: meta.ReadyCondition, Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-d))},
+		}
+		return k
+	}
+
+	deployedTests := []struct {
+		name          string
+		kustomization *kustomizev1.Kustomization
+		want          time.Time
+	}{
+		{
+			name:          "no Ready condition",
+			kustomization: &kustomizev1.Kustomization{},
+			want:          now,
+		},
+		{
+			name:          "Ready after advancing",
+			kustomization: readyAt(time.Minute * 10),
+			want:          now.Add(-time.Minute * 10),
+		},
+		{
+			name:          "Ready before advancing",
+			kustomization: readyAt(time.Hour * 2),
+			want:          now,
+		},
+	}
+
+	for _, tt := range deployedTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deployedTime(tt.kustomization, &advanced, now); !got.Time.Equal(tt.want) {
+				t.Errorf("got deployed time %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordDeployment(t *testing.T) {
+	now := time.Date(2023, time.May, 14, 9, 0, 0, 0, time.UTC)
+	deployer := test.NewKustomizationAutoDeployer()
+
+	failed := deployerv1.DeploymentRecord{Commit: test.CommitIDs[1], DeployedTime: metav1.NewTime(now.Add(-time.Hour)), Failed: true}
+	if !recordDeployment(deployer, failed, now) {
+		t.Fatal("failed to record the failed deployment")
+	}
+	if recordDeployment(deployer, failed, now) {
+		t.Fatal("recorded the same failed deployment twice")
+	}
+
+	restored := deployerv1.DeploymentRecord{Commit: test.CommitIDs[2], DeployedTime: metav1.NewTime(now)}
+	if !recordDeployment(deployer, restored, now) {
+		t.Fatal("failed to record the successful deployment")
+	}
+
+	want := []deployerv1.DeploymentRecord{
+		{Commit: test.CommitIDs[1], DeployedTime: metav1.NewTime(now.Add(-time.Hour)), Failed: true, RestoredTime: &metav1.Time{Time: now}},
+		restored,
+	}
+	if diff := cmp.Diff(want, deployer.Status.DORA.RecentDeployments); diff != "" {
+		t.Errorf("failed to record deployments:\n%s", diff)
+	}
+	if hasUnrestoredFailure(deployer.Status.DORA) {
+		t.Error("failed deployment was not restored")
+	}
+}
+
+func quantity(s string) *resource.Quantity {
+	q := resource.MustParse(s)
+	return &q
+}
+
+func quantityEqual(a, b resource.Quantity) bool {
+	return a.Cmp(b) == 0
+}
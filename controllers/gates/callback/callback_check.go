@@ -0,0 +1,367 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package callback
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/gateplugin"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/git"
+)
+
+// DefaultTimeout is how long to wait for a callback if the gate does not
+// configure a timeout.
+const DefaultTimeout = time.Hour
+
+// maxErrorBody limits the amount of an error response that is included in
+// errors.
+const maxErrorBody = 1024
+
+// Request is POSTed to the external system to request a callback.
+type Request struct {
+	// ID must be provided in the callback.
+	ID string `json:"id"`
+
+	// Secret must be provided in the callback, it is only sent in this
+	// request.
+	Secret string `json:"secret"`
+
+	// CallbackURL is the URL to POST the Callback to.
+	CallbackURL string `json:"callbackURL"`
+
+	// Deployer is the KustomizationAutoDeployer that requested the callback.
+	Deployer ObjectReference `json:"deployer"`
+
+	// Gate is the name of the gate that requested the callback.
+	Gate string `json:"gate"`
+
+	// Candidate is the commit that will be deployed if the gates are open.
+	Candidate gateplugin.Commit `json:"candidate"`
+
+	// Current is the commit that is currently deployed.
+	Current gateplugin.Commit `json:"current"`
+}
+
+// ObjectReference identifies a KustomizationAutoDeployer.
+type ObjectReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// Factory is a function for creating per-reconciliation gates for
+// the CallbackGate.
+//
+// Callbacks are sent to the callbackURL which must route to the Handler.
+func Factory(httpClient *http.Client, callbackURL string) gates.GateFactory {
+	return func(l logr.Logger, c client.Client) gates.Gate {
+		return New(l, c, httpClient, callbackURL)
+	}
+}
+
+// New creates and returns a new CallbackGate.
+func New(l logr.Logger, c client.Client, httpClient *http.Client, callbackURL string, opts ...func(*CallbackGate)) *CallbackGate {
+	cg := &CallbackGate{
+		Logger:      l,
+		Client:      c,
+		HTTPClient:  httpClient,
+		CallbackURL: callbackURL,
+		Clock:       time.Now,
+		NewID:       newID,
+		NewSecret:   newSecret,
+		requeue:     map[string]time.Duration{},
+	}
+
+	for _, opt := range opts {
+		opt(cg)
+	}
+
+	return cg
+}
+
+// CallbackGate requests a callback from an external system for the candidate
+// commit, and is open when the callback passes the commit.
+//
+// The requested callback is recorded in the status of the deployer, and a new
+// callback is requested if the candidate commit changes, or the callback times
+// out.
+//
+// Only the hash of the secret sent in the request is recorded, the Handler
+// rejects callbacks that don't provide the secret.
+//
+// The callback is saved in the status with the Client before it is requested,
+// so that the Handler can record callbacks that arrive before the
+// reconciliation of the deployer completes, and a callback is not requested
+// again if the reconciliation fails.
+type CallbackGate struct {
+	Logger      logr.Logger
+	Client      client.Client
+	HTTPClient  *http.Client
+	CallbackURL string
+	Clock       func() time.Time
+	NewID       func() (string, error)
+	NewSecret   func() (string, error)
+
+	mu      sync.Mutex
+	requeue map[string]time.Duration
+}
+
+// Check returns the result of the callback for the candidate commit,
+// requesting a callback if necessary.
+func (g *CallbackGate) Check(ctx context.Context, req gates.CheckRequest) (gates.Result, error) {
+	gate := req.Gate
+	now := g.Clock()
+	timeout := DefaultTimeout
+	if gate.Callback.Timeout != nil {
+		timeout = gate.Callback.Timeout.Duration
+	}
+
+	message := ""
+	previous := FindCallback(req.Deployer.Status.Callbacks, gate.Name)
+	if previous != nil && previous.Commit == req.Candidate.ID {
+		switch previous.Result {
+		case deployerv1.CallbackPassed:
+			return gates.Result{Open: true, Message: callbackMessage(previous, "passed")}, nil
+		case deployerv1.CallbackFailed:
+			return gates.Result{Open: false, Message: callbackMessage(previous, "failed")}, nil
+		}
+
+		if deadline := previous.RequestedTime.Add(timeout); now.Before(deadline) {
+			g.setRequeue(gate.Name, deadline.Sub(now))
+			return gates.Result{Open: false, Message: fmt.Sprintf("waiting for callback %s", previous.ID)}, nil
+		}
+		g.Logger.Info("callback timed out", "gate", gate.Name, "id", previous.ID)
+		message = fmt.Sprintf("callback %s timed out, ", previous.ID)
+	}
+
+	id, err := g.NewID()
+	if err != nil {
+		return gates.Result{}, fmt.Errorf("failed to generate callback ID: %w", err)
+	}
+	secret, err := g.NewSecret()
+	if err != nil {
+		return gates.Result{}, fmt.Errorf("failed to generate callback secret: %w", err)
+	}
+
+	if g.CallbackURL == "" {
+		return gates.Result{}, fmt.Errorf("no callback URL is configured for gate %s", gate.Name)
+	}
+
+	pending := deployerv1.CallbackStatus{
+		Gate:          gate.Name,
+		ID:            id,
+		SecretHash:    hashSecret(secret),
+		Commit:        req.Candidate.ID,
+		RequestedTime: metav1.NewTime(now),
+		Result:        deployerv1.CallbackPending,
+	}
+	if err := g.updateCallbacks(ctx, req.Deployer, func(status *deployerv1.KustomizationAutoDeployerStatus) {
+		setCallback(status, pending)
+	}); err != nil {
+		return gates.Result{}, fmt.Errorf("failed to save callback %s: %w", id, err)
+	}
+
+	if err := g.requestCallback(ctx, gate, Request{
+		ID:          id,
+		Secret:      secret,
+		CallbackURL: CallbackURLFor(g.CallbackURL, req.Deployer),
+		Deployer:    ObjectReference{Name: req.Deployer.GetName(), Namespace: req.Deployer.GetNamespace()},
+		Gate:        gate.Name,
+		Candidate:   commit(req.Candidate),
+		Current:     commit(req.Current),
+	}); err != nil {
+		// The callback is requested again when the gate is next checked.
+		if removeErr := g.updateCallbacks(ctx, req.Deployer, func(status *deployerv1.KustomizationAutoDeployerStatus) {
+			removeCallback(status, pending)
+		}); removeErr != nil {
+			g.Logger.Error(removeErr, "failed to remove the callback", "gate", gate.Name, "id", id)
+		}
+		return gates.Result{}, err
+	}
+
+	req.UpdateStatus(func(status *deployerv1.KustomizationAutoDeployerStatus) {
+		setCallback(status, pending)
+	})
+	g.setRequeue(gate.Name, timeout)
+
+	return gates.Result{Open: false, Message: message + fmt.Sprintf("requested callback %s", id)}, nil
+}
+
+// Interval returns the time until the pending callback times out.
+func (g *CallbackGate) Interval(gate *deployerv1.KustomizationGate) (time.Duration, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.requeue[gate.Name], nil
+}
+
+func (g *CallbackGate) setRequeue(name string, d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.requeue[name] = d
+}
+
+// Cacheable implements the gates.CacheableGate interface, the results are
+// recorded in the status when the callback is received, and must not be
+// cached.
+func (g *CallbackGate) Cacheable() bool {
+	return false
+}
+
+// updateCallbacks applies a change to the callbacks in the status of the
+// deployer in the cluster.
+func (g *CallbackGate) updateCallbacks(ctx context.Context, deployer *deployerv1.KustomizationAutoDeployer, f func(*deployerv1.KustomizationAutoDeployerStatus)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var latest deployerv1.KustomizationAutoDeployer
+		if err := g.Client.Get(ctx, client.ObjectKeyFromObject(deployer), &latest); err != nil {
+			return err
+		}
+		f(&latest.Status)
+
+		return g.Client.Status().Update(ctx, &latest)
+	})
+}
+
+func (g *CallbackGate) requestCallback(ctx context.Context, gate *deployerv1.KustomizationGate, callbackReq Request) error {
+	b, err := json.Marshal(callbackReq)
+	if err != nil {
+		return fmt.Errorf("failed to marshal callback request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, gate.Callback.URL, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to create callback request for %s: %w", gate.Callback.URL, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	g.Logger.Info("requesting callback", "gate", gate.Name, "url", gate.Callback.URL, "id", callbackReq.ID)
+
+	resp, err := g.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request callback from %s: %w", gate.Callback.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("callback request to %s returned %s: %s", gate.Callback.URL, resp.Status, bytes.TrimSpace(body))
+	}
+
+	return nil
+}
+
+// CallbackURLFor returns the URL that callbacks for the deployer are sent to.
+func CallbackURLFor(base string, deployer *deployerv1.KustomizationAutoDeployer) string {
+	u, err := url.JoinPath(base, "callbacks", deployer.GetNamespace(), deployer.GetName())
+	if err != nil {
+		return ""
+	}
+
+	return u
+}
+
+// FindCallback returns the callback requested by the named gate.
+func FindCallback(callbacks []deployerv1.CallbackStatus, gateName string) *deployerv1.CallbackStatus {
+	for i := range callbacks {
+		if callbacks[i].Gate == gateName {
+			return &callbacks[i]
+		}
+	}
+
+	return nil
+}
+
+func setCallback(status *deployerv1.KustomizationAutoDeployerStatus, callback deployerv1.CallbackStatus) {
+	if existing := FindCallback(status.Callbacks, callback.Gate); existing != nil {
+		*existing = callback
+		return
+	}
+
+	status.Callbacks = append(status.Callbacks, callback)
+}
+
+// removeCallback removes the callback from the status if it has not been
+// replaced.
+func removeCallback(status *deployerv1.KustomizationAutoDeployerStatus, callback deployerv1.CallbackStatus) {
+	for i := range status.Callbacks {
+		if status.Callbacks[i].Gate == callback.Gate && status.Callbacks[i].ID == callback.ID {
+			status.Callbacks = append(status.Callbacks[:i], status.Callbacks[i+1:]...)
+			return
+		}
+	}
+}
+
+func callbackMessage(callback *deployerv1.CallbackStatus, result string) string {
+	if callback.Message == "" {
+		return fmt.Sprintf("callback %s %s", callback.ID, result)
+	}
+
+	return fmt.Sprintf("callback %s %s: %s", callback.ID, result, callback.Message)
+}
+
+func commit(r git.Revision) gateplugin.Commit {
+	return gateplugin.Commit{
+		ID:      r.ID,
+		Time:    r.Time,
+		Author:  r.Author,
+		Message: r.Message,
+	}
+}
+
+func newID() (string, error) {
+	return randomHex(16)
+}
+
+func newSecret() (string, error) {
+	return randomHex(32)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// hashSecret returns the hex encoded SHA-256 hash of the secret.
+func hashSecret(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package callback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/gateplugin"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/git"
+	"github.com/gitops-tools/kustomization-auto-deployer/test"
+)
+
+var _ gates.Gate = (*CallbackGate)(nil)
+
+// 9am on the 14th May 2023
+var now = time.Date(2023, time.May, 14, 9, 0, 0, 0, time.UTC)
+
+var (
+	candidate = git.Revision{ID: "6f935147b28e38a99a700843e4893a801c3c8148", Time: now.Add(-time.Hour), Author: "Test User <test@example.com>", Message: "Add feature"}
+	current   = git.Revision{ID: "dd587268153ad335545a53f15efee4ecfabcd1c8", Time: now.Add(-time.Hour * 2)}
+)
+
+func TestCallbackGate_Check_requests_callback(t *testing.T) {
+	var received []Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		received = append(received, req)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	deployer := test.NewKustomizationAutoDeployer()
+	gate := newCallbackGate(ts.URL)
+	c := newFakeClient(t, deployer)
+	cg := newTestGate(c, ts.Client())
+
+	res, err := cg.Check(context.TODO(), gates.CheckRequest{Gate: gate, Deployer: deployer, Candidate: candidate, Current: current})
+	test.AssertNoError(t, err)
+
+	if want := (gates.Result{Open: false, Message: "requested callback callback-1"}); res != want {
+		t.Fatalf("got %#v, want %#v", res, want)
+	}
+
+	wantRequests := []Request{
+		{
+			ID:          "callback-1",
+			Secret:      "secret-1",
+			CallbackURL: "http://callbacks.example.com/callbacks/" + deployer.GetNamespace() + "/" + deployer.GetName(),
+			Deployer:    ObjectReference{Name: deployer.GetName(), Namespace: deployer.GetNamespace()},
+			Gate:        "integration tests",
+			Candidate:   gateplugin.Commit{ID: candidate.ID, Time: candidate.Time, Author: candidate.Author, Message: candidate.Message},
+			Current:     gateplugin.Commit{ID: current.ID, Time: current.Time},
+		},
+	}
+	if diff := cmp.Diff(wantRequests, received); diff != "" {
+		t.Fatalf("failed to request the callback:\n%s", diff)
+	}
+
+	wantStatus := []deployerv1.CallbackStatus{
+		{
+			Gate:          "integration tests",
+			ID:            "callback-1",
+			SecretHash:    hashSecret("secret-1"),
+			Commit:        candidate.ID,
+			RequestedTime: metav1.NewTime(now),
+			Result:        deployerv1.CallbackPending,
+		},
+	}
+	if diff := cmp.Diff(wantStatus, deployer.Status.Callbacks); diff != "" {
+		t.Fatalf("failed to record the callback:\n%s", diff)
+	}
+	var saved deployerv1.KustomizationAutoDeployer
+	test.AssertNoError(t, c.Get(context.TODO(), client.ObjectKeyFromObject(deployer), &saved))
+	if diff := cmp.Diff(wantStatus, saved.Status.Callbacks); diff != "" {
+		t.Fatalf("failed to save the callback:\n%s", diff)
+	}
+
+	interval, err := cg.Interval(gate)
+	test.AssertNoError(t, err)
+	if interval != time.Minute*20 {
+		t.Errorf("Interval() got %v, want %v", interval, time.Minute*20)
+	}
+}
+
+func TestCallbackGate_Check(t *testing.T) {
+	checkTests := []struct {
+		name         string
+		callback     deployerv1.CallbackStatus
+		want         gates.Result
+		wantRequests int
+		wantInterval time.Duration
+	}{
+		{
+			name:         "pending callback",
+			callback:     deployerv1.CallbackStatus{Commit: candidate.ID, RequestedTime: metav1.NewTime(now.Add(-time.Minute * 5)), Result: deployerv1.CallbackPending},
+			want:         gates.Result{Open: false, Message: "waiting for callback callback-0"},
+			wantInterval: time.Minute * 15,
+		},
+		{
+			name:     "passed callback",
+			callback: deployerv1.CallbackStatus{Commit: candidate.ID, RequestedTime: metav1.NewTime(now.Add(-time.Minute * 5)), Result: deployerv1.CallbackPassed, Message: "all tests passed"},
+			want:     gates.Result{Open: true, Message: "callback callback-0 passed: all tests passed"},
+		},
+		{
+			name:     "failed callback",
+			callback: deployerv1.CallbackStatus{Commit: candidate.ID, RequestedTime: metav1.NewTime(now.Add(-time.Minute * 5)), Result: deployerv1.CallbackFailed},
+			want:     gates.Result{Open: false, Message: "callback callback-0 failed"},
+		},
+		{
+			name:         "timed out callback",
+			callback:     deployerv1.CallbackStatus{Commit: candidate.ID, RequestedTime: metav1.NewTime(now.Add(-time.Minute * 25)), Result: deployerv1.CallbackPending},
+			want:         gates.Result{Open: false, Message: "callback callback-0 timed out, requested callback callback-1"},
+			wantRequests: 1,
+			wantInterval: time.Minute * 20,
+		},
+		{
+			name:         "callback for a different commit",
+			callback:     deployerv1.CallbackStatus{Commit: current.ID, RequestedTime: metav1.NewTime(now.Add(-time.Minute * 5)), Result: deployerv1.CallbackPassed},
+			want:         gates.Result{Open: false, Message: "requested callback callback-1"},
+			wantRequests: 1,
+			wantInterval: time.Minute * 20,
+		},
+	}
+
+	for _, tt := range checkTests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+			}))
+			defer ts.Close()
+
+			tt.callback.Gate = "integration tests"
+			tt.callback.ID = "callback-0"
+			deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Status.Callbacks = []deployerv1.CallbackStatus{tt.callback}
+			})
+			gate := newCallbackGate(ts.URL)
+			cg := newTestGate(newFakeClient(t, deployer), ts.Client())
+
+			res, err := cg.Check(context.TODO(), gates.CheckRequest{Gate: gate, Deployer: deployer, Candidate: candidate, Current: current})
+			test.AssertNoError(t, err)
+
+			if res != tt.want {
+				t.Errorf("got %#v, want %#v", res, tt.want)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("got %d requests, want %d", requests, tt.wantRequests)
+			}
+			if len(deployer.Status.Callbacks) != 1 {
+				t.Errorf("got %d callbacks in the status, want 1", len(deployer.Status.Callbacks))
+			}
+			interval, err := cg.Interval(gate)
+			test.AssertNoError(t, err)
+			if interval != tt.wantInterval {
+				t.Errorf("Interval() got %v, want %v", interval, tt.wantInterval)
+			}
+		})
+	}
+}
+
+func TestCallbackGate_Check_errors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "test platform unavailable", http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	deployer := test.NewKustomizationAutoDeployer()
+	c := newFakeClient(t, deployer)
+	cg := newTestGate(c, ts.Client())
+
+	_, err := cg.Check(context.TODO(), gates.CheckRequest{Gate: newCallbackGate(ts.URL), Deployer: deployer, Candidate: candidate})
+
+	test.AssertErrorMatch(t, "returned 503 Service Unavailable: test platform unavailable", err)
+	if len(deployer.Status.Callbacks) != 0 {
+		t.Errorf("failed request recorded callbacks: %v", deployer.Status.Callbacks)
+	}
+	var saved deployerv1.KustomizationAutoDeployer
+	test.AssertNoError(t, c.Get(context.TODO(), client.ObjectKeyFromObject(deployer), &saved))
+	if len(saved.Status.Callbacks) != 0 {
+		t.Errorf("failed request left saved callbacks: %v", saved.Status.Callbacks)
+	}
+}
+
+func TestCallbackGate_Check_callback_before_reconciliation_completes(t *testing.T) {
+	deployer := test.NewKustomizationAutoDeployer()
+	c := newFakeClient(t, deployer)
+
+	// The external system calls back before responding to the request.
+	handler := Handler(c, logr.Discard())
+	var callbackStatus int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		callback := httptest.NewRequest(http.MethodPost, "/callbacks/"+req.Deployer.Namespace+"/"+req.Deployer.Name, strings.NewReader(`{"id":"`+req.ID+`","secret":"`+req.Secret+`","passed":true}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, callback)
+		callbackStatus = rec.Code
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	cg := newTestGate(c, ts.Client())
+	_, err := cg.Check(context.TODO(), gates.CheckRequest{Gate: newCallbackGate(ts.URL), Deployer: deployer, Candidate: candidate, Current: current})
+	test.AssertNoError(t, err)
+
+	if callbackStatus != http.StatusNoContent {
+		t.Fatalf("got callback status %d, want %d", callbackStatus, http.StatusNoContent)
+	}
+	var saved deployerv1.KustomizationAutoDeployer
+	test.AssertNoError(t, c.Get(context.TODO(), client.ObjectKeyFromObject(deployer), &saved))
+	if result := saved.Status.Callbacks[0].Result; result != deployerv1.CallbackPassed {
+		t.Errorf("got result %s, want %s", result, deployerv1.CallbackPassed)
+	}
+}
+
+func TestCallbackGate_Check_no_callback_url(t *testing.T) {
+	cg := New(logr.Discard(), nil, http.DefaultClient, "")
+
+	_, err := cg.Check(context.TODO(), gates.CheckRequest{Gate: newCallbackGate("http://example.com/"), Deployer: test.NewKustomizationAutoDeployer()})
+
+	test.AssertErrorMatch(t, "no callback URL is configured for gate integration tests", err)
+}
+
+func newCallbackGate(u string) *deployerv1.KustomizationGate {
+	return &deployerv1.KustomizationGate{
+		Name: "integration tests",
+		Callback: &deployerv1.CallbackCheck{
+			URL:     u,
+			Timeout: &metav1.Duration{Duration: time.Minute * 20},
+		},
+	}
+}
+
+func newTestGate(c client.Client, httpClient *http.Client) *CallbackGate {
+	ids := 0
+	return New(logr.Discard(), c, httpClient, "http://callbacks.example.com", func(g *CallbackGate) {
+		g.Clock = func() time.Time { return now }
+		g.NewID = func() (string, error) {
+			ids++
+			return fmt.Sprintf("callback-%d", ids), nil
+		}
+		g.NewSecret = func() (string, error) {
+			return fmt.Sprintf("secret-%d", ids), nil
+		}
+	})
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package callback
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+// maxCallbackBody limits the size of callbacks accepted by the Handler.
+const maxCallbackBody = 1 << 20
+
+// Callback is POSTed by the external system to the CallbackURL.
+type Callback struct {
+	// ID is the ID from the Request.
+	ID string `json:"id"`
+
+	// Secret is the Secret from the Request.
+	Secret string `json:"secret"`
+
+	// Passed is true if the commit can be deployed.
+	Passed bool `json:"passed"`
+
+	// Message is recorded in the status of the deployer.
+	Message string `json:"message,omitempty"`
+}
+
+var (
+	errUnknownCallback   = errors.New("unknown callback")
+	errCallbackCompleted = errors.New("callback already completed")
+	errInvalidSecret     = errors.New("invalid callback secret")
+)
+
+// Handler returns an http.Handler that records callbacks in the status of
+// KustomizationAutoDeployers, and requests a reconciliation of the deployer.
+//
+// Callbacks are rejected unless they provide the secret that was sent in the
+// request for the callback.
+func Handler(c client.Client, logger logr.Logger) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /callbacks/{namespace}/{name}", func(w http.ResponseWriter, r *http.Request) {
+		key := types.NamespacedName{Namespace: r.PathValue("namespace"), Name: r.PathValue("name")}
+
+		var callback Callback
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCallbackBody)).Decode(&callback); err != nil {
+			http.Error(w, fmt.Sprintf("failed to decode callback: %s", err), http.StatusBadRequest)
+			return
+		}
+		if callback.ID == "" {
+			http.Error(w, "callback has no id", http.StatusBadRequest)
+			return
+		}
+
+		err := recordCallback(r.Context(), c, key, callback)
+		switch {
+		case err == nil:
+			logger.Info("callback received", "deployer", key, "id", callback.ID, "passed", callback.Passed)
+		case apierrors.IsNotFound(err), errors.Is(err, errUnknownCallback):
+			http.Error(w, fmt.Sprintf("unknown callback %s for %s", callback.ID, key), http.StatusNotFound)
+			return
+		case errors.Is(err, errInvalidSecret):
+			http.Error(w, fmt.Sprintf("invalid secret for callback %s for %s", callback.ID, key), http.StatusForbidden)
+			return
+		case errors.Is(err, errCallbackCompleted):
+			http.Error(w, fmt.Sprintf("callback %s for %s has already completed", callback.ID, key), http.StatusConflict)
+			return
+		default:
+			logger.Error(err, "failed to record callback", "deployer", key, "id", callback.ID)
+			http.Error(w, "failed to record callback", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return mux
+}
+
+func recordCallback(ctx context.Context, c client.Client, key types.NamespacedName, callback Callback) error {
+	result := deployerv1.CallbackFailed
+	if callback.Passed {
+		result = deployerv1.CallbackPassed
+	}
+
+	var deployer deployerv1.KustomizationAutoDeployer
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := c.Get(ctx, key, &deployer); err != nil {
+			return err
+		}
+
+		status := findCallbackByID(deployer.Status.Callbacks, callback.ID)
+		if status == nil {
+			return errUnknownCallback
+		}
+		if !validSecret(status, callback.Secret) {
+			return errInvalidSecret
+		}
+		if status.Result != deployerv1.CallbackPending {
+			if status.Result == result && status.Message == callback.Message {
+				return nil
+			}
+			return errCallbackCompleted
+		}
+
+		now := metav1.Now()
+		status.Result = result
+		status.Message = callback.Message
+		status.CompletedTime = &now
+
+		return c.Status().Update(ctx, &deployer)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Changes to the status do not trigger a reconciliation of the deployer.
+	patch := client.MergeFrom(deployer.DeepCopy())
+	annotations := deployer.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
+	deployer.SetAnnotations(annotations)
+
+	return c.Patch(ctx, &deployer, patch)
+}
+
+// validSecret returns true if the hash of the secret matches the hash
+// recorded for the callback, callbacks without a hash are never valid.
+func validSecret(status *deployerv1.CallbackStatus, secret string) bool {
+	if status.SecretHash == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(status.SecretHash)) == 1
+}
+
+func findCallbackByID(callbacks []deployerv1.CallbackStatus, id string) *deployerv1.CallbackStatus {
+	for i := range callbacks {
+		if callbacks[i].ID == id {
+			return &callbacks[i]
+		}
+	}
+
+	return nil
+}
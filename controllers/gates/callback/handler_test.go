@@ -0,0 +1,209 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package callback
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/test"
+)
+
+func TestHandler(t *testing.T) {
+	handlerTests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		result     deployerv1.CallbackResult
+		wantStatus int
+		wantResult deployerv1.CallbackResult
+	}{
+		{
+			name:       "passing callback",
+			body:       `{"id":"callback-1","secret":"secret-1","passed":true,"message":"all tests passed"}`,
+			result:     deployerv1.CallbackPending,
+			wantStatus: http.StatusNoContent,
+			wantResult: deployerv1.CallbackPassed,
+		},
+		{
+			name:       "failing callback",
+			body:       `{"id":"callback-1","secret":"secret-1","passed":false}`,
+			result:     deployerv1.CallbackPending,
+			wantStatus: http.StatusNoContent,
+			wantResult: deployerv1.CallbackFailed,
+		},
+		{
+			name:       "repeated callback",
+			body:       `{"id":"callback-1","secret":"secret-1","passed":true,"message":"all tests passed"}`,
+			result:     deployerv1.CallbackPassed,
+			wantStatus: http.StatusNoContent,
+			wantResult: deployerv1.CallbackPassed,
+		},
+		{
+			name:       "conflicting callback",
+			body:       `{"id":"callback-1","secret":"secret-1","passed":false}`,
+			result:     deployerv1.CallbackPassed,
+			wantStatus: http.StatusConflict,
+			wantResult: deployerv1.CallbackPassed,
+		},
+		{
+			name:       "wrong secret",
+			body:       `{"id":"callback-1","secret":"secret-2","passed":true}`,
+			result:     deployerv1.CallbackPending,
+			wantStatus: http.StatusForbidden,
+			wantResult: deployerv1.CallbackPending,
+		},
+		{
+			name:       "missing secret",
+			body:       `{"id":"callback-1","passed":true}`,
+			result:     deployerv1.CallbackPending,
+			wantStatus: http.StatusForbidden,
+			wantResult: deployerv1.CallbackPending,
+		},
+		{
+			name:       "unknown callback",
+			body:       `{"id":"callback-2","secret":"secret-1","passed":true}`,
+			result:     deployerv1.CallbackPending,
+			wantStatus: http.StatusNotFound,
+			wantResult: deployerv1.CallbackPending,
+		},
+		{
+			name:       "unknown deployer",
+			path:       "/callbacks/default/unknown-deployer",
+			body:       `{"id":"callback-1","secret":"secret-1","passed":true}`,
+			result:     deployerv1.CallbackPending,
+			wantStatus: http.StatusNotFound,
+			wantResult: deployerv1.CallbackPending,
+		},
+		{
+			name:       "invalid callback",
+			body:       `{"id":`,
+			result:     deployerv1.CallbackPending,
+			wantStatus: http.StatusBadRequest,
+			wantResult: deployerv1.CallbackPending,
+		},
+		{
+			name:       "missing ID",
+			body:       `{"passed":true}`,
+			result:     deployerv1.CallbackPending,
+			wantStatus: http.StatusBadRequest,
+			wantResult: deployerv1.CallbackPending,
+		},
+		{
+			name:       "other methods",
+			method:     http.MethodGet,
+			result:     deployerv1.CallbackPending,
+			wantStatus: http.StatusMethodNotAllowed,
+			wantResult: deployerv1.CallbackPending,
+		},
+	}
+
+	for _, tt := range handlerTests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Status.Callbacks = []deployerv1.CallbackStatus{
+					{
+						Gate:          "integration tests",
+						ID:            "callback-1",
+						SecretHash:    hashSecret("secret-1"),
+						Commit:        candidate.ID,
+						RequestedTime: metav1.NewTime(now),
+						Result:        tt.result,
+					},
+				}
+				if tt.result != deployerv1.CallbackPending {
+					d.Status.Callbacks[0].Message = "all tests passed"
+				}
+			})
+			c := newFakeClient(t, deployer)
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			path := tt.path
+			if path == "" {
+				path = "/callbacks/" + deployer.GetNamespace() + "/" + deployer.GetName()
+			}
+			req := httptest.NewRequest(method, path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			Handler(c, logr.Discard()).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("got status code %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			var updated deployerv1.KustomizationAutoDeployer
+			test.AssertNoError(t, c.Get(context.TODO(), client.ObjectKeyFromObject(deployer), &updated))
+			if result := updated.Status.Callbacks[0].Result; result != tt.wantResult {
+				t.Errorf("got result %s, want %s", result, tt.wantResult)
+			}
+			_, requested := updated.GetAnnotations()[meta.ReconcileRequestAnnotation]
+			if wantRequested := tt.wantStatus == http.StatusNoContent; requested != wantRequested {
+				t.Errorf("got reconcile requested %v, want %v", requested, wantRequested)
+			}
+		})
+	}
+}
+
+func TestHandler_records_callback(t *testing.T) {
+	deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+		d.Status.Callbacks = []deployerv1.CallbackStatus{
+			{Gate: "integration tests", ID: "callback-1", SecretHash: hashSecret("secret-1"), Commit: candidate.ID, RequestedTime: metav1.NewTime(now), Result: deployerv1.CallbackPending},
+		}
+	})
+	c := newFakeClient(t, deployer)
+
+	req := httptest.NewRequest(http.MethodPost, "/callbacks/"+deployer.GetNamespace()+"/"+deployer.GetName(), strings.NewReader(`{"id":"callback-1","secret":"secret-1","passed":true,"message":"all tests passed"}`))
+	w := httptest.NewRecorder()
+	Handler(c, logr.Discard()).ServeHTTP(w, req)
+
+	var updated deployerv1.KustomizationAutoDeployer
+	test.AssertNoError(t, c.Get(context.TODO(), client.ObjectKeyFromObject(deployer), &updated))
+	callback := updated.Status.Callbacks[0]
+	if callback.Message != "all tests passed" {
+		t.Errorf("got message %q, want %q", callback.Message, "all tests passed")
+	}
+	if callback.CompletedTime == nil {
+		t.Error("callback has no completed time")
+	}
+}
+
+func newFakeClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	test.AssertNoError(t, deployerv1.AddToScheme(scheme))
+
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&deployerv1.KustomizationAutoDeployer{}).
+		Build()
+}
@@ -18,65 +18,320 @@ package gates
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
+	"sync"
+	"time"
 
-	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/git"
 )
 
-// Check checks the gates defined in the KustomizationAutoDeployer and returns
+var tracer = otel.Tracer("github.com/gitops-tools/kustomization-auto-deployer/controllers/gates")
+
+// DefaultGateTimeout is how long to wait for the checks in a gate if the gate
+// does not configure a timeout.
+const DefaultGateTimeout = time.Second * 30
+
+// DefaultGatesTimeout is the total time allowed for checking the gates if the
+// deployer does not configure a timeout.
+const DefaultGatesTimeout = time.Minute * 2
+
+// Check checks the gates defined in the KustomizationAutoDeployer and the
+// DeploymentPolicies that apply to it for the candidate commit, and returns
 // true if all gates are open.
-func Check(ctx context.Context, r *deployerv1.KustomizationAutoDeployer, configuredGates map[string]Gate) (bool, deployerv1.GatesStatus, error) {
+//
+// The gates from the policies are checked after the gates in the deployer,
+// and are named <policy name>/<gate name>. Gates that reference a GateTemplate
+// are replaced with the gates in the template.
+//
+// The gates are checked concurrently, each gate is limited to its timeout, the
+// gates in the deployer are limited to the GatesTimeout of the deployer, and
+// the gates from the policies to the DefaultGatesTimeout.
+//
+// The previous result of a check is reused until its NextCheckTime, unless the
+// candidate commit or the generation of the deployer has changed.
+//
+// The state of each gate is returned, errors and timeouts from the checks are
+// recorded in the state, and the check is closed unless the FailurePolicy of
+// the deployer ignores gate errors, which doesn't apply to the policy gates.
+func Check(ctx context.Context, r *deployerv1.KustomizationAutoDeployer, candidate, current git.Revision, enabledGates *Set, policies ...deployerv1.DeploymentPolicy) (bool, []deployerv1.GateStatus, error) {
+	configured, configErr := mergeGates(r.Spec.Gates, policies)
+	if configErr == nil {
+		configured, configErr = expandTemplates(ctx, enabledGates.client, r.GetNamespace(), configured)
+	}
+	ctx, span := tracer.Start(ctx, "gates.Check", trace.WithAttributes(
+		attribute.Int("gates.count", len(configured)),
+		attribute.String("commit.candidate", candidate.ID),
+		attribute.String("commit.current", current.ID),
+	))
+	defer span.End()
+
+	if configErr != nil {
+		span.RecordError(configErr)
+		span.SetStatus(codes.Error, configErr.Error())
+		return false, nil, configErr
+	}
+
 	// Open if no Gates are defined.
-	if len(r.Spec.Gates) == 0 {
+	if len(configured) == 0 {
+		span.SetAttributes(attribute.Bool("gates.open", true))
 		return true, nil, nil
 	}
 
-	result := deployerv1.GatesStatus{}
-	for _, gate := range r.Spec.Gates {
-		checks, err := check(ctx, gate, r, configuredGates)
+	relevantGates := make([][]RelevantGate, len(configured))
+	for i, c := range configured {
+		relevant, err := enabledGates.Resolve(c.gate)
 		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
 			return false, nil, err
 		}
+		relevantGates[i] = relevant
+	}
 
-		result[gate.Name] = checks
+	gatesTimeout := DefaultGatesTimeout
+	if r.Spec.GatesTimeout != nil {
+		gatesTimeout = r.Spec.GatesTimeout.Duration
 	}
+	deployerCtx, cancel := context.WithTimeout(ctx, gatesTimeout)
+	defer cancel()
+	// The deployer can't shorten the time allowed for the policy gates.
+	policyCtx, cancelPolicy := context.WithTimeout(ctx, DefaultGatesTimeout)
+	defer cancelPolicy()
 
-	return summarise(result), result, nil
-}
+	// The gates are given a snapshot of the deployer, and changes to the
+	// status are applied to the deployer until the checks are complete.
+	snapshot := r.DeepCopy()
+	updater := &statusUpdater{deployer: r}
+	defer updater.close()
+
+	now := metav1.Now()
+	result := make([]deployerv1.GateStatus, len(configured))
+	cached := 0
+	var wg sync.WaitGroup
+	for i, c := range configured {
+		previous := findGateStatus(snapshot.Status.GateResults(), c.gate.Name)
+		result[i] = deployerv1.GateStatus{Name: c.gate.Name, Source: c.source, Checks: make([]deployerv1.GateCheckStatus, len(relevantGates[i]))}
+		for j, rg := range relevantGates[i] {
+			req := CheckRequest{Gate: rg.Config, Deployer: snapshot, Candidate: candidate, Current: current, updateStatus: updater.update}
+			previousCheck := findCheckStatus(previous, rg.Name)
+			if isCached(previousCheck, rg.Gate, req, now) {
+				result[i].Checks[j] = *previousCheck
+				cached++
+				continue
+			}
+
+			checkCtx := deployerCtx
+			if c.policy {
+				checkCtx = policyCtx
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result[i].Checks[j] = check(checkCtx, req, rg, c.policy, previousCheck, now)
+			}()
+		}
+	}
+	wg.Wait()
+	span.SetAttributes(attribute.Int("gates.cached", cached))
 
-func summarise(res map[string]map[string]bool) bool {
-	for _, gate := range res {
-		for _, check := range gate {
-			if check == false {
-				return false
+	for i := range result {
+		result[i].Open = true
+		for _, checkStatus := range result[i].Checks {
+			if !checkStatus.Open {
+				result[i].Open = false
 			}
 		}
 	}
 
+	open := summarise(result)
+	span.SetAttributes(attribute.Bool("gates.open", open))
+
+	return open, result, nil
+}
+
+// ClosedGates returns the names of the closed gates.
+func ClosedGates(gatesStatus []deployerv1.GateStatus) []string {
+	closed := []string{}
+	for _, gate := range gatesStatus {
+		if !gate.Open {
+			closed = append(closed, gate.Name)
+		}
+	}
+
+	return closed
+}
+
+func summarise(res []deployerv1.GateStatus) bool {
+	return len(ClosedGates(res)) == 0
+}
+
+// check checks a single gate, policy gates are closed on errors regardless of
+// the FailurePolicy of the deployer.
+func check(ctx context.Context, req CheckRequest, rg RelevantGate, policy bool, previous *deployerv1.GateCheckStatus, now metav1.Time) deployerv1.GateCheckStatus {
+	gate := req.Gate
+	ctx, span := tracer.Start(ctx, "Gate.Check", trace.WithAttributes(
+		attribute.String("gate.name", gate.Name),
+		attribute.String("gate.check", rg.Name),
+		attribute.String("gate.kind", rg.Kind),
+	))
+	defer span.End()
+
+	timeout := DefaultGateTimeout
+	if gate.Timeout != nil {
+		timeout = gate.Timeout.Duration
+	}
+
+	start := time.Now()
+	res, err := checkWithTimeout(ctx, rg.Gate, req, timeout)
+	checkDuration.WithLabelValues(checkLabels(req, rg)...).Observe(time.Since(start).Seconds())
+
+	checkStatus := deployerv1.GateCheckStatus{
+		Name:               rg.Name,
+		Open:               res.Open,
+		Message:            checkMessage(res),
+		LastCheckTime:      now,
+		LastTransitionTime: now,
+		Commit:             req.Candidate.ID,
+		ObservedGeneration: req.Deployer.Generation,
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil:
+		checkTimeouts.WithLabelValues(checkLabels(req, rg)...).Inc()
+		span.SetStatus(codes.Error, "gates timed out")
+		checkStatus.Open = false
+		checkStatus.Message = "check did not complete before the gates timed out"
+	case errors.Is(err, context.DeadlineExceeded):
+		checkTimeouts.WithLabelValues(checkLabels(req, rg)...).Inc()
+		span.SetStatus(codes.Error, "check timed out")
+		checkStatus.Open = false
+		checkStatus.Message = fmt.Sprintf("check timed out after %s", timeout)
+	case err != nil:
+		checkErrors.WithLabelValues(checkLabels(req, rg)...).Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		checkStatus.Open = false
+		checkStatus.Message = "check failed"
+		checkStatus.LastError = err.Error()
+	}
+	if err != nil && !policy && req.Deployer.Spec.FailurePolicy.GateErrors == deployerv1.IgnoreGateErrorPolicy {
+		checkStatus.Open = true
+		checkStatus.Message += ", ignored by the failure policy"
+	}
+	span.SetAttributes(attribute.Bool("gate.open", checkStatus.Open))
+
+	if previous != nil && previous.Open == checkStatus.Open {
+		checkStatus.LastTransitionTime = previous.LastTransitionTime
+	}
+	if interval, err := rg.Gate.Interval(gate); err == nil && interval > NoRequeueInterval {
+		checkStatus.NextCheckTime = &metav1.Time{Time: now.Add(interval)}
+	}
+
+	return checkStatus
+}
+
+// isCached returns true if the previous result of the check can be reused.
+func isCached(previous *deployerv1.GateCheckStatus, g Gate, req CheckRequest, now metav1.Time) bool {
+	if previous == nil || previous.NextCheckTime == nil || !now.Before(previous.NextCheckTime) {
+		return false
+	}
+	if previous.Commit != req.Candidate.ID || previous.ObservedGeneration != req.Deployer.Generation {
+		return false
+	}
+	if c, ok := g.(CacheableGate); ok && !c.Cacheable() {
+		return false
+	}
+
 	return true
 }
 
-func check(ctx context.Context, gate deployerv1.KustomizationGate, deployer *deployerv1.KustomizationAutoDeployer, configuredGates map[string]Gate) (map[string]bool, error) {
-	gates, err := FindRelevantGates(gate, configuredGates)
-	if err != nil {
-		return nil, err
+// checkWithTimeout returns when the check completes or the timeout expires,
+// whichever is first, checks that do not respect the context are abandoned.
+func checkWithTimeout(ctx context.Context, g Gate, req CheckRequest, timeout time.Duration) (Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		res Result
+		err error
 	}
+	done := make(chan outcome, 1)
+	go func() {
+		res, err := g.Check(ctx, req)
+		done <- outcome{res: res, err: err}
+	}()
 
-	result := map[string]bool{}
-	for _, g := range gates {
-		open, err := g.Check(ctx, &gate, deployer)
-		if err != nil {
-			return nil, err
+	select {
+	case o := <-done:
+		if o.err != nil && ctx.Err() != nil {
+			return Result{}, ctx.Err()
+		}
+		return o.res, o.err
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+}
+
+// statusUpdater applies changes from the gates to the status of the deployer
+// until it is closed.
+type statusUpdater struct {
+	mu       sync.Mutex
+	deployer *deployerv1.KustomizationAutoDeployer
+	closed   bool
+}
+
+func (u *statusUpdater) update(f func(*deployerv1.KustomizationAutoDeployerStatus)) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return
+	}
+	f(&u.deployer.Status)
+}
+
+func (u *statusUpdater) close() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.closed = true
+}
+
+func checkMessage(res Result) string {
+	if res.Message != "" {
+		return res.Message
+	}
+	if res.Open {
+		return "check is open"
+	}
+
+	return "check is closed"
+}
+
+func findGateStatus(gatesStatus []deployerv1.GateStatus, name string) *deployerv1.GateStatus {
+	for i := range gatesStatus {
+		if gatesStatus[i].Name == name {
+			return &gatesStatus[i]
 		}
-		result[gateName(g)] = open
 	}
 
-	return result, nil
+	return nil
 }
 
-func gateName(gate Gate) string {
-	name := fmt.Sprintf("%T", gate)
-	elements := strings.Split(name, ".")
-	return elements[1]
+func findCheckStatus(gateStatus *deployerv1.GateStatus, name string) *deployerv1.GateCheckStatus {
+	if gateStatus == nil {
+		return nil
+	}
+	for i := range gateStatus.Checks {
+		if gateStatus.Checks[i].Name == name {
+			return &gateStatus.Checks[i]
+		}
+	}
+
+	return nil
 }
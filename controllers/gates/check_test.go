@@ -18,15 +18,33 @@ package gates_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
-	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	"github.com/fluxcd/pkg/apis/meta"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/healthcheck"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/scheduled"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/git"
 	"github.com/gitops-tools/kustomization-auto-deployer/test"
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 func TestCheck(t *testing.T) {
@@ -37,7 +55,7 @@ func TestCheck(t *testing.T) {
 		name     string
 		deployer *deployerv1.KustomizationAutoDeployer
 		open     bool
-		checks   deployerv1.GatesStatus
+		checks   []deployerv1.GateStatus
 	}{
 		{
 			name:     "no gates should fail open",
@@ -57,8 +75,14 @@ func TestCheck(t *testing.T) {
 					},
 				}
 			}),
-			open:   true,
-			checks: deployerv1.GatesStatus{"within scheduled hours": {"ScheduledGate": true}},
+			open: true,
+			checks: []deployerv1.GateStatus{
+				{
+					Name:   "within scheduled hours",
+					Open:   true,
+					Checks: []deployerv1.GateCheckStatus{{Name: "Scheduled", Open: true, Message: "open until 16:00", Commit: candidate.ID}},
+				},
+			},
 		},
 		{
 			name: "closed gate is closed",
@@ -73,8 +97,39 @@ func TestCheck(t *testing.T) {
 					},
 				}
 			}),
-			open:   false,
-			checks: deployerv1.GatesStatus{"outwith scheduled hours": {"ScheduledGate": false}},
+			open: false,
+			checks: []deployerv1.GateStatus{
+				{
+					Name:   "outwith scheduled hours",
+					Open:   false,
+					Checks: []deployerv1.GateCheckStatus{{Name: "Scheduled", Open: false, Message: "closed until 10:00", Commit: candidate.ID}},
+				},
+			},
+		},
+		{
+			name: "gate with checks of the same kind is closed if any check is closed",
+			deployer: test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Spec.Gates = []deployerv1.KustomizationGate{
+					{
+						Name: "business hours",
+						Checks: []deployerv1.GateCheck{
+							{Name: "morning", Kind: "Scheduled", Config: &apiextensionsv1.JSON{Raw: []byte(`{"open":"08:00","close":"12:00"}`)}},
+							{Name: "afternoon", Kind: "Scheduled", Config: &apiextensionsv1.JSON{Raw: []byte(`{"open":"13:00","close":"17:00"}`)}},
+						},
+					},
+				}
+			}),
+			open: false,
+			checks: []deployerv1.GateStatus{
+				{
+					Name: "business hours",
+					Open: false,
+					Checks: []deployerv1.GateCheckStatus{
+						{Name: "morning", Open: true, Message: "open until 12:00", Commit: candidate.ID},
+						{Name: "afternoon", Open: false, Message: "closed until 13:00", Commit: candidate.ID},
+					},
+				},
+			},
 		},
 	}
 
@@ -86,7 +141,7 @@ func TestCheck(t *testing.T) {
 
 	for _, tt := range checkTests {
 		t.Run(tt.name, func(t *testing.T) {
-			open, checks, err := gates.Check(context.TODO(), tt.deployer, gateValues)
+			open, checks, err := gates.Check(context.TODO(), tt.deployer, candidate, current, newGateSet(t, gateValues))
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -95,9 +150,736 @@ func TestCheck(t *testing.T) {
 				t.Errorf("got open %v, want %v", open, tt.open)
 			}
 
-			if diff := cmp.Diff(tt.checks, checks); diff != "" {
+			if diff := cmp.Diff(tt.checks, checks, cmpopts.IgnoreFields(deployerv1.GateCheckStatus{}, "LastCheckTime", "LastTransitionTime", "NextCheckTime")); diff != "" {
 				t.Fatalf("failed to calculate checks:\n%s", diff)
 			}
 		})
 	}
 }
+
+func TestCheck_with_policies(t *testing.T) {
+	// 9am on the 14th May 2023
+	now := time.Date(2023, time.May, 14, 9, 0, 0, 0, time.UTC)
+	gateValues := map[string]gates.Gate{
+		"Scheduled": scheduled.New(logr.Discard(), func(s *scheduled.ScheduledGate) {
+			s.Clock = func() time.Time { return now }
+		}),
+	}
+	deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+		d.Spec.Gates = []deployerv1.KustomizationGate{
+			{
+				Name: "business hours",
+				Scheduled: &deployerv1.ScheduledCheck{
+					Open:  "08:00",
+					Close: "16:00",
+				},
+			},
+		}
+	})
+	policy := test.NewDeploymentPolicy(func(p *deployerv1.DeploymentPolicy) {
+		p.Spec.Gates[0].Scheduled.Open = "10:00"
+	})
+
+	open, checks, err := gates.Check(context.TODO(), deployer, candidate, current, newGateSet(t, gateValues), *policy)
+	test.AssertNoError(t, err)
+
+	if open {
+		t.Error("got open, want closed by the policy gate")
+	}
+	want := []deployerv1.GateStatus{
+		{
+			Name:   "business hours",
+			Open:   true,
+			Checks: []deployerv1.GateCheckStatus{{Name: "Scheduled", Open: true, Message: "open until 16:00", Commit: candidate.ID}},
+		},
+		{
+			Name:   "demo-policy/business hours",
+			Source: "DeploymentPolicy/demo-policy",
+			Open:   false,
+			Checks: []deployerv1.GateCheckStatus{{Name: "Scheduled", Open: false, Message: "closed until 10:00", Commit: candidate.ID}},
+		},
+	}
+	if diff := cmp.Diff(want, checks, cmpopts.IgnoreFields(deployerv1.GateCheckStatus{}, "LastCheckTime", "LastTransitionTime", "NextCheckTime")); diff != "" {
+		t.Fatalf("failed to calculate checks:\n%s", diff)
+	}
+	if l := len(policy.Spec.Gates); policy.Spec.Gates[l-1].Name != "business hours" {
+		t.Errorf("policy gate was renamed to %q", policy.Spec.Gates[l-1].Name)
+	}
+}
+
+func TestCheck_policy_gates_ignore_deployer_failure_policy(t *testing.T) {
+	// The deployer can't open the policy gates by ignoring errors, or by
+	// timing them out.
+	deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+		d.Spec.FailurePolicy.GateErrors = deployerv1.IgnoreGateErrorPolicy
+		d.Spec.GatesTimeout = &metav1.Duration{Duration: time.Millisecond}
+	})
+	policy := test.NewDeploymentPolicy(func(p *deployerv1.DeploymentPolicy) {
+		p.Spec.Gates = []deployerv1.KustomizationGate{
+			{
+				Name:        "change approval",
+				HealthCheck: &deployerv1.HealthCheck{URL: "https://example.com/"},
+			},
+		}
+	})
+
+	policyTests := []struct {
+		name string
+		gate gates.Gate
+		want deployerv1.GateCheckStatus
+	}{
+		{
+			name: "failing check",
+			gate: gates.FromBoolGate(failingGate{err: errors.New("connection refused")}),
+			want: deployerv1.GateCheckStatus{Name: "HealthCheck", Message: "check failed", LastError: "connection refused", Commit: candidate.ID},
+		},
+		{
+			name: "slow check",
+			gate: gates.FromBoolGate(sleepingGate{delay: time.Millisecond * 50}),
+			want: deployerv1.GateCheckStatus{Name: "HealthCheck", Open: true, Message: "check is open", Commit: candidate.ID},
+		},
+	}
+
+	for _, tt := range policyTests {
+		t.Run(tt.name, func(t *testing.T) {
+			open, checks, err := gates.Check(context.TODO(), deployer, candidate, current, newGateSet(t, map[string]gates.Gate{"HealthCheck": tt.gate}), *policy)
+			test.AssertNoError(t, err)
+
+			if open != tt.want.Open {
+				t.Errorf("got open %v, want %v", open, tt.want.Open)
+			}
+			want := []deployerv1.GateStatus{
+				{
+					Name:   "demo-policy/change approval",
+					Source: "DeploymentPolicy/demo-policy",
+					Open:   tt.want.Open,
+					Checks: []deployerv1.GateCheckStatus{tt.want},
+				},
+			}
+			if diff := cmp.Diff(want, checks, cmpopts.IgnoreFields(deployerv1.GateCheckStatus{}, "LastCheckTime", "LastTransitionTime", "NextCheckTime")); diff != "" {
+				t.Fatalf("failed to check the policy gate:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheck_with_conflicting_policy_gates(t *testing.T) {
+	deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+		d.Spec.Gates = []deployerv1.KustomizationGate{
+			{
+				Name:      "demo-policy/business hours",
+				Scheduled: &deployerv1.ScheduledCheck{Open: "08:00", Close: "16:00"},
+			},
+		}
+	})
+
+	_, _, err := gates.Check(context.TODO(), deployer, candidate, current, newGateSet(t, nil), *test.NewDeploymentPolicy())
+
+	test.AssertErrorMatch(t, "gate demo-policy/business hours conflicts with a gate from DeploymentPolicy/demo-policy", err)
+}
+
+func TestCheck_with_templates(t *testing.T) {
+	// 9am on the 14th May 2023
+	now := time.Date(2023, time.May, 14, 9, 0, 0, 0, time.UTC)
+	gateValues := map[string]gates.Gate{
+		"Scheduled": scheduled.New(logr.Discard(), func(s *scheduled.ScheduledGate) {
+			s.Clock = func() time.Time { return now }
+		}),
+	}
+	template := &deployerv1.GateTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "standard-gates", Namespace: test.DefaultNamespace},
+		Spec: deployerv1.GateTemplateSpec{
+			Gates: []deployerv1.KustomizationGate{
+				{Name: "morning", Scheduled: &deployerv1.ScheduledCheck{Open: "08:00", Close: "12:00"}},
+				{Name: "late", Timeout: &metav1.Duration{Duration: time.Minute}, Scheduled: &deployerv1.ScheduledCheck{Open: "10:00", Close: "12:00"}},
+			},
+		},
+	}
+	deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+		d.Spec.Gates = []deployerv1.KustomizationGate{
+			{Name: "standard", TemplateRef: &meta.LocalObjectReference{Name: "standard-gates"}},
+		}
+	})
+
+	open, checks, err := gates.Check(context.TODO(), deployer, candidate, current, newGateSet(t, gateValues, template))
+	test.AssertNoError(t, err)
+
+	if open {
+		t.Error("got open, want closed by the template gate")
+	}
+	want := []deployerv1.GateStatus{
+		{
+			Name:   "standard/morning",
+			Source: "GateTemplate/standard-gates",
+			Open:   true,
+			Checks: []deployerv1.GateCheckStatus{{Name: "Scheduled", Open: true, Message: "open until 12:00", Commit: candidate.ID}},
+		},
+		{
+			Name:   "standard/late",
+			Source: "GateTemplate/standard-gates",
+			Open:   false,
+			Checks: []deployerv1.GateCheckStatus{{Name: "Scheduled", Open: false, Message: "closed until 10:00", Commit: candidate.ID}},
+		},
+	}
+	if diff := cmp.Diff(want, checks, cmpopts.IgnoreFields(deployerv1.GateCheckStatus{}, "LastCheckTime", "LastTransitionTime", "NextCheckTime")); diff != "" {
+		t.Fatalf("failed to calculate checks:\n%s", diff)
+	}
+}
+
+func TestCheck_with_policy_template(t *testing.T) {
+	// A template in the namespace of the deployer can't replace the policy
+	// gate.
+	template := &deployerv1.GateTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "standard-gates", Namespace: test.DefaultNamespace},
+		Spec: deployerv1.GateTemplateSpec{
+			Gates: []deployerv1.KustomizationGate{
+				{Name: "always", Scheduled: &deployerv1.ScheduledCheck{Open: "00:00", Close: "23:59"}},
+			},
+		},
+	}
+	policy := test.NewDeploymentPolicy(func(p *deployerv1.DeploymentPolicy) {
+		p.Spec.Gates = []deployerv1.KustomizationGate{
+			{Name: "mandatory", TemplateRef: &meta.LocalObjectReference{Name: "standard-gates"}},
+		}
+	})
+
+	open, checks, err := gates.Check(context.TODO(), test.NewKustomizationAutoDeployer(), candidate, current, newGateSet(t, nil, template), *policy)
+
+	test.AssertErrorMatch(t, "gate demo-policy/mandatory from DeploymentPolicy/demo-policy cannot reference GateTemplate standard-gates", err)
+	if open || checks != nil {
+		t.Errorf("got open %v with checks %v, want closed", open, checks)
+	}
+}
+
+func TestCheck_with_missing_template(t *testing.T) {
+	deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+		d.Spec.Gates = []deployerv1.KustomizationGate{
+			{Name: "standard", TemplateRef: &meta.LocalObjectReference{Name: "standard-gates"}},
+		}
+	})
+
+	_, _, err := gates.Check(context.TODO(), deployer, candidate, current, newGateSet(t, nil))
+
+	test.AssertErrorMatch(t, "failed to get GateTemplate default/standard-gates for gate standard", err)
+}
+
+func TestCheck_tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	original := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(original) })
+
+	now := time.Date(2023, time.May, 14, 9, 0, 0, 0, time.UTC)
+	deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+		d.Spec.Gates = []deployerv1.KustomizationGate{
+			{
+				Name: "within scheduled hours",
+				Scheduled: &deployerv1.ScheduledCheck{
+					Open:  "08:00",
+					Close: "16:00",
+				},
+			},
+		}
+	})
+	gateValues := map[string]gates.Gate{
+		"Scheduled": scheduled.New(logr.Discard(), func(s *scheduled.ScheduledGate) {
+			s.Clock = func() time.Time { return now }
+		}),
+	}
+
+	_, _, err := gates.Check(context.TODO(), deployer, candidate, current, newGateSet(t, gateValues))
+	test.AssertNoError(t, err)
+
+	got := map[string][]attribute.KeyValue{}
+	for _, span := range recorder.Ended() {
+		got[span.Name()] = span.Attributes()
+	}
+
+	want := map[string][]attribute.KeyValue{
+		"gates.Check": {
+			attribute.Int("gates.count", 1),
+			attribute.String("commit.candidate", candidate.ID),
+			attribute.String("commit.current", current.ID),
+			attribute.Int("gates.cached", 0),
+			attribute.Bool("gates.open", true),
+		},
+		"Gate.Check": {
+			attribute.String("gate.name", "within scheduled hours"),
+			attribute.String("gate.check", "Scheduled"),
+			attribute.String("gate.kind", "Scheduled"),
+			attribute.Bool("gate.open", true),
+		},
+	}
+	if diff := cmp.Diff(want, got, cmp.Comparer(func(x, y attribute.Value) bool { return x == y })); diff != "" {
+		t.Errorf("failed to record spans:\n%s", diff)
+	}
+}
+
+func TestCheck_records_errors(t *testing.T) {
+	deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+		d.Spec.Gates = []deployerv1.KustomizationGate{
+			{
+				Name:        "failing health check",
+				HealthCheck: &deployerv1.HealthCheck{URL: "https://example.com/"},
+				Checks: []deployerv1.GateCheck{
+					{Name: "api", Kind: "HealthCheck", Config: &apiextensionsv1.JSON{Raw: []byte(`{"url":"https://api.example.com/"}`)}},
+					{Name: "ui", Kind: "HealthCheck", Config: &apiextensionsv1.JSON{Raw: []byte(`{"url":"https://ui.example.com/"}`)}},
+				},
+			},
+		}
+	})
+	gateValues := map[string]gates.Gate{
+		"HealthCheck": gates.FromBoolGate(failingGate{err: errors.New("connection refused")}),
+	}
+	gates.DeleteMetrics(deployer)
+
+	open, checks, err := gates.Check(context.TODO(), deployer, candidate, current, newGateSet(t, gateValues))
+	test.AssertNoError(t, err)
+	if open {
+		t.Error("gate with a failing check should be closed")
+	}
+
+	wantMetrics := `
+# HELP kustomization_auto_deployer_gate_check_errors_total Number of gate checks that returned an error.
+# TYPE kustomization_auto_deployer_gate_check_errors_total counter
+kustomization_auto_deployer_gate_check_errors_total{check="HealthCheck",gate="failing health check",name="demo-deployer",namespace="default"} 1
+kustomization_auto_deployer_gate_check_errors_total{check="api",gate="failing health check",name="demo-deployer",namespace="default"} 1
+kustomization_auto_deployer_gate_check_errors_total{check="ui",gate="failing health check",name="demo-deployer",namespace="default"} 1
+`
+	if err := testutil.GatherAndCompare(metrics.Registry, strings.NewReader(wantMetrics), "kustomization_auto_deployer_gate_check_errors_total"); err != nil {
+		t.Error(err)
+	}
+	gates.DeleteMetrics(deployer)
+	if c, err := testutil.GatherAndCount(metrics.Registry, "kustomization_auto_deployer_gate_check_errors_total", "kustomization_auto_deployer_gate_check_duration_seconds"); err != nil || c != 0 {
+		t.Errorf("failed to delete the gate check metrics, got %d series: %v", c, err)
+	}
+
+	want := []deployerv1.GateStatus{
+		{
+			Name: "failing health check",
+			Checks: []deployerv1.GateCheckStatus{
+				{Name: "HealthCheck", Message: "check failed", LastError: "connection refused", Commit: candidate.ID},
+				{Name: "api", Message: "check failed", LastError: "connection refused", Commit: candidate.ID},
+				{Name: "ui", Message: "check failed", LastError: "connection refused", Commit: candidate.ID},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, checks, cmpopts.IgnoreFields(deployerv1.GateCheckStatus{}, "LastCheckTime", "LastTransitionTime")); diff != "" {
+		t.Fatalf("failed to record the error:\n%s", diff)
+	}
+}
+
+func TestCheck_ignores_errors_with_failure_policy(t *testing.T) {
+	deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+		d.Spec.FailurePolicy.GateErrors = deployerv1.IgnoreGateErrorPolicy
+		d.Spec.Gates = []deployerv1.KustomizationGate{
+			{
+				Name:        "failing health check",
+				HealthCheck: &deployerv1.HealthCheck{URL: "https://example.com/"},
+			},
+		}
+	})
+	gateValues := map[string]gates.Gate{
+		"HealthCheck": gates.FromBoolGate(failingGate{err: errors.New("connection refused")}),
+	}
+
+	open, checks, err := gates.Check(context.TODO(), deployer, candidate, current, newGateSet(t, gateValues))
+	test.AssertNoError(t, err)
+	if !open {
+		t.Error("gate with an ignored failing check should be open")
+	}
+
+	want := []deployerv1.GateStatus{
+		{
+			Name: "failing health check",
+			Open: true,
+			Checks: []deployerv1.GateCheckStatus{
+				{Name: "HealthCheck", Open: true, Message: "check failed, ignored by the failure policy", LastError: "connection refused", Commit: candidate.ID},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, checks, cmpopts.IgnoreFields(deployerv1.GateCheckStatus{}, "LastCheckTime", "LastTransitionTime")); diff != "" {
+		t.Fatalf("failed to record the error:\n%s", diff)
+	}
+}
+
+func TestCheck_passes_commits(t *testing.T) {
+	deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+		d.Spec.Gates = []deployerv1.KustomizationGate{
+			{
+				Name:        "recording",
+				HealthCheck: &deployerv1.HealthCheck{URL: "https://example.com/"},
+			},
+		}
+	})
+	recorder := &recordingGate{}
+	gateValues := map[string]gates.Gate{
+		"HealthCheck": recorder,
+	}
+
+	open, checks, err := gates.Check(context.TODO(), deployer, candidate, current, newGateSet(t, gateValues))
+	test.AssertNoError(t, err)
+	if !open {
+		t.Error("gate with an open check should be open")
+	}
+
+	want := gates.CheckRequest{
+		Gate:      &deployer.Spec.Gates[0],
+		Deployer:  deployer,
+		Candidate: candidate,
+		Current:   current,
+	}
+	if diff := cmp.Diff(want, recorder.req, cmpopts.IgnoreUnexported(gates.CheckRequest{})); diff != "" {
+		t.Fatalf("failed to pass the request:\n%s", diff)
+	}
+	if msg := checks[0].Checks[0].Message; msg != "candidate abc123 by Test User <test@example.com>" {
+		t.Errorf("got message %q", msg)
+	}
+}
+
+func TestCheck_timeouts(t *testing.T) {
+	timeoutTests := []struct {
+		name        string
+		gateTimeout *metav1.Duration
+		timeout     *metav1.Duration
+		wantMessage string
+	}{
+		{
+			name:        "gate timeout",
+			gateTimeout: &metav1.Duration{Duration: time.Millisecond * 10},
+			wantMessage: "check timed out after 10ms",
+		},
+		{
+			name:        "gates timeout",
+			timeout:     &metav1.Duration{Duration: time.Millisecond * 10},
+			wantMessage: "check did not complete before the gates timed out",
+		},
+	}
+
+	for _, tt := range timeoutTests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Spec.GatesTimeout = tt.timeout
+				d.Spec.Gates = []deployerv1.KustomizationGate{
+					{
+						Name:        "slow health check",
+						Timeout:     tt.gateTimeout,
+						HealthCheck: &deployerv1.HealthCheck{URL: "https://example.com/"},
+					},
+				}
+			})
+			// The gate ignores the context, and is only released when the
+			// test is complete.
+			release := make(chan struct{})
+			t.Cleanup(func() { close(release) })
+			gateValues := map[string]gates.Gate{
+				"HealthCheck": gates.FromBoolGate(blockingGate{release: release}),
+			}
+
+			open, checks, err := gates.Check(context.TODO(), deployer, candidate, current, newGateSet(t, gateValues))
+			test.AssertNoError(t, err)
+			if open {
+				t.Error("gate that timed out should be closed")
+			}
+
+			want := []deployerv1.GateStatus{
+				{
+					Name:   "slow health check",
+					Checks: []deployerv1.GateCheckStatus{{Name: "HealthCheck", Message: tt.wantMessage, Commit: candidate.ID}},
+				},
+			}
+			if diff := cmp.Diff(want, checks, cmpopts.IgnoreFields(deployerv1.GateCheckStatus{}, "LastCheckTime", "LastTransitionTime")); diff != "" {
+				t.Fatalf("failed to record the timeout:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheck_concurrently(t *testing.T) {
+	deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+		for _, name := range []string{"first", "second", "third"} {
+			d.Spec.Gates = append(d.Spec.Gates, deployerv1.KustomizationGate{
+				Name:        name,
+				HealthCheck: &deployerv1.HealthCheck{URL: "https://example.com/" + name},
+			})
+		}
+	})
+	gateValues := map[string]gates.Gate{
+		"HealthCheck": gates.FromBoolGate(sleepingGate{delay: time.Millisecond * 200}),
+	}
+
+	start := time.Now()
+	open, checks, err := gates.Check(context.TODO(), deployer, candidate, current, newGateSet(t, gateValues))
+	test.AssertNoError(t, err)
+
+	if elapsed := time.Since(start); elapsed >= time.Millisecond*600 {
+		t.Errorf("gates were not checked concurrently, took %v", elapsed)
+	}
+	if !open {
+		t.Error("open gates should be open")
+	}
+	names := []string{}
+	for _, check := range checks {
+		names = append(names, check.Name)
+	}
+	if diff := cmp.Diff([]string{"first", "second", "third"}, names); diff != "" {
+		t.Errorf("failed to keep the gate order:\n%s", diff)
+	}
+}
+
+func TestCheck_updates_status(t *testing.T) {
+	deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+		d.Spec.Gates = []deployerv1.KustomizationGate{
+			{
+				Name:        "recording",
+				HealthCheck: &deployerv1.HealthCheck{URL: "https://example.com/"},
+			},
+		}
+	})
+	gateValues := map[string]gates.Gate{
+		"HealthCheck": statusGate{},
+	}
+
+	_, _, err := gates.Check(context.TODO(), deployer, candidate, current, newGateSet(t, gateValues))
+	test.AssertNoError(t, err)
+
+	want := []deployerv1.CallbackStatus{{Gate: "recording", ID: "test-id", Commit: candidate.ID}}
+	if diff := cmp.Diff(want, deployer.Status.Callbacks); diff != "" {
+		t.Fatalf("failed to update the status:\n%s", diff)
+	}
+}
+
+func TestCheck_caching(t *testing.T) {
+	now := time.Now()
+	nextCheck := metav1.NewTime(now.Add(time.Minute * 5))
+	lastCheck := metav1.NewTime(now.Add(-time.Minute * 5))
+	previous := deployerv1.GateCheckStatus{
+		Name:               "HealthCheck",
+		Open:               false,
+		Message:            "cached result",
+		LastCheckTime:      lastCheck,
+		LastTransitionTime: lastCheck,
+		NextCheckTime:      &nextCheck,
+		Commit:             candidate.ID,
+		ObservedGeneration: 2,
+	}
+
+	cachingTests := []struct {
+		name       string
+		generation int64
+		previous   func(*deployerv1.GateCheckStatus)
+		gate       gates.Gate
+		wantCached bool
+	}{
+		{
+			name:       "interval has not elapsed",
+			generation: 2,
+			gate:       &countingGate{},
+			wantCached: true,
+		},
+		{
+			name:       "interval has elapsed",
+			generation: 2,
+			previous: func(c *deployerv1.GateCheckStatus) {
+				c.NextCheckTime = &metav1.Time{Time: now.Add(-time.Second)}
+			},
+			gate: &countingGate{},
+		},
+		{
+			name:       "no interval",
+			generation: 2,
+			previous: func(c *deployerv1.GateCheckStatus) {
+				c.NextCheckTime = nil
+			},
+			gate: &countingGate{},
+		},
+		{
+			name:       "generation changed",
+			generation: 3,
+			gate:       &countingGate{},
+		},
+		{
+			name:       "candidate commit changed",
+			generation: 2,
+			previous: func(c *deployerv1.GateCheckStatus) {
+				c.Commit = current.ID
+			},
+			gate: &countingGate{},
+		},
+		{
+			name:       "gate that can't be cached",
+			generation: 2,
+			gate:       &countingGate{uncacheable: true},
+		},
+	}
+
+	for _, tt := range cachingTests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousCheck := previous
+			if tt.previous != nil {
+				tt.previous(&previousCheck)
+			}
+			deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Generation = tt.generation
+				d.Spec.Gates = []deployerv1.KustomizationGate{
+					{
+						Name:        "health check",
+						HealthCheck: &deployerv1.HealthCheck{URL: "https://example.com/"},
+					},
+				}
+				d.Status.Gates = &deployerv1.GatesStatus{
+					Results: []deployerv1.GateStatus{
+						{Name: "health check", Checks: []deployerv1.GateCheckStatus{previousCheck}},
+					},
+				}
+			})
+			gateValues := map[string]gates.Gate{
+				"HealthCheck": tt.gate,
+			}
+
+			open, checks, err := gates.Check(context.TODO(), deployer, candidate, current, newGateSet(t, gateValues))
+			test.AssertNoError(t, err)
+
+			calls := tt.gate.(*countingGate).calls
+			if tt.wantCached {
+				if calls != 0 {
+					t.Errorf("cached check was called %d times", calls)
+				}
+				if open {
+					t.Error("cached closed check should be closed")
+				}
+				if diff := cmp.Diff(previousCheck, checks[0].Checks[0]); diff != "" {
+					t.Errorf("failed to reuse the cached check:\n%s", diff)
+				}
+				return
+			}
+
+			if calls != 1 {
+				t.Errorf("check was called %d times, want 1", calls)
+			}
+			if !open {
+				t.Error("checked open gate should be open")
+			}
+			if gen := checks[0].Checks[0].ObservedGeneration; gen != tt.generation {
+				t.Errorf("got ObservedGeneration %d, want %d", gen, tt.generation)
+			}
+		})
+	}
+}
+
+var (
+	candidate = git.Revision{ID: "abc123", Time: time.Date(2023, time.May, 14, 8, 0, 0, 0, time.UTC), Author: "Test User <test@example.com>", Message: "Add feature"}
+	current   = git.Revision{ID: "def456", Time: time.Date(2023, time.May, 14, 7, 0, 0, 0, time.UTC), Author: "Test User <test@example.com>", Message: "Initial commit"}
+)
+
+// newGateSet returns a Set with the HealthCheck and Scheduled gates enabled if
+// they are provided, the Set has a client with the objects.
+func newGateSet(t *testing.T, enabled map[string]gates.Gate, objs ...client.Object) *gates.Set {
+	t.Helper()
+	defs := []gates.Definition{
+		healthcheck.Definition(nil),
+		scheduled.Definition(nil),
+	}
+	for i := range defs {
+		if g, ok := enabled[defs[i].Key]; ok {
+			defs[i].Factory = func(logr.Logger, client.Client) gates.Gate { return g }
+		}
+	}
+	registry, err := gates.NewRegistry(defs...)
+	test.AssertNoError(t, err)
+
+	scheme := runtime.NewScheme()
+	test.AssertNoError(t, deployerv1.AddToScheme(scheme))
+
+	return registry.Instantiate(logr.Discard(), fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build())
+}
+
+type recordingGate struct {
+	req gates.CheckRequest
+}
+
+func (g *recordingGate) Check(_ context.Context, req gates.CheckRequest) (gates.Result, error) {
+	g.req = req
+
+	return gates.Result{Open: true, Message: fmt.Sprintf("candidate %s by %s", req.Candidate.ID, req.Candidate.Author)}, nil
+}
+
+func (g *recordingGate) Interval(*deployerv1.KustomizationGate) (time.Duration, error) {
+	return gates.NoRequeueInterval, nil
+}
+
+type blockingGate struct {
+	release chan struct{}
+}
+
+func (g blockingGate) Check(context.Context, *deployerv1.KustomizationGate, *deployerv1.KustomizationAutoDeployer) (bool, error) {
+	<-g.release
+
+	return true, nil
+}
+
+func (g blockingGate) Interval(*deployerv1.KustomizationGate) (time.Duration, error) {
+	return gates.NoRequeueInterval, nil
+}
+
+type sleepingGate struct {
+	delay time.Duration
+}
+
+func (g sleepingGate) Check(ctx context.Context, _ *deployerv1.KustomizationGate, _ *deployerv1.KustomizationAutoDeployer) (bool, error) {
+	select {
+	case <-time.After(g.delay):
+		return true, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+func (g sleepingGate) Interval(*deployerv1.KustomizationGate) (time.Duration, error) {
+	return gates.NoRequeueInterval, nil
+}
+
+type statusGate struct{}
+
+func (g statusGate) Check(_ context.Context, req gates.CheckRequest) (gates.Result, error) {
+	req.UpdateStatus(func(status *deployerv1.KustomizationAutoDeployerStatus) {
+		status.Callbacks = append(status.Callbacks, deployerv1.CallbackStatus{Gate: req.Gate.Name, ID: "test-id", Commit: req.Candidate.ID})
+	})
+
+	return gates.Result{Open: true}, nil
+}
+
+func (g statusGate) Interval(*deployerv1.KustomizationGate) (time.Duration, error) {
+	return gates.NoRequeueInterval, nil
+}
+
+type countingGate struct {
+	calls       int
+	uncacheable bool
+}
+
+func (g *countingGate) Check(context.Context, gates.CheckRequest) (gates.Result, error) {
+	g.calls++
+
+	return gates.Result{Open: true}, nil
+}
+
+func (g *countingGate) Interval(*deployerv1.KustomizationGate) (time.Duration, error) {
+	return time.Minute * 5, nil
+}
+
+func (g *countingGate) Cacheable() bool {
+	return !g.uncacheable
+}
+
+type failingGate struct {
+	err error
+}
+
+func (g failingGate) Check(context.Context, *deployerv1.KustomizationGate, *deployerv1.KustomizationAutoDeployer) (bool, error) {
+	return true, g.err
+}
+
+func (g failingGate) Interval(*deployerv1.KustomizationGate) (time.Duration, error) {
+	return gates.NoRequeueInterval, nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	deployerv1alpha1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/gateplugin"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/git"
+)
+
+// Factory is a function for creating per-reconciliation gates for
+// the ExternalGate.
+func Factory(httpClient *http.Client) gates.GateFactory {
+	return func(l logr.Logger, c client.Client) gates.Gate {
+		return New(l, httpClient)
+	}
+}
+
+// New creates and returns a new ExternalGate.
+func New(l logr.Logger, httpClient *http.Client) *ExternalGate {
+	return &ExternalGate{
+		Logger:  l,
+		Client:  gateplugin.NewClient(httpClient),
+		requeue: map[*deployerv1.ExternalCheck]time.Duration{},
+	}
+}
+
+// ExternalGate delegates checks to a gate server using the gate plugin
+// protocol.
+//
+// The requeue interval suggested by the gate server is used as the Interval
+// for the gate.
+type ExternalGate struct {
+	Logger logr.Logger
+	Client *gateplugin.Client
+
+	// requeue is keyed by the configuration of the check, as a gate can have
+	// several external checks.
+	mu      sync.Mutex
+	requeue map[*deployerv1.ExternalCheck]time.Duration
+}
+
+// Check sends the check to the gate server and returns the result.
+func (g *ExternalGate) Check(ctx context.Context, req gates.CheckRequest) (gates.Result, error) {
+	gate := req.Gate
+	g.Logger.Info("checking external gate", "gate", gate.Name, "url", gate.External.URL)
+
+	// The gate plugin protocol uses the v1alpha1 representation of the
+	// deployer.
+	deployer := &deployerv1alpha1.KustomizationAutoDeployer{}
+	if err := deployer.ConvertFrom(req.Deployer); err != nil {
+		return gates.Result{}, err
+	}
+	deployer.APIVersion = deployerv1alpha1.GroupVersion.String()
+	deployer.Kind = "KustomizationAutoDeployer"
+	protocolGate := &deployerv1alpha1.KustomizationGate{}
+	protocolGate.ConvertFrom(gate)
+
+	resp, err := g.Client.Check(ctx, gate.External.URL, gateplugin.CheckRequest{
+		Deployer:  deployer,
+		Gate:      protocolGate,
+		Candidate: commit(req.Candidate),
+		Current:   commit(req.Current),
+	})
+	if err != nil {
+		return gates.Result{}, err
+	}
+
+	g.Logger.Info("external gate check complete", "gate", gate.Name, "open", resp.Open, "requeueAfter", resp.RequeueAfter())
+	g.mu.Lock()
+	g.requeue[gate.External] = resp.RequeueAfter()
+	g.mu.Unlock()
+
+	return gates.Result{Open: resp.Open, Message: resp.Message}, nil
+}
+
+// Interval returns the requeue interval suggested by the gate server, or the
+// configured interval if the gate server made no suggestion.
+func (g *ExternalGate) Interval(gate *deployerv1.KustomizationGate) (time.Duration, error) {
+	g.mu.Lock()
+	d := g.requeue[gate.External]
+	g.mu.Unlock()
+	if d > gates.NoRequeueInterval {
+		return d, nil
+	}
+
+	if gate.External.Interval != nil {
+		return gate.External.Interval.Duration, nil
+	}
+
+	return gates.NoRequeueInterval, nil
+}
+
+func commit(r git.Revision) gateplugin.Commit {
+	return gateplugin.Commit{
+		ID:      r.ID,
+		Time:    r.Time,
+		Author:  r.Author,
+		Message: r.Message,
+	}
+}
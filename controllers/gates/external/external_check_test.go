@@ -0,0 +1,158 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	deployerv1alpha1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/gateplugin"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/git"
+	"github.com/gitops-tools/kustomization-auto-deployer/test"
+)
+
+var _ gates.Gate = (*ExternalGate)(nil)
+
+func TestExternalGate_Check(t *testing.T) {
+	var received gateplugin.CheckRequest
+	ts := httptest.NewServer(gateplugin.Handler(func(ctx context.Context, req gateplugin.CheckRequest) (gateplugin.CheckResponse, error) {
+		received = req
+
+		return gateplugin.CheckResponse{Open: false, Message: "change ticket CHG-1234 is not approved", RequeueAfterSeconds: 300}, nil
+	}))
+	defer ts.Close()
+
+	deployer := test.NewKustomizationAutoDeployer()
+	gate := &deployerv1.KustomizationGate{
+		Name: "change approval",
+		External: &deployerv1.ExternalCheck{
+			URL:        ts.URL,
+			Parameters: map[string]string{"service": "payments"},
+		},
+	}
+	candidate := git.Revision{ID: "6f935147b28e38a99a700843e4893a801c3c8148", Time: time.Date(2023, time.May, 14, 8, 0, 0, 0, time.UTC), Author: "Test User <test@example.com>", Message: "Add feature"}
+	current := git.Revision{ID: "dd587268153ad335545a53f15efee4ecfabcd1c8", Time: time.Date(2023, time.May, 14, 7, 0, 0, 0, time.UTC)}
+
+	gen := New(logr.Discard(), ts.Client())
+	got, err := gen.Check(context.TODO(), gates.CheckRequest{
+		Gate:      gate,
+		Deployer:  deployer,
+		Candidate: candidate,
+		Current:   current,
+	})
+	test.AssertNoError(t, err)
+
+	want := gates.Result{Open: false, Message: "change ticket CHG-1234 is not approved"}
+	if got != want {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+
+	// The gate server receives the v1alpha1 representation of the deployer.
+	wantReq := gateplugin.CheckRequest{
+		APIVersion: gateplugin.APIVersion,
+		Deployer: &deployerv1alpha1.KustomizationAutoDeployer{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "flux.gitops.pro/v1alpha1", Kind: "KustomizationAutoDeployer"},
+			ObjectMeta: deployer.ObjectMeta,
+			Spec: deployerv1alpha1.KustomizationAutoDeployerSpec{
+				KustomizationRef: meta.LocalObjectReference{Name: "test-kustomization"},
+				Interval:         deployer.Spec.Interval,
+				CommitLimit:      deployer.Spec.CommitLimit,
+			},
+		},
+		Gate: &deployerv1alpha1.KustomizationGate{
+			Name: "change approval",
+			External: &deployerv1alpha1.ExternalCheck{
+				URL:        ts.URL,
+				Parameters: map[string]string{"service": "payments"},
+			},
+		},
+		Candidate: gateplugin.Commit{ID: candidate.ID, Time: candidate.Time, Author: candidate.Author, Message: candidate.Message},
+		Current:   gateplugin.Commit{ID: current.ID, Time: current.Time},
+	}
+	if diff := cmp.Diff(wantReq, received); diff != "" {
+		t.Fatalf("failed to send the check request:\n%s", diff)
+	}
+
+	interval, err := gen.Interval(gate)
+	test.AssertNoError(t, err)
+	if interval != time.Minute*5 {
+		t.Errorf("Interval() got %v, want %v", interval, time.Minute*5)
+	}
+}
+
+func TestExternalGate_Check_errors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "ticket system unavailable", http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	gen := New(logr.Discard(), ts.Client())
+	_, err := gen.Check(context.TODO(), gates.CheckRequest{
+		Gate: &deployerv1.KustomizationGate{
+			Name:     "change approval",
+			External: &deployerv1.ExternalCheck{URL: ts.URL},
+		},
+		Deployer: test.NewKustomizationAutoDeployer(),
+	})
+
+	test.AssertErrorMatch(t, "returned 503 Service Unavailable: ticket system unavailable", err)
+}
+
+func TestExternalGate_Interval(t *testing.T) {
+	intervalTests := []struct {
+		name     string
+		interval *metav1.Duration
+		want     time.Duration
+	}{
+		{
+			name: "no interval",
+			want: gates.NoRequeueInterval,
+		},
+		{
+			name:     "configured interval",
+			interval: &metav1.Duration{Duration: time.Minute * 10},
+			want:     time.Minute * 10,
+		},
+	}
+
+	for _, tt := range intervalTests {
+		t.Run(tt.name, func(t *testing.T) {
+			gate := &deployerv1.KustomizationGate{
+				Name:     "testing",
+				External: &deployerv1.ExternalCheck{URL: "https://example.com/", Interval: tt.interval},
+			}
+
+			gen := New(logr.Discard(), nil)
+			i, err := gen.Interval(gate)
+			test.AssertNoError(t, err)
+			if i != tt.want {
+				t.Fatalf("Interval() got %v, want %v", i, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package healthcheck
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+)
+
+// Key is the key of the HealthCheckGate in the gate registry.
+const Key = "HealthCheck"
+
+// Definition returns the definition of the HealthCheckGate for the gate
+// registry, the gate is not enabled if the factory is nil.
+func Definition(factory gates.GateFactory) gates.Definition {
+	def := gates.NewDefinition(Key, "healthCheck", func(g *deployerv1.KustomizationGate) **deployerv1.HealthCheck {
+		return &g.HealthCheck
+	}, factory)
+	def.Validate = validate
+
+	return def
+}
+
+func validate(gate *deployerv1.KustomizationGate, path *field.Path) field.ErrorList {
+	errs := gates.ValidateURL(gate.HealthCheck.URL, path.Child("url"))
+
+	return append(errs, gates.ValidateDuration(&gate.HealthCheck.Interval, false, path.Child("interval"))...)
+}
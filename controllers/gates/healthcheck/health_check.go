@@ -18,12 +18,15 @@ package healthcheck
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
-	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -52,27 +55,34 @@ type HealthCheckGate struct {
 	HTTPClient *http.Client
 }
 
-// Check returns true if the URL returns a 200 response.
-func (g HealthCheckGate) Check(ctx context.Context, gate *deployerv1.KustomizationGate, _ *deployerv1.KustomizationAutoDeployer) (bool, error) {
+// Check is open if the URL returns a 200 response.
+func (g HealthCheckGate) Check(ctx context.Context, checkReq gates.CheckRequest) (gates.Result, error) {
 	// TODO: logging
+	gate := checkReq.Gate
 
-	req, err := http.NewRequest(http.MethodGet, gate.HealthCheck.URL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gate.HealthCheck.URL, nil)
 	if err != nil {
 		// TODO: improve this error!
-		return false, err
+		return gates.Result{}, err
 	}
+	// Propagate the trace context to the health check endpoint.
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	g.Logger.Info("getting healthcheck", "gate", gate.Name, "url", gate.HealthCheck.URL)
 
 	resp, err := g.HTTPClient.Do(req)
 	if err != nil {
 		// TODO: improve this error!
-		return false, err
+		return gates.Result{}, err
 	}
+	defer resp.Body.Close()
 
 	g.Logger.Info("healthcheck complete", "gate", gate.Name, "statusCode", resp.StatusCode)
 
-	return resp.StatusCode == http.StatusOK, nil
+	return gates.Result{
+		Open:    resp.StatusCode == http.StatusOK,
+		Message: fmt.Sprintf("%s returned %s", gate.HealthCheck.URL, resp.Status),
+	}, nil
 }
 
 // Interval returns the time after which to requeue this check.
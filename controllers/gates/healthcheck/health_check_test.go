@@ -26,10 +26,13 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
 	"github.com/gitops-tools/kustomization-auto-deployer/test"
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var _ gates.Gate = (*HealthCheckGate)(nil)
@@ -49,16 +52,16 @@ func TestHealthCheckGate_Check(t *testing.T) {
 
 	testCases := []struct {
 		path string
-		want bool
+		want gates.Result
 	}{
 		{
-			"/open", true,
+			"/open", gates.Result{Open: true, Message: ts.URL + "/open returned 200 OK"},
 		},
 		{
-			"/closed", false,
+			"/closed", gates.Result{Open: false, Message: ts.URL + "/closed returned 500 Internal Server Error"},
 		},
 		{
-			"/other", false,
+			"/other", gates.Result{Open: false, Message: ts.URL + "/other returned 404 Not Found"},
 		},
 	}
 
@@ -66,21 +69,61 @@ func TestHealthCheckGate_Check(t *testing.T) {
 		t.Run(tt.path, func(t *testing.T) {
 			gen := New(logr.Discard(), ts.Client())
 
-			got, err := gen.Check(context.TODO(), &deployerv1.KustomizationGate{
-				Name: "testing",
-				HealthCheck: &deployerv1.HealthCheck{
-					URL: ts.URL + tt.path,
+			got, err := gen.Check(context.TODO(), gates.CheckRequest{
+				Gate: &deployerv1.KustomizationGate{
+					Name: "testing",
+					HealthCheck: &deployerv1.HealthCheck{
+						URL: ts.URL + tt.path,
+					},
 				},
-			}, nil)
+			})
 
 			test.AssertNoError(t, err)
 			if got != tt.want {
-				t.Fatalf("got %v, want %v", got, tt.want)
+				t.Fatalf("got %#v, want %#v", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestHealthCheckGate_Check_propagates_trace_context(t *testing.T) {
+	original := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(original) })
+
+	var traceparent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer ts.Close()
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	test.AssertNoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	test.AssertNoError(t, err)
+	ctx := trace.ContextWithSpanContext(context.TODO(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	gen := New(logr.Discard(), ts.Client())
+	_, err = gen.Check(ctx, gates.CheckRequest{
+		Gate: &deployerv1.KustomizationGate{
+			Name: "testing",
+			HealthCheck: &deployerv1.HealthCheck{
+				URL: ts.URL,
+			},
+		},
+	})
+	test.AssertNoError(t, err)
+
+	want := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	if traceparent != want {
+		t.Errorf("got traceparent %q, want %q", traceparent, want)
+	}
+}
+
 func TestHealthCheckGate_Interval(t *testing.T) {
 	gate := &deployerv1.KustomizationGate{
 		Name: "testing",
@@ -23,18 +23,62 @@ import (
 	"github.com/go-logr/logr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/git"
 )
 
 // GateFactory is a way to create a per-reconciliation gate.
 type GateFactory func(logr.Logger, client.Client) Gate
 
+// CheckRequest is the information provided to a Gate when checking.
+type CheckRequest struct {
+	// Gate is the configuration of the gate being checked.
+	Gate *deployerv1.KustomizationGate
+
+	// Deployer is the KustomizationAutoDeployer that is being reconciled.
+	//
+	// Gates are checked concurrently and must not modify the Deployer, use
+	// UpdateStatus to record state in the status.
+	Deployer *deployerv1.KustomizationAutoDeployer
+
+	// Candidate is the commit that will be deployed if the gates are open.
+	Candidate git.Revision
+
+	// Current is the commit that is currently deployed.
+	Current git.Revision
+
+	updateStatus func(func(*deployerv1.KustomizationAutoDeployerStatus))
+}
+
+// UpdateStatus applies a change to the status of the deployer, the status is
+// persisted after the gates are checked.
+//
+// Changes made after the check has timed out are discarded.
+func (r CheckRequest) UpdateStatus(f func(*deployerv1.KustomizationAutoDeployerStatus)) {
+	if r.updateStatus != nil {
+		r.updateStatus(f)
+		return
+	}
+	f(&r.Deployer.Status)
+}
+
+// Result is the outcome of checking a Gate.
+type Result struct {
+	// Open is true if the Gate is open.
+	Open bool
+
+	// Message is a human-readable explanation of the result.
+	//
+	// If this is empty a default message is used.
+	Message string
+}
+
 // Gate defines the interface implemented by all gates.
 type Gate interface {
-	// Check returns true if the Gate is open.
+	// Check returns an open Result if the Gate is open.
 	//
 	// Errors are only for exceptional cases.
-	Check(context.Context, *deployerv1.KustomizationGate, *deployerv1.KustomizationAutoDeployer) (bool, error)
+	Check(context.Context, CheckRequest) (Result, error)
 
 	// Interval is the time after which a Gate should be checked.
 	//
@@ -43,6 +87,41 @@ type Gate interface {
 	Interval(*deployerv1.KustomizationGate) (time.Duration, error)
 }
 
+// CacheableGate can be implemented by gates to disable caching of the results,
+// e.g. because the result can change outside of the check.
+//
+// The results of gates that do not implement this interface are cached until
+// the Interval has elapsed.
+type CacheableGate interface {
+	// Cacheable returns false if the result of the check must not be reused.
+	Cacheable() bool
+}
+
+// BoolGate is the interface implemented by gates that report only whether or
+// not they are open.
+type BoolGate interface {
+	// Check returns true if the Gate is open.
+	Check(context.Context, *deployerv1.KustomizationGate, *deployerv1.KustomizationAutoDeployer) (bool, error)
+
+	// Interval is the time after which a Gate should be checked.
+	Interval(*deployerv1.KustomizationGate) (time.Duration, error)
+}
+
+// FromBoolGate adapts a BoolGate to the Gate interface.
+func FromBoolGate(g BoolGate) Gate {
+	return boolGate{BoolGate: g}
+}
+
+type boolGate struct {
+	BoolGate
+}
+
+func (g boolGate) Check(ctx context.Context, req CheckRequest) (Result, error) {
+	open, err := g.BoolGate.Check(ctx, req.Gate, req.Deployer)
+
+	return Result{Open: open}, err
+}
+
 // NoRequeueInterval is a simple default value that can be used to indicate that
 // a Gate should not requeue after a time duration.
 var NoRequeueInterval time.Duration
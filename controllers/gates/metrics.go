@@ -0,0 +1,69 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gates
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+var (
+	checkDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "kustomization_auto_deployer_gate_check_duration_seconds",
+			Help:    "Duration of the gate checks.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"namespace", "name", "gate", "check"},
+	)
+
+	checkErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kustomization_auto_deployer_gate_check_errors_total",
+			Help: "Number of gate checks that returned an error.",
+		},
+		[]string{"namespace", "name", "gate", "check"},
+	)
+
+	checkTimeouts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kustomization_auto_deployer_gate_check_timeouts_total",
+			Help: "Number of gate checks that timed out.",
+		},
+		[]string{"namespace", "name", "gate", "check"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(checkDuration, checkErrors, checkTimeouts)
+}
+
+// DeleteMetrics deletes the gate check metrics for the deployer.
+func DeleteMetrics(deployer *deployerv1.KustomizationAutoDeployer) {
+	labels := prometheus.Labels{"namespace": deployer.GetNamespace(), "name": deployer.GetName()}
+	checkDuration.DeletePartialMatch(labels)
+	checkErrors.DeletePartialMatch(labels)
+	checkTimeouts.DeletePartialMatch(labels)
+}
+
+// checkLabels returns the labels for the check, the check is identified by its
+// name, a gate can have more than one check of the same kind.
+func checkLabels(req CheckRequest, rg RelevantGate) []string {
+	return []string{req.Deployer.GetNamespace(), req.Deployer.GetName(), req.Gate.Name, rg.Name}
+}
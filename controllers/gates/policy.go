@@ -0,0 +1,77 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gates
+
+import (
+	"fmt"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+// PolicySource returns the Source recorded in the status of the gates from a
+// DeploymentPolicy.
+func PolicySource(policy *deployerv1.DeploymentPolicy) string {
+	return "DeploymentPolicy/" + policy.Name
+}
+
+// PolicyGateName returns the name that a gate from a DeploymentPolicy is
+// checked with, this identifies the gate in the status and callbacks of the
+// deployer.
+func PolicyGateName(policy *deployerv1.DeploymentPolicy, gate *deployerv1.KustomizationGate) string {
+	return policy.Name + "/" + gate.Name
+}
+
+// configuredGate is a gate to be checked, with the source that configured it.
+//
+// Gates from a policy are not affected by the FailurePolicy or GatesTimeout of
+// the deployer.
+type configuredGate struct {
+	gate   deployerv1.KustomizationGate
+	source string
+	policy bool
+}
+
+// mergeGates returns the gates configured in the deployer, followed by the
+// gates from the policies.
+//
+// A gate in the deployer with the same name as a gate from a policy is an
+// error, the policy gate can't be replaced by the deployer.
+func mergeGates(deployerGates []deployerv1.KustomizationGate, policies []deployerv1.DeploymentPolicy) ([]configuredGate, error) {
+	configured := make([]configuredGate, 0, len(deployerGates))
+	names := map[string]string{}
+	for _, gate := range deployerGates {
+		configured = append(configured, configuredGate{gate: gate})
+		names[gate.Name] = ""
+	}
+
+	for i := range policies {
+		policy := &policies[i]
+		for _, gate := range policy.Spec.Gates {
+			gate.Name = PolicyGateName(policy, &gate)
+			if source, ok := names[gate.Name]; ok {
+				if source == "" {
+					return nil, fmt.Errorf("gate %s conflicts with a gate from %s", gate.Name, PolicySource(policy))
+				}
+				return nil, fmt.Errorf("gate %s from %s conflicts with a gate from %s", gate.Name, source, PolicySource(policy))
+			}
+			names[gate.Name] = PolicySource(policy)
+			configured = append(configured, configuredGate{gate: gate, source: PolicySource(policy), policy: true})
+		}
+	}
+
+	return configured, nil
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+)
+
+// Key is the key of the RateLimitGate in the gate registry.
+const Key = "RateLimit"
+
+// Definition returns the definition of the RateLimitGate for the gate
+// registry, the gate is not enabled if the factory is nil.
+func Definition(factory gates.GateFactory) gates.Definition {
+	def := gates.NewDefinition(Key, "rateLimit", func(g *deployerv1.KustomizationGate) **deployerv1.RateLimitCheck {
+		return &g.RateLimit
+	}, factory)
+	def.Validate = validate
+
+	return def
+}
+
+func validate(gate *deployerv1.KustomizationGate, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if limit := gate.RateLimit.Limit; limit < 1 || limit > deployerv1.MaxRateLimit {
+		errs = append(errs, field.Invalid(path.Child("limit"), limit, fmt.Sprintf("must be between 1 and %d", deployerv1.MaxRateLimit)))
+	}
+	errs = append(errs, gates.ValidateDuration(&gate.RateLimit.Window, true, path.Child("window"))...)
+	if window := gate.RateLimit.Window.Duration; window > deployerv1.MaxRateLimitWindow {
+		errs = append(errs, field.Invalid(path.Child("window"), window.String(), fmt.Sprintf("must be at most %s", deployerv1.MaxRateLimitWindow)))
+	}
+
+	return errs
+}
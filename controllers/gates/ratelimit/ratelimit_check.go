@@ -0,0 +1,110 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+)
+
+// Factory is a function for creating per-reconciliation gates for the
+// RateLimitGate.
+func Factory(l logr.Logger, _ client.Client) gates.Gate {
+	return New(l)
+}
+
+// New creates and returns a new RateLimitGate.
+func New(l logr.Logger, opts ...func(*RateLimitGate)) *RateLimitGate {
+	g := &RateLimitGate{
+		Logger:   l,
+		Clock:    time.Now,
+		nextSlot: map[*deployerv1.KustomizationGate]time.Time{},
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// RateLimitGate is open if fewer than the Limit of commits were advanced
+// within the Window, the commits are counted from the AdvancedTimes in the
+// status of the deployer.
+//
+// When the gate is closed, the time that the next commit can be advanced is
+// recorded for the gate, and the Interval is the time until then.
+type RateLimitGate struct {
+	Logger logr.Logger
+	Clock  func() time.Time
+
+	mu       sync.Mutex
+	nextSlot map[*deployerv1.KustomizationGate]time.Time
+}
+
+// Check is open if there is a free slot in the window.
+func (g *RateLimitGate) Check(ctx context.Context, req gates.CheckRequest) (gates.Result, error) {
+	rateLimit := req.Gate.RateLimit
+	now := g.Clock()
+	windowStart := now.Add(-rateLimit.Window.Duration)
+
+	var advanced []time.Time
+	for _, t := range req.Deployer.Status.AdvancedTimes {
+		if t.Time.After(windowStart) {
+			advanced = append(advanced, t.Time)
+		}
+	}
+
+	counted := fmt.Sprintf("%d of %d commits advanced in the last %s", len(advanced), rateLimit.Limit, rateLimit.Window.Duration)
+	if len(advanced) < int(rateLimit.Limit) {
+		return gates.Result{Open: true, Message: counted}, nil
+	}
+
+	// The AdvancedTimes are oldest first, a slot is free when all but
+	// Limit-1 of the advanced commits have left the window.
+	next := advanced[len(advanced)-int(rateLimit.Limit)].Add(rateLimit.Window.Duration)
+	g.mu.Lock()
+	g.nextSlot[req.Gate] = next
+	g.mu.Unlock()
+
+	return gates.Result{Open: false, Message: fmt.Sprintf("%s, closed until %s", counted, next.UTC().Format(time.RFC3339))}, nil
+}
+
+// Interval returns the time until the next slot is free if the gate was
+// closed when it was checked.
+func (g *RateLimitGate) Interval(gate *deployerv1.KustomizationGate) (time.Duration, error) {
+	g.mu.Lock()
+	next, ok := g.nextSlot[gate]
+	g.mu.Unlock()
+	if !ok {
+		return gates.NoRequeueInterval, nil
+	}
+
+	if d := next.Sub(g.Clock()); d > gates.NoRequeueInterval {
+		return d, nil
+	}
+
+	return gates.NoRequeueInterval, nil
+}
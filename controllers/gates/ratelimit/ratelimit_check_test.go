@@ -0,0 +1,117 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+	"github.com/gitops-tools/kustomization-auto-deployer/test"
+)
+
+var _ gates.Gate = (*RateLimitGate)(nil)
+
+func TestRateLimitGate(t *testing.T) {
+	// 9am on the 14th May 2023
+	now := time.Date(2023, time.May, 14, 9, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		advanced     []time.Duration
+		want         gates.Result
+		wantInterval time.Duration
+	}{
+		{
+			name: "no advanced commits",
+			want: gates.Result{Open: true, Message: "0 of 3 commits advanced in the last 1h0m0s"},
+		},
+		{
+			name:     "advanced commits outside the window",
+			advanced: []time.Duration{-3 * time.Hour, -2 * time.Hour, -90 * time.Minute, -time.Hour},
+			want:     gates.Result{Open: true, Message: "0 of 3 commits advanced in the last 1h0m0s"},
+		},
+		{
+			name:     "fewer advanced commits than the limit",
+			advanced: []time.Duration{-2 * time.Hour, -40 * time.Minute, -10 * time.Minute},
+			want:     gates.Result{Open: true, Message: "2 of 3 commits advanced in the last 1h0m0s"},
+		},
+		{
+			name:         "limit reached",
+			advanced:     []time.Duration{-2 * time.Hour, -40 * time.Minute, -20 * time.Minute, -10 * time.Minute},
+			want:         gates.Result{Open: false, Message: "3 of 3 commits advanced in the last 1h0m0s, closed until 2023-05-14T09:20:00Z"},
+			wantInterval: 20 * time.Minute,
+		},
+		{
+			name:         "limit exceeded",
+			advanced:     []time.Duration{-50 * time.Minute, -40 * time.Minute, -30 * time.Minute, -5 * time.Minute},
+			want:         gates.Result{Open: false, Message: "4 of 3 commits advanced in the last 1h0m0s, closed until 2023-05-14T09:20:00Z"},
+			wantInterval: 20 * time.Minute,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := New(logr.Discard(), func(g *RateLimitGate) {
+				g.Clock = func() time.Time {
+					return now
+				}
+			})
+			deployer := &deployerv1.KustomizationAutoDeployer{}
+			for _, d := range tt.advanced {
+				deployer.Status.AdvancedTimes = append(deployer.Status.AdvancedTimes, metav1.NewTime(now.Add(d)))
+			}
+			gate := &deployerv1.KustomizationGate{
+				Name: "testing",
+				RateLimit: &deployerv1.RateLimitCheck{
+					Limit:  3,
+					Window: metav1.Duration{Duration: time.Hour},
+				},
+			}
+
+			got, err := gen.Check(context.TODO(), gates.CheckRequest{Gate: gate, Deployer: deployer})
+			test.AssertNoError(t, err)
+			if got != tt.want {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+
+			interval, err := gen.Interval(gate)
+			test.AssertNoError(t, err)
+			if interval != tt.wantInterval {
+				t.Fatalf("Interval() got %v, want %v", interval, tt.wantInterval)
+			}
+		})
+	}
+}
+
+func TestRateLimitGate_Interval_unchecked(t *testing.T) {
+	gen := Factory(logr.Discard(), nil)
+
+	interval, err := gen.Interval(&deployerv1.KustomizationGate{
+		Name:      "testing",
+		RateLimit: &deployerv1.RateLimitCheck{Limit: 1, Window: metav1.Duration{Duration: time.Hour}},
+	})
+	test.AssertNoError(t, err)
+	if interval != gates.NoRequeueInterval {
+		t.Fatalf("Interval() got %v, want %v", interval, gates.NoRequeueInterval)
+	}
+}
@@ -0,0 +1,351 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gates
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+// Definition describes a kind of gate that can be configured in a
+// KustomizationGate.
+type Definition struct {
+	// Key identifies the kind of gate, e.g. HealthCheck, this is the Kind of
+	// checks configured in the checks list.
+	Key string
+
+	// FieldName is the JSON name of the field that configures the gate in the
+	// KustomizationGate, e.g. healthCheck.
+	FieldName string
+
+	// Factory creates the Gate, gates without a Factory are known but not
+	// enabled.
+	Factory GateFactory
+
+	// Unique is true if at most one check of this kind can be configured in a
+	// gate.
+	Unique bool
+
+	// IsConfigured returns true if the field for the gate is set.
+	IsConfigured func(*deployerv1.KustomizationGate) bool
+
+	// Decode sets the field for the gate from the config of a check.
+	Decode func([]byte, *deployerv1.KustomizationGate) error
+
+	// Encode returns the config of a check from the field for the gate.
+	Encode func(*deployerv1.KustomizationGate) ([]byte, error)
+
+	// Validate returns the errors in the field for the gate, the path is the
+	// path to the configuration of the gate.
+	Validate func(*deployerv1.KustomizationGate, *field.Path) field.ErrorList
+
+	// Default sets the defaults in the field for the gate.
+	Default func(*deployerv1.KustomizationGate)
+}
+
+// NewDefinition creates a Definition for a gate configured by a field of the
+// KustomizationGate, the accessor returns the field.
+func NewDefinition[T any](key, fieldName string, accessor func(*deployerv1.KustomizationGate) **T, factory GateFactory) Definition {
+	return Definition{
+		Key:       key,
+		FieldName: fieldName,
+		Factory:   factory,
+		IsConfigured: func(g *deployerv1.KustomizationGate) bool {
+			return *accessor(g) != nil
+		},
+		Decode: func(b []byte, g *deployerv1.KustomizationGate) error {
+			v := new(T)
+			if len(b) > 0 {
+				if err := json.Unmarshal(b, v); err != nil {
+					return err
+				}
+			}
+			*accessor(g) = v
+
+			return nil
+		},
+		Encode: func(g *deployerv1.KustomizationGate) ([]byte, error) {
+			return json.Marshal(*accessor(g))
+		},
+	}
+}
+
+// Registry is the set of gates known to the controller.
+type Registry struct {
+	keys        []string
+	definitions map[string]Definition
+}
+
+// NewRegistry creates and returns a new Registry, gates are checked in the
+// order they are registered.
+func NewRegistry(defs ...Definition) (*Registry, error) {
+	r := &Registry{definitions: map[string]Definition{}}
+	for _, def := range defs {
+		if def.Key == "" {
+			return nil, fmt.Errorf("gate definition has no key")
+		}
+		if def.IsConfigured == nil || def.Decode == nil || def.Encode == nil {
+			return nil, fmt.Errorf("gate definition %s is incomplete", def.Key)
+		}
+		if _, ok := r.definitions[def.Key]; ok {
+			return nil, fmt.Errorf("gate %s registered more than once", def.Key)
+		}
+		r.keys = append(r.keys, def.Key)
+		r.definitions[def.Key] = def
+	}
+
+	return r, nil
+}
+
+// Lookup returns the Definition for a key.
+func (r *Registry) Lookup(key string) (Definition, bool) {
+	def, ok := r.definitions[key]
+
+	return def, ok
+}
+
+// Keys returns the keys of the registered gates in registration order.
+func (r *Registry) Keys() []string {
+	return append([]string(nil), r.keys...)
+}
+
+// Instantiate creates the enabled gates for a reconciliation.
+func (r *Registry) Instantiate(l logr.Logger, c client.Client) *Set {
+	gates := map[string]Gate{}
+	for _, key := range r.keys {
+		if factory := r.definitions[key].Factory; factory != nil {
+			gates[key] = factory(l, c)
+		}
+	}
+
+	return &Set{registry: r, gates: gates, client: c}
+}
+
+// Default sets the defaults for the checks configured in the gate.
+func (r *Registry) Default(gate *deployerv1.KustomizationGate) {
+	for _, key := range r.keys {
+		def := r.definitions[key]
+		if def.Default != nil && def.IsConfigured(gate) {
+			def.Default(gate)
+		}
+	}
+
+	for i := range gate.Checks {
+		check := &gate.Checks[i]
+		def, ok := r.definitions[check.Kind]
+		if !ok || def.Default == nil {
+			continue
+		}
+		config, err := decodeCheck(def, *gate, *check)
+		if err != nil {
+			continue
+		}
+		def.Default(config)
+		b, err := def.Encode(config)
+		if err != nil {
+			continue
+		}
+		check.Config = &apiextensionsv1.JSON{Raw: b}
+	}
+}
+
+// Validate returns the errors in the configuration of the gate, including
+// checks for gates that are not enabled.
+//
+// Exactly one of the fields for the gates, the templateRef or the checks must
+// be set.
+func (r *Registry) Validate(gate *deployerv1.KustomizationGate, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	var set []string
+	for _, key := range r.keys {
+		def := r.definitions[key]
+		if !def.IsConfigured(gate) {
+			continue
+		}
+		set = append(set, def.FieldName)
+		if def.Factory == nil {
+			errs = append(errs, field.Forbidden(path.Child(def.FieldName), notEnabledMessage(key)))
+		}
+		if def.Validate != nil {
+			errs = append(errs, def.Validate(gate, path.Child(def.FieldName))...)
+		}
+	}
+	if gate.TemplateRef != nil {
+		set = append(set, "templateRef")
+		if gate.TemplateRef.Name == "" {
+			errs = append(errs, field.Required(path.Child("templateRef", "name"), ""))
+		}
+	}
+	if len(gate.Checks) > 0 {
+		set = append(set, "checks")
+	}
+
+	names := sets.New[string]()
+	kinds := sets.New[string]()
+	for i, check := range gate.Checks {
+		checkPath := path.Child("checks").Index(i)
+		if check.Name == "" {
+			errs = append(errs, field.Required(checkPath.Child("name"), ""))
+		} else if names.Has(check.Name) {
+			errs = append(errs, field.Duplicate(checkPath.Child("name"), check.Name))
+		}
+		names.Insert(check.Name)
+
+		def, ok := r.definitions[check.Kind]
+		if !ok {
+			errs = append(errs, field.NotSupported(checkPath.Child("kind"), check.Kind, r.keys))
+			continue
+		}
+		if def.Factory == nil {
+			errs = append(errs, field.Forbidden(checkPath.Child("kind"), notEnabledMessage(check.Kind)))
+		}
+		if def.Unique && kinds.Has(check.Kind) {
+			errs = append(errs, field.Invalid(checkPath.Child("kind"), check.Kind, "only one check of this kind can be configured in a gate"))
+		}
+		kinds.Insert(check.Kind)
+		config, err := decodeCheck(def, *gate, check)
+		if err != nil {
+			errs = append(errs, field.Invalid(checkPath.Child("config"), check.Config, err.Error()))
+			continue
+		}
+		if def.Validate != nil {
+			errs = append(errs, def.Validate(config, checkPath.Child("config"))...)
+		}
+	}
+
+	switch len(set) {
+	case 0:
+		errs = append(errs, field.Required(path, r.exactlyOneMessage()))
+	case 1:
+	default:
+		errs = append(errs, field.Invalid(path, strings.Join(set, ", "), r.exactlyOneMessage()))
+	}
+
+	return errs
+}
+
+func (r *Registry) exactlyOneMessage() string {
+	fields := make([]string, 0, len(r.keys))
+	for _, key := range r.keys {
+		fields = append(fields, r.definitions[key].FieldName)
+	}
+
+	return fmt.Sprintf("exactly one of %s, templateRef or checks must be set", strings.Join(fields, ", "))
+}
+
+func notEnabledMessage(key string) string {
+	return fmt.Sprintf("gate %s is not enabled in the controller", key)
+}
+
+// Set is the enabled gates for a reconciliation.
+//
+// The client is used to get the GateTemplates referenced by gates.
+type Set struct {
+	registry *Registry
+	gates    map[string]Gate
+	client   client.Client
+}
+
+// Resolve returns the enabled gates for the checks configured in the gate,
+// checks configured by field are returned in registration order followed by
+// the checks in the checks list.
+func (s *Set) Resolve(gate deployerv1.KustomizationGate) ([]RelevantGate, error) {
+	res := []RelevantGate{}
+	for _, key := range s.registry.keys {
+		def := s.registry.definitions[key]
+		if !def.IsConfigured(&gate) {
+			continue
+		}
+		g, ok := s.gates[key]
+		if !ok {
+			return nil, GateNotEnabledError{Name: key}
+		}
+		config, err := copyField(def, gate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy the configuration for %s: %w", key, err)
+		}
+		if def.Default != nil {
+			def.Default(config)
+		}
+		res = append(res, RelevantGate{Name: key, Kind: key, Gate: g, Config: config})
+	}
+
+	for _, check := range gate.Checks {
+		def, ok := s.registry.definitions[check.Kind]
+		if !ok {
+			return nil, fmt.Errorf("check %s has unknown kind %q", check.Name, check.Kind)
+		}
+		g, ok := s.gates[check.Kind]
+		if !ok {
+			return nil, GateNotEnabledError{Name: check.Kind}
+		}
+		config, err := decodeCheck(def, gate, check)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the config for check %s: %w", check.Name, err)
+		}
+		if def.Default != nil {
+			def.Default(config)
+		}
+		res = append(res, RelevantGate{Name: check.Name, Kind: check.Kind, Gate: g, Config: config})
+	}
+
+	return res, nil
+}
+
+// configFor returns a KustomizationGate with the Name and Timeout of the gate
+// and no checks.
+func configFor(gate deployerv1.KustomizationGate) *deployerv1.KustomizationGate {
+	return &deployerv1.KustomizationGate{Name: gate.Name, Timeout: gate.Timeout.DeepCopy()}
+}
+
+// copyField returns a KustomizationGate with a copy of the field for the gate.
+func copyField(def Definition, gate deployerv1.KustomizationGate) (*deployerv1.KustomizationGate, error) {
+	b, err := def.Encode(&gate)
+	if err != nil {
+		return nil, err
+	}
+	config := configFor(gate)
+	if err := def.Decode(b, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// decodeCheck returns a KustomizationGate with the field for the kind of the
+// check set from the config of the check.
+func decodeCheck(def Definition, gate deployerv1.KustomizationGate, check deployerv1.GateCheck) (*deployerv1.KustomizationGate, error) {
+	config := configFor(gate)
+	var raw []byte
+	if check.Config != nil {
+		raw = check.Config.Raw
+	}
+	if err := def.Decode(raw, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
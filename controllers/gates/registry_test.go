@@ -0,0 +1,427 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gates_test
+
+// This is in a _test package to prevent import cycles.
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/callback"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/healthcheck"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/scheduled"
+	"github.com/gitops-tools/kustomization-auto-deployer/test"
+)
+
+var (
+	healthCheckGate = &healthcheck.HealthCheckGate{}
+	scheduledGate   = &scheduled.ScheduledGate{}
+	callbackGate    = &callback.CallbackGate{}
+)
+
+func TestSetResolve(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	tests := []struct {
+		name string
+		gate deployerv1.KustomizationGate
+		want []gates.RelevantGate
+	}{
+		{
+			name: "no gates",
+			gate: deployerv1.KustomizationGate{},
+			want: []gates.RelevantGate{},
+		},
+		{
+			name: "one gate",
+			gate: deployerv1.KustomizationGate{
+				Name:        "test-gate",
+				HealthCheck: &deployerv1.HealthCheck{URL: "https://example.com/"},
+			},
+			want: []gates.RelevantGate{
+				{Name: "HealthCheck", Kind: "HealthCheck", Gate: healthCheckGate, Config: &deployerv1.KustomizationGate{
+					Name:        "test-gate",
+					HealthCheck: &deployerv1.HealthCheck{URL: "https://example.com/"},
+				}},
+			},
+		},
+		{
+			name: "two gates in registration order",
+			gate: deployerv1.KustomizationGate{
+				Scheduled:   &deployerv1.ScheduledCheck{Open: "09:00", Close: "17:00"},
+				HealthCheck: &deployerv1.HealthCheck{},
+			},
+			want: []gates.RelevantGate{
+				{Name: "HealthCheck", Kind: "HealthCheck", Gate: healthCheckGate, Config: &deployerv1.KustomizationGate{
+					HealthCheck: &deployerv1.HealthCheck{},
+				}},
+				{Name: "Scheduled", Kind: "Scheduled", Gate: scheduledGate, Config: &deployerv1.KustomizationGate{
+					Scheduled: &deployerv1.ScheduledCheck{Open: "09:00", Close: "17:00"},
+				}},
+			},
+		},
+		{
+			name: "gate with a timeout",
+			gate: deployerv1.KustomizationGate{
+				Timeout:     &metav1.Duration{Duration: time.Second},
+				HealthCheck: &deployerv1.HealthCheck{},
+			},
+			want: []gates.RelevantGate{
+				{Name: "HealthCheck", Kind: "HealthCheck", Gate: healthCheckGate, Config: &deployerv1.KustomizationGate{
+					Timeout:     &metav1.Duration{Duration: time.Second},
+					HealthCheck: &deployerv1.HealthCheck{},
+				}},
+			},
+		},
+		{
+			name: "checks of the same kind",
+			gate: deployerv1.KustomizationGate{
+				Name: "test-gate",
+				Checks: []deployerv1.GateCheck{
+					{Name: "api", Kind: "HealthCheck", Config: jsonConfig(`{"url":"https://api.example.com/","interval":"5m"}`)},
+					{Name: "ui", Kind: "HealthCheck", Config: jsonConfig(`{"url":"https://ui.example.com/","interval":"1m"}`)},
+				},
+			},
+			want: []gates.RelevantGate{
+				{Name: "api", Kind: "HealthCheck", Gate: healthCheckGate, Config: &deployerv1.KustomizationGate{
+					Name:        "test-gate",
+					HealthCheck: &deployerv1.HealthCheck{URL: "https://api.example.com/", Interval: metav1.Duration{Duration: time.Minute * 5}},
+				}},
+				{Name: "ui", Kind: "HealthCheck", Gate: healthCheckGate, Config: &deployerv1.KustomizationGate{
+					Name:        "test-gate",
+					HealthCheck: &deployerv1.HealthCheck{URL: "https://ui.example.com/", Interval: metav1.Duration{Duration: time.Minute}},
+				}},
+			},
+		},
+		{
+			name: "defaults are applied",
+			gate: deployerv1.KustomizationGate{
+				Callback: &deployerv1.CallbackCheck{URL: "https://example.com/"},
+			},
+			want: []gates.RelevantGate{
+				{Name: "Callback", Kind: "Callback", Gate: callbackGate, Config: &deployerv1.KustomizationGate{
+					Callback: &deployerv1.CallbackCheck{URL: "https://example.com/", Timeout: &metav1.Duration{Duration: callback.DefaultTimeout}},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := registry.Instantiate(logr.Discard(), nil).Resolve(tt.gate)
+			test.AssertNoError(t, err)
+			if diff := cmp.Diff(tt.want, got, cmp.Comparer(sameGate)); diff != "" {
+				t.Fatalf("failed to resolve gates:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetResolve_does_not_modify_the_gate(t *testing.T) {
+	gate := deployerv1.KustomizationGate{
+		Callback: &deployerv1.CallbackCheck{URL: "https://example.com/"},
+	}
+
+	_, err := newTestRegistry(t).Instantiate(logr.Discard(), nil).Resolve(gate)
+	test.AssertNoError(t, err)
+
+	if gate.Callback.Timeout != nil {
+		t.Fatalf("Resolve() set the default in the gate")
+	}
+}
+
+func TestSetResolveErrors(t *testing.T) {
+	registry, err := gates.NewRegistry(
+		healthcheck.Definition(staticFactory(healthCheckGate)),
+		scheduled.Definition(nil),
+	)
+	test.AssertNoError(t, err)
+
+	tests := []struct {
+		name       string
+		gate       deployerv1.KustomizationGate
+		err        string
+		notEnabled bool
+	}{
+		{
+			name: "gate not enabled",
+			gate: deployerv1.KustomizationGate{
+				HealthCheck: &deployerv1.HealthCheck{},
+				Scheduled:   &deployerv1.ScheduledCheck{},
+			},
+			err:        "Scheduled not enabled",
+			notEnabled: true,
+		},
+		{
+			name: "check not enabled",
+			gate: deployerv1.KustomizationGate{
+				Checks: []deployerv1.GateCheck{{Name: "test", Kind: "Scheduled"}},
+			},
+			err:        "Scheduled not enabled",
+			notEnabled: true,
+		},
+		{
+			name: "unknown kind",
+			gate: deployerv1.KustomizationGate{
+				Checks: []deployerv1.GateCheck{{Name: "test", Kind: "Unknown"}},
+			},
+			err: `check test has unknown kind "Unknown"`,
+		},
+		{
+			name: "invalid config",
+			gate: deployerv1.KustomizationGate{
+				Checks: []deployerv1.GateCheck{{Name: "test", Kind: "HealthCheck", Config: jsonConfig(`{"url":1}`)}},
+			},
+			err: "failed to decode the config for check test",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := registry.Instantiate(logr.Discard(), nil).Resolve(tt.gate)
+			test.AssertErrorMatch(t, tt.err, err)
+			if tt.notEnabled && !errors.Is(err, gates.GateNotEnabledError{Name: "Scheduled"}) {
+				t.Errorf(`Resolve() error should be GateNotEnabledError{Name: "Scheduled"}`)
+			}
+		})
+	}
+}
+
+func TestNewRegistry_duplicate_keys(t *testing.T) {
+	_, err := gates.NewRegistry(
+		healthcheck.Definition(nil),
+		healthcheck.Definition(nil),
+	)
+
+	test.AssertErrorMatch(t, "gate HealthCheck registered more than once", err)
+}
+
+func TestRegistryKeys(t *testing.T) {
+	want := []string{"HealthCheck", "Scheduled", "Callback"}
+	if diff := cmp.Diff(want, newTestRegistry(t).Keys()); diff != "" {
+		t.Fatalf("failed to get keys:\n%s", diff)
+	}
+}
+
+func TestRegistryValidate(t *testing.T) {
+	registry := newTestRegistry(t)
+	path := field.NewPath("spec", "gates").Index(0)
+
+	tests := []struct {
+		name string
+		gate deployerv1.KustomizationGate
+		want []string
+	}{
+		{
+			name: "valid gate",
+			gate: deployerv1.KustomizationGate{
+				HealthCheck: &deployerv1.HealthCheck{URL: "https://example.com/"},
+			},
+		},
+		{
+			name: "valid checks",
+			gate: deployerv1.KustomizationGate{
+				Checks: []deployerv1.GateCheck{
+					{Name: "api", Kind: "HealthCheck", Config: jsonConfig(`{"url":"https://api.example.com/"}`)},
+					{Name: "hours", Kind: "Scheduled", Config: jsonConfig(`{"open":"09:00","close":"17:00"}`)},
+				},
+			},
+		},
+		{
+			name: "valid template reference",
+			gate: deployerv1.KustomizationGate{
+				TemplateRef: &meta.LocalObjectReference{Name: "production-gates"},
+			},
+		},
+		{
+			name: "template reference with checks",
+			gate: deployerv1.KustomizationGate{
+				HealthCheck: &deployerv1.HealthCheck{URL: "https://example.com/"},
+				TemplateRef: &meta.LocalObjectReference{},
+			},
+			want: []string{
+				`spec.gates[0].templateRef.name: Required value`,
+				`spec.gates[0]: Invalid value: "healthCheck, templateRef": exactly one of healthCheck, scheduled, callback, templateRef or checks must be set`,
+			},
+		},
+		{
+			name: "no checks",
+			gate: deployerv1.KustomizationGate{},
+			want: []string{"spec.gates[0]: Required value: exactly one of healthCheck, scheduled, callback, templateRef or checks must be set"},
+		},
+		{
+			name: "invalid fields",
+			gate: deployerv1.KustomizationGate{
+				HealthCheck: &deployerv1.HealthCheck{URL: "example.com", Interval: metav1.Duration{Duration: -time.Second}},
+				Scheduled:   &deployerv1.ScheduledCheck{Open: "17:00", Close: "9am"},
+			},
+			want: []string{
+				`spec.gates[0].healthCheck.url: Invalid value: "example.com": must be an absolute http or https URL`,
+				`spec.gates[0].healthCheck.interval: Invalid value: "-1s": must not be negative`,
+				`spec.gates[0].scheduled.close: Invalid value: "9am": must be a time in the format hh:mm`,
+				`spec.gates[0]: Invalid value: "healthCheck, scheduled": exactly one of healthCheck, scheduled, callback, templateRef or checks must be set`,
+			},
+		},
+		{
+			name: "more than one field",
+			gate: deployerv1.KustomizationGate{
+				HealthCheck: &deployerv1.HealthCheck{URL: "https://example.com/"},
+				Checks: []deployerv1.GateCheck{
+					{Name: "api", Kind: "HealthCheck", Config: jsonConfig(`{"url":"https://api.example.com/"}`)},
+				},
+			},
+			want: []string{`spec.gates[0]: Invalid value: "healthCheck, checks": exactly one of healthCheck, scheduled, callback, templateRef or checks must be set`},
+		},
+		{
+			name: "close before open",
+			gate: deployerv1.KustomizationGate{
+				Scheduled: &deployerv1.ScheduledCheck{Open: "17:00", Close: "09:00"},
+			},
+			want: []string{`spec.gates[0].scheduled.close: Invalid value: "09:00": must be after open`},
+		},
+		{
+			name: "invalid checks",
+			gate: deployerv1.KustomizationGate{
+				Checks: []deployerv1.GateCheck{
+					{Name: "api", Kind: "HealthCheck", Config: jsonConfig(`{"url":"https://api.example.com/"}`)},
+					{Kind: "Unknown"},
+					{Name: "api", Kind: "HealthCheck"},
+				},
+			},
+			want: []string{
+				`spec.gates[0].checks[1].name: Required value`,
+				`spec.gates[0].checks[1].kind: Unsupported value: "Unknown": supported values: "HealthCheck", "Scheduled", "Callback"`,
+				`spec.gates[0].checks[2].name: Duplicate value: "api"`,
+				`spec.gates[0].checks[2].config.url: Required value`,
+			},
+		},
+		{
+			name: "more than one callback",
+			gate: deployerv1.KustomizationGate{
+				Checks: []deployerv1.GateCheck{
+					{Name: "first", Kind: "Callback", Config: jsonConfig(`{"url":"https://example.com/"}`)},
+					{Name: "second", Kind: "Callback", Config: jsonConfig(`{"url":"https://example.com/"}`)},
+				},
+			},
+			want: []string{`spec.gates[0].checks[1].kind: Invalid value: "Callback": only one check of this kind can be configured in a gate`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range registry.Validate(&tt.gate, path) {
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("failed to validate:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRegistryValidate_gates_not_enabled(t *testing.T) {
+	registry, err := gates.NewRegistry(
+		healthcheck.Definition(staticFactory(healthCheckGate)),
+		scheduled.Definition(nil),
+	)
+	test.AssertNoError(t, err)
+	configured := []deployerv1.KustomizationGate{
+		{
+			Scheduled: &deployerv1.ScheduledCheck{Open: "09:00", Close: "17:00"},
+		},
+		{
+			Checks: []deployerv1.GateCheck{
+				{Name: "afternoon", Kind: "Scheduled", Config: jsonConfig(`{"open":"13:00","close":"17:00"}`)},
+			},
+		},
+	}
+
+	var got []string
+	for i := range configured {
+		for _, err := range registry.Validate(&configured[i], field.NewPath("gates").Index(i)) {
+			got = append(got, err.Error())
+		}
+	}
+
+	want := []string{
+		"gates[0].scheduled: Forbidden: gate Scheduled is not enabled in the controller",
+		"gates[1].checks[0].kind: Forbidden: gate Scheduled is not enabled in the controller",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("failed to validate:\n%s", diff)
+	}
+}
+
+func TestRegistryDefault(t *testing.T) {
+	gate := deployerv1.KustomizationGate{
+		Callback: &deployerv1.CallbackCheck{URL: "https://example.com/"},
+		Checks: []deployerv1.GateCheck{
+			{Name: "other", Kind: "Callback", Config: jsonConfig(`{"url":"https://example.com/other"}`)},
+		},
+	}
+
+	newTestRegistry(t).Default(&gate)
+
+	want := deployerv1.KustomizationGate{
+		Callback: &deployerv1.CallbackCheck{URL: "https://example.com/", Timeout: &metav1.Duration{Duration: time.Hour}},
+		Checks: []deployerv1.GateCheck{
+			{Name: "other", Kind: "Callback", Config: jsonConfig(`{"url":"https://example.com/other","timeout":"1h0m0s"}`)},
+		},
+	}
+	if diff := cmp.Diff(want, gate); diff != "" {
+		t.Fatalf("failed to set defaults:\n%s", diff)
+	}
+}
+
+func newTestRegistry(t *testing.T) *gates.Registry {
+	t.Helper()
+	registry, err := gates.NewRegistry(
+		healthcheck.Definition(staticFactory(healthCheckGate)),
+		scheduled.Definition(staticFactory(scheduledGate)),
+		callback.Definition(staticFactory(callbackGate)),
+	)
+	test.AssertNoError(t, err)
+
+	return registry
+}
+
+func staticFactory(g gates.Gate) gates.GateFactory {
+	return func(logr.Logger, client.Client) gates.Gate {
+		return g
+	}
+}
+
+func sameGate(a, b gates.Gate) bool {
+	return a == b
+}
+
+func jsonConfig(s string) *apiextensionsv1.JSON {
+	return &apiextensionsv1.JSON{Raw: []byte(s)}
+}
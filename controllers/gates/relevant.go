@@ -18,9 +18,8 @@ package gates
 
 import (
 	"fmt"
-	"reflect"
 
-	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
 )
 
 // GateNotEnabledError is returned when a gate is not enabled
@@ -36,27 +35,15 @@ func (g GateNotEnabledError) Error() string {
 	return fmt.Sprintf("gate %s not enabled", g.Name)
 }
 
-// FindRelevantGates takes a struct with keys of the same type as
-// Gates in the map and finds relevant gates.
-func FindRelevantGates(setGate deployerv1.KustomizationGate, enabledGates map[string]Gate) ([]Gate, error) {
-	res := []Gate{}
-	v := reflect.Indirect(reflect.ValueOf(setGate))
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i)
-		fieldName := v.Type().Field(i).Name
-		if !field.CanInterface() || fieldName == "Name" {
-			continue
-		}
-
-		if !reflect.ValueOf(field.Interface()).IsNil() {
-			gen, ok := enabledGates[fieldName]
-			if !ok {
-				return nil, GateNotEnabledError{Name: fieldName}
-			}
-			res = append(res, gen)
-			continue
-		}
-	}
-
-	return res, nil
+// RelevantGate is an enabled Gate that is configured in a KustomizationGate.
+type RelevantGate struct {
+	// Name identifies the check in the status of the gate, this is the Kind
+	// for checks configured by field, e.g. HealthCheck.
+	Name string
+	// Kind is the key of the gate in the Registry, e.g. HealthCheck.
+	Kind string
+	Gate Gate
+	// Config is the KustomizationGate with only the field for this check
+	// set, with defaults applied.
+	Config *deployerv1.KustomizationGate
 }
@@ -0,0 +1,57 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduled
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+)
+
+// Key is the key of the ScheduledGate in the gate registry.
+const Key = "Scheduled"
+
+// Definition returns the definition of the ScheduledGate for the gate
+// registry, the gate is not enabled if the factory is nil.
+func Definition(factory gates.GateFactory) gates.Definition {
+	def := gates.NewDefinition(Key, "scheduled", func(g *deployerv1.KustomizationGate) **deployerv1.ScheduledCheck {
+		return &g.Scheduled
+	}, factory)
+	def.Validate = validate
+
+	return def
+}
+
+func validate(gate *deployerv1.KustomizationGate, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	open, err := time.Parse("15:04", gate.Scheduled.Open)
+	if err != nil {
+		errs = append(errs, field.Invalid(path.Child("open"), gate.Scheduled.Open, "must be a time in the format hh:mm"))
+	}
+	closed, err := time.Parse("15:04", gate.Scheduled.Close)
+	if err != nil {
+		errs = append(errs, field.Invalid(path.Child("close"), gate.Scheduled.Close, "must be a time in the format hh:mm"))
+	}
+	if len(errs) == 0 && !open.Before(closed) {
+		errs = append(errs, field.Invalid(path.Child("close"), gate.Scheduled.Close, "must be after open"))
+	}
+
+	return errs
+}
@@ -21,7 +21,7 @@ import (
 	"fmt"
 	"time"
 
-	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
 	"github.com/go-logr/logr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -56,20 +56,25 @@ type ScheduledGate struct {
 // TODO: if closed is earlier than open, we could assume an "overnight" type
 // scenario.
 
-// Check returns true if now is within the the Scheduled gate time duration.
-func (g ScheduledGate) Check(ctx context.Context, gate *deployerv1.KustomizationGate, _ *deployerv1.KustomizationAutoDeployer) (bool, error) {
+// Check is open if now is within the the Scheduled gate time duration.
+func (g ScheduledGate) Check(ctx context.Context, req gates.CheckRequest) (gates.Result, error) {
 	// TODO: Logging
+	gate := req.Gate
 	now := g.Clock()
 	open, closed, err := parseScheduledTimes(now, gate.Name, gate.Scheduled)
 	if err != nil {
-		return false, err
+		return gates.Result{}, err
 	}
 
 	if closed.Before(open) {
-		return false, fmt.Errorf("parsing Scheduled %s %v is before %v", gate.Name, gate.Scheduled.Close, gate.Scheduled.Open)
+		return gates.Result{}, fmt.Errorf("parsing Scheduled %s %v is before %v", gate.Name, gate.Scheduled.Close, gate.Scheduled.Open)
 	}
 
-	return now.After(open) && now.Before(closed), nil
+	if now.After(open) && now.Before(closed) {
+		return gates.Result{Open: true, Message: fmt.Sprintf("open until %s", gate.Scheduled.Close)}, nil
+	}
+
+	return gates.Result{Open: false, Message: fmt.Sprintf("closed until %s", gate.Scheduled.Open)}, nil
 }
 
 func parseAndMerge(now time.Time, name, phase, str string) (time.Time, error) {
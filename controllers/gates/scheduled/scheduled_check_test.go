@@ -24,7 +24,7 @@ import (
 
 	"github.com/go-logr/logr"
 
-	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
 	"github.com/gitops-tools/kustomization-auto-deployer/test"
 )
@@ -38,17 +38,17 @@ func TestScheduledGate_Check(t *testing.T) {
 	testCases := []struct {
 		open   string
 		closed string
-		want   bool
+		want   gates.Result
 	}{
 		{
 			open:   "07:00",
 			closed: "17:00",
-			want:   true,
+			want:   gates.Result{Open: true, Message: "open until 17:00"},
 		},
 		{
 			open:   "10:00",
 			closed: "17:00",
-			want:   false,
+			want:   gates.Result{Open: false, Message: "closed until 10:00"},
 		},
 	}
 
@@ -59,17 +59,19 @@ func TestScheduledGate_Check(t *testing.T) {
 				return now
 			}
 
-			got, err := gen.Check(context.TODO(), &deployerv1.KustomizationGate{
-				Name: "testing",
-				Scheduled: &deployerv1.ScheduledCheck{
-					Open:  tt.open,
-					Close: tt.closed,
+			got, err := gen.Check(context.TODO(), gates.CheckRequest{
+				Gate: &deployerv1.KustomizationGate{
+					Name: "testing",
+					Scheduled: &deployerv1.ScheduledCheck{
+						Open:  tt.open,
+						Close: tt.closed,
+					},
 				},
-			}, nil)
+			})
 
 			test.AssertNoError(t, err)
 			if got != tt.want {
-				t.Fatalf("got %v, want %v", got, tt.want)
+				t.Fatalf("got %#v, want %#v", got, tt.want)
 			}
 		})
 	}
@@ -105,13 +107,15 @@ func TestScheduledGate_Check_errors(t *testing.T) {
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
 			gen := Factory(logr.Discard(), nil)
-			_, err := gen.Check(context.TODO(), &deployerv1.KustomizationGate{
-				Name: "testing",
-				Scheduled: &deployerv1.ScheduledCheck{
-					Open:  tt.open,
-					Close: tt.closed,
+			_, err := gen.Check(context.TODO(), gates.CheckRequest{
+				Gate: &deployerv1.KustomizationGate{
+					Name: "testing",
+					Scheduled: &deployerv1.ScheduledCheck{
+						Open:  tt.open,
+						Close: tt.closed,
+					},
 				},
-			}, nil)
+			})
 
 			test.AssertErrorMatch(t, tt.wantErr, err)
 		})
@@ -0,0 +1,42 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package soaktime
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+)
+
+// Key is the key of the SoakTimeGate in the gate registry.
+const Key = "SoakTime"
+
+// Definition returns the definition of the SoakTimeGate for the gate
+// registry, the gate is not enabled if the factory is nil.
+func Definition(factory gates.GateFactory) gates.Definition {
+	def := gates.NewDefinition(Key, "soakTime", func(g *deployerv1.KustomizationGate) **deployerv1.SoakTimeCheck {
+		return &g.SoakTime
+	}, factory)
+	def.Validate = validate
+
+	return def
+}
+
+func validate(gate *deployerv1.KustomizationGate, path *field.Path) field.ErrorList {
+	return gates.ValidateDuration(&gate.SoakTime.Duration, true, path.Child("duration"))
+}
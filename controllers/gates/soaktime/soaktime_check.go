@@ -0,0 +1,120 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package soaktime
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/go-logr/logr"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+)
+
+// Factory is a function for creating per-reconciliation gates for the
+// SoakTimeGate.
+func Factory(l logr.Logger, c client.Client) gates.Gate {
+	return New(l, c)
+}
+
+// New creates and returns a new SoakTimeGate.
+func New(l logr.Logger, c client.Client, opts ...func(*SoakTimeGate)) *SoakTimeGate {
+	g := &SoakTimeGate{
+		Logger:   l,
+		Client:   c,
+		Clock:    time.Now,
+		soakedAt: map[*deployerv1.KustomizationGate]time.Time{},
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// SoakTimeGate is open when the Kustomization has applied the current commit,
+// and has been Ready for the Duration.
+//
+// The time is taken from the LastTransitionTime of the Ready condition of the
+// Kustomization.
+//
+// When the gate is closed while the commit soaks, the time that the Duration
+// elapses is recorded for the gate, and the Interval is the time until then.
+type SoakTimeGate struct {
+	Logger logr.Logger
+	Client client.Client
+	Clock  func() time.Time
+
+	mu       sync.Mutex
+	soakedAt map[*deployerv1.KustomizationGate]time.Time
+}
+
+// Check is open if the current commit has been Ready for the Duration.
+func (g *SoakTimeGate) Check(ctx context.Context, req gates.CheckRequest) (gates.Result, error) {
+	key := req.Deployer.KustomizationKey()
+	var kustomization kustomizev1.Kustomization
+	if err := g.Client.Get(ctx, key, &kustomization); err != nil {
+		return gates.Result{}, fmt.Errorf("failed to get Kustomization %s: %w", key, err)
+	}
+
+	if !strings.HasSuffix(kustomization.Status.LastAppliedRevision, ":"+req.Current.ID) {
+		return gates.Result{Open: false, Message: fmt.Sprintf("waiting for commit %s to be applied", req.Current.ID)}, nil
+	}
+
+	ready := apimeta.FindStatusCondition(kustomization.Status.Conditions, meta.ReadyCondition)
+	if ready == nil || ready.Status != metav1.ConditionTrue {
+		return gates.Result{Open: false, Message: fmt.Sprintf("waiting for commit %s to be Ready", req.Current.ID)}, nil
+	}
+
+	soakedAt := ready.LastTransitionTime.Add(req.Gate.SoakTime.Duration.Duration)
+	if !g.Clock().Before(soakedAt) {
+		return gates.Result{Open: true, Message: fmt.Sprintf("commit %s Ready since %s", req.Current.ID, ready.LastTransitionTime.UTC().Format(time.RFC3339))}, nil
+	}
+
+	g.mu.Lock()
+	g.soakedAt[req.Gate] = soakedAt
+	g.mu.Unlock()
+
+	return gates.Result{Open: false, Message: fmt.Sprintf("soaking commit %s until %s", req.Current.ID, soakedAt.UTC().Format(time.RFC3339))}, nil
+}
+
+// Interval returns the time until the current commit has soaked if the gate
+// was closed while the commit soaks.
+func (g *SoakTimeGate) Interval(gate *deployerv1.KustomizationGate) (time.Duration, error) {
+	g.mu.Lock()
+	soakedAt, ok := g.soakedAt[gate]
+	g.mu.Unlock()
+	if !ok {
+		return gates.NoRequeueInterval, nil
+	}
+
+	if d := soakedAt.Sub(g.Clock()); d > gates.NoRequeueInterval {
+		return d, nil
+	}
+
+	return gates.NoRequeueInterval, nil
+}
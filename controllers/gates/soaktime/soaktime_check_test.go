@@ -0,0 +1,143 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package soaktime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/git"
+	"github.com/gitops-tools/kustomization-auto-deployer/test"
+)
+
+var _ gates.Gate = (*SoakTimeGate)(nil)
+
+func TestSoakTimeGate(t *testing.T) {
+	// 9am on the 14th May 2023
+	now := time.Date(2023, time.May, 14, 9, 0, 0, 0, time.UTC)
+	ready := func(status metav1.ConditionStatus, d time.Duration) []metav1.Condition {
+		return []metav1.Condition{
+			{Type: meta.ReadyCondition, Status: status, Reason: "ReconciliationSucceeded", LastTransitionTime: metav1.NewTime(now.Add(-d))},
+		}
+	}
+
+	testCases := []struct {
+		name         string
+		revision     string
+		conditions   []metav1.Condition
+		want         gates.Result
+		wantInterval time.Duration
+	}{
+		{
+			name:       "commit not applied",
+			revision:   "main@sha1:" + test.CommitIDs[4],
+			conditions: ready(metav1.ConditionTrue, time.Hour),
+			want:       gates.Result{Open: false, Message: "waiting for commit " + test.CommitIDs[3] + " to be applied"},
+		},
+		{
+			name:     "no Ready condition",
+			revision: "main@sha1:" + test.CommitIDs[3],
+			want:     gates.Result{Open: false, Message: "waiting for commit " + test.CommitIDs[3] + " to be Ready"},
+		},
+		{
+			name:       "not Ready",
+			revision:   "main@sha1:" + test.CommitIDs[3],
+			conditions: ready(metav1.ConditionFalse, time.Hour),
+			want:       gates.Result{Open: false, Message: "waiting for commit " + test.CommitIDs[3] + " to be Ready"},
+		},
+		{
+			name:         "soaking",
+			revision:     "main@sha1:" + test.CommitIDs[3],
+			conditions:   ready(metav1.ConditionTrue, time.Minute*10),
+			want:         gates.Result{Open: false, Message: "soaking commit " + test.CommitIDs[3] + " until 2023-05-14T09:20:00Z"},
+			wantInterval: time.Minute * 20,
+		},
+		{
+			name:       "soaked",
+			revision:   "main@sha1:" + test.CommitIDs[3],
+			conditions: ready(metav1.ConditionTrue, time.Minute*30),
+			want:       gates.Result{Open: true, Message: "commit " + test.CommitIDs[3] + " Ready since 2023-05-14T08:30:00Z"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			kustomization := test.NewKustomization(nil)
+			kustomization.Status.LastAppliedRevision = tt.revision
+			kustomization.Status.Conditions = tt.conditions
+			gen := New(logr.Discard(), newFakeClient(t, kustomization), func(g *SoakTimeGate) {
+				g.Clock = func() time.Time {
+					return now
+				}
+			})
+			gate := &deployerv1.KustomizationGate{
+				Name:     "testing",
+				SoakTime: &deployerv1.SoakTimeCheck{Duration: metav1.Duration{Duration: time.Minute * 30}},
+			}
+
+			got, err := gen.Check(context.TODO(), gates.CheckRequest{
+				Gate:     gate,
+				Deployer: test.NewKustomizationAutoDeployer(),
+				Current:  git.Revision{ID: test.CommitIDs[3]},
+			})
+			test.AssertNoError(t, err)
+			if got != tt.want {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+
+			interval, err := gen.Interval(gate)
+			test.AssertNoError(t, err)
+			if interval != tt.wantInterval {
+				t.Fatalf("Interval() got %v, want %v", interval, tt.wantInterval)
+			}
+		})
+	}
+}
+
+func TestSoakTimeGate_missing_Kustomization(t *testing.T) {
+	gen := Factory(logr.Discard(), newFakeClient(t))
+
+	_, err := gen.Check(context.TODO(), gates.CheckRequest{
+		Gate: &deployerv1.KustomizationGate{
+			Name:     "testing",
+			SoakTime: &deployerv1.SoakTimeCheck{Duration: metav1.Duration{Duration: time.Minute * 30}},
+		},
+		Deployer: test.NewKustomizationAutoDeployer(),
+		Current:  git.Revision{ID: test.CommitIDs[3]},
+	})
+
+	test.AssertErrorMatch(t, "failed to get Kustomization default/test-kustomization", err)
+}
+
+func newFakeClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	test.AssertNoError(t, kustomizev1.AddToScheme(scheme))
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
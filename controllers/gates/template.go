@@ -0,0 +1,89 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gates
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+// TemplateSource returns the Source recorded in the status of the gates from a
+// GateTemplate.
+func TemplateSource(template *deployerv1.GateTemplate) string {
+	return "GateTemplate/" + template.Name
+}
+
+// expandTemplates replaces the gates that reference a GateTemplate with the
+// gates in the template, the templates are in the namespace of the deployer.
+//
+// Gates from a template are named <gate name>/<template gate name>, gates from a
+// policy can't reference a template.
+func expandTemplates(ctx context.Context, c client.Client, namespace string, configured []configuredGate) ([]configuredGate, error) {
+	expanded := make([]configuredGate, 0, len(configured))
+	templates := map[string]*deployerv1.GateTemplate{}
+	for _, cg := range configured {
+		ref := cg.gate.TemplateRef
+		if ref == nil {
+			expanded = append(expanded, cg)
+			continue
+		}
+		// A template in the namespace of the deployer could replace the
+		// policy gate.
+		if cg.policy {
+			return nil, fmt.Errorf("gate %s from %s cannot reference GateTemplate %s", cg.gate.Name, cg.source, ref.Name)
+		}
+
+		template, ok := templates[ref.Name]
+		if !ok {
+			if c == nil {
+				return nil, fmt.Errorf("gate %s references GateTemplate %s, and no client is configured", cg.gate.Name, ref.Name)
+			}
+			template = &deployerv1.GateTemplate{}
+			if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, template); err != nil {
+				return nil, fmt.Errorf("failed to get GateTemplate %s/%s for gate %s: %w", namespace, ref.Name, cg.gate.Name, err)
+			}
+			templates[ref.Name] = template
+		}
+
+		source := TemplateSource(template)
+		for _, gate := range template.Spec.Gates {
+			if gate.TemplateRef != nil {
+				return nil, fmt.Errorf("gate %s in %s cannot reference GateTemplate %s", gate.Name, TemplateSource(template), gate.TemplateRef.Name)
+			}
+			gate = *gate.DeepCopy()
+			gate.Name = cg.gate.Name + "/" + gate.Name
+			if gate.Timeout == nil {
+				gate.Timeout = cg.gate.Timeout.DeepCopy()
+			}
+			expanded = append(expanded, configuredGate{gate: gate, source: source})
+		}
+	}
+
+	names := map[string]bool{}
+	for _, cg := range expanded {
+		if names[cg.gate.Name] {
+			return nil, fmt.Errorf("gate %s is configured more than once", cg.gate.Name)
+		}
+		names[cg.gate.Name] = true
+	}
+
+	return expanded, nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+// indexTemplateRefs indexes deployers by the GateTemplates their gates
+// reference.
+func indexTemplateRefs(o client.Object) []string {
+	deployer, ok := o.(*deployerv1.KustomizationAutoDeployer)
+	if !ok {
+		panic(fmt.Sprintf("Expected a KustomizationAutoDeployer, got %T", o))
+	}
+
+	return templateRefs(deployer.Spec.Gates)
+}
+
+// templateRefs returns the names of the GateTemplates referenced by the gates.
+func templateRefs(kgs []deployerv1.KustomizationGate) []string {
+	refs := sets.New[string]()
+	for _, gate := range kgs {
+		if gate.TemplateRef != nil {
+			refs.Insert(gate.TemplateRef.Name)
+		}
+	}
+
+	return sets.List(refs)
+}
+
+// templateToAutoDeployers enqueues the deployers that reference a GateTemplate
+// when it changes.
+func (r *KustomizationAutoDeployerReconciler) templateToAutoDeployers(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list deployerv1.KustomizationAutoDeployerList
+	if err := r.List(ctx, &list, client.InNamespace(obj.GetNamespace()), client.MatchingFields{templateIndexKey: obj.GetName()}); err != nil {
+		return nil
+	}
+
+	result := []reconcile.Request{}
+	for _, v := range list.Items {
+		result = append(result, reconcile.Request{NamespacedName: types.NamespacedName{Name: v.GetName(), Namespace: v.GetNamespace()}})
+	}
+
+	return result
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/test"
+)
+
+func TestTemplateToAutoDeployers(t *testing.T) {
+	template := &deployerv1.GateTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "standard-gates", Namespace: test.DefaultNamespace},
+	}
+	referencing := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+		d.Name = "referencing"
+		d.Spec.Gates = []deployerv1.KustomizationGate{
+			{Name: "standard", TemplateRef: &meta.LocalObjectReference{Name: "standard-gates"}},
+		}
+	})
+	other := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+		d.Name = "other"
+	})
+	otherNamespace := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+		d.Namespace = "other"
+	})
+	templateTests := []struct {
+		name string
+		objs []client.Object
+		want []reconcile.Request
+	}{
+		{
+			name: "deployers referencing the template",
+			objs: []client.Object{referencing, other, otherNamespace},
+			want: []reconcile.Request{
+				{NamespacedName: types.NamespacedName{Name: "referencing", Namespace: test.DefaultNamespace}},
+			},
+		},
+	}
+
+	for _, tt := range templateTests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			test.AssertNoError(t, deployerv1.AddToScheme(scheme))
+			cl := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(tt.objs...).
+				WithIndex(&deployerv1.KustomizationAutoDeployer{}, templateIndexKey, indexTemplateRefs).
+				Build()
+			reconciler := &KustomizationAutoDeployerReconciler{Client: cl}
+
+			requests := reconciler.templateToAutoDeployers(context.TODO(), template)
+
+			if diff := cmp.Diff(tt.want, requests); diff != "" {
+				t.Fatalf("failed to map the template to deployers:\n%s", diff)
+			}
+		})
+	}
+}
@@ -23,15 +23,25 @@ import (
 	"strings"
 	"time"
 
+	eventv1 "github.com/fluxcd/pkg/apis/event/v1beta1"
 	"github.com/fluxcd/pkg/apis/meta"
 	"github.com/fluxcd/pkg/runtime/patch"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kuberecorder "k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -39,37 +49,90 @@ import (
 
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
-	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/callback"
 	"github.com/gitops-tools/kustomization-auto-deployer/pkg/git"
 )
 
 const (
 	kustomizationIndexKey string = ".metadata.kustomization"
+	templateIndexKey      string = ".spec.gates.templateRef"
+)
+
+var tracer = otel.Tracer("github.com/gitops-tools/kustomization-auto-deployer/controllers")
+
+// warningReasons are the Ready condition reasons that are recorded as Warning
+// Events.
+var warningReasons = sets.New(
+	deployerv1.FailedToLoadKustomizationReason,
+	deployerv1.GitRepositoryNotPopulatedReason,
+	deployerv1.RevisionsErrorReason,
+	deployerv1.TargetCommitNotFoundReason,
+	deployerv1.AccessDeniedReason,
 )
 
 // RevisionLister is a function type that queries revisions from a git URL.
-type RevisionLister func(ctx context.Context, url string, options git.ListOptions) ([]string, error)
+type RevisionLister func(ctx context.Context, url string, options git.ListOptions) ([]git.Revision, error)
 
 // KustomizationAutoDeployerReconciler reconciles a KustomizationAutoDeployer object
 type KustomizationAutoDeployerReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 
+	EventRecorder  kuberecorder.EventRecorder
 	RevisionLister RevisionLister
-	GateFactories  map[string]gates.GateFactory
+	Gates          *gates.Registry
+
+	// NoCrossNamespaceRefs prevents deployers from referencing Kustomizations
+	// in other namespaces.
+	NoCrossNamespaceRefs bool
+
+	// ConcurrencyGroupNamespace is the namespace of the Leases for
+	// ConcurrencyGroups, if this is empty the Leases are in the namespace of
+	// the deployers, and groups only apply within a namespace.
+	ConcurrencyGroupNamespace string
+
+	// ConcurrencyLeaseDuration is how long a deployer can hold the Lease for
+	// its ConcurrencyGroup before another deployer in the group can take it,
+	// if this is zero the Lease is only taken from holders that are not
+	// deploying the commit in the Lease.
+	ConcurrencyLeaseDuration time.Duration
 }
 
 //+kubebuilder:rbac:groups=flux.gitops.pro,resources=kustomizationautodeployers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=flux.gitops.pro,resources=kustomizationautodeployers/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=flux.gitops.pro,resources=kustomizationautodeployers/finalizers,verbs=update
+//+kubebuilder:rbac:groups=flux.gitops.pro,resources=deploymentpolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=flux.gitops.pro,resources=gatetemplates,verbs=get;list;watch
+//+kubebuilder:rbac:groups=flux.gitops.pro,resources=deploymentfreezes,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update
 //+kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=gitrepositories,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=kustomize.toolkit.fluxcd.io,resources=kustomizations,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 func (r *KustomizationAutoDeployerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracer.Start(ctx, "KustomizationAutoDeployer.Reconcile", trace.WithAttributes(
+		attribute.String("deployer.namespace", req.Namespace),
+		attribute.String("deployer.name", req.Name),
+	))
+	defer span.End()
+
+	result, err := r.reconcile(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return result, err
+}
+
+func (r *KustomizationAutoDeployerReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
+	span := trace.SpanFromContext(ctx)
 
 	var deployer deployerv1.KustomizationAutoDeployer
 	if err := r.Client.Get(ctx, req.NamespacedName, &deployer); err != nil {
@@ -77,41 +140,114 @@ func (r *KustomizationAutoDeployerReconciler) Reconcile(ctx context.Context, req
 	}
 
 	if !deployer.ObjectMeta.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, &deployer)
+	}
+
+	if !controllerutil.ContainsFinalizer(&deployer, deployerv1.KustomizationAutoDeployerFinalizer) {
+		patchHelper := client.MergeFrom(deployer.DeepCopy())
+		controllerutil.AddFinalizer(&deployer, deployerv1.KustomizationAutoDeployerFinalizer)
+		if err := r.Patch(ctx, &deployer, patchHelper); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to add finalizer: %w", err)
+		}
+	}
+
+	if deployer.Spec.Suspend {
+		logger.Info("reconciliation is suspended")
+		if !apimeta.IsStatusConditionTrue(deployer.Status.Conditions, deployerv1.SuspendedCondition) {
+			r.EventRecorder.Event(&deployer, corev1.EventTypeNormal, deployerv1.SuspendedReason, "reconciliation is suspended")
+		}
+		apimeta.SetStatusCondition(&deployer.Status.Conditions, metav1.Condition{
+			Type:    deployerv1.SuspendedCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  deployerv1.SuspendedReason,
+			Message: "reconciliation is suspended",
+		})
+		if err := r.releaseConcurrencyGroup(ctx, &deployer, nil); err != nil {
+			logger.Error(err, "releasing concurrency group", "concurrencyGroup", deployer.Spec.ConcurrencyGroup)
+			return ctrl.Result{}, err
+		}
+		apimeta.RemoveStatusCondition(&deployer.Status.Conditions, deployerv1.WaitingCondition)
+		deployer.Status.ObservedGeneration = deployer.Generation
+		if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
+			logger.Error(err, "failed to update deployer status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if apimeta.RemoveStatusCondition(&deployer.Status.Conditions, deployerv1.SuspendedCondition) {
+		logger.Info("reconciliation resumed")
+		r.EventRecorder.Event(&deployer, corev1.EventTypeNormal, deployerv1.ResumedReason, "reconciliation resumed")
+		if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
+			logger.Error(err, "failed to update deployer status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if deployer.GetAnnotations()[deployerv1.PipelinePausedAnnotation] == "true" {
+		logger.Info("deployment pipeline is paused")
+		if err := r.releaseConcurrencyGroup(ctx, &deployer, nil); err != nil {
+			logger.Error(err, "releasing concurrency group", "concurrencyGroup", deployer.Spec.ConcurrencyGroup)
+			return ctrl.Result{}, err
+		}
+		r.setReadiness(&deployer, metav1.ConditionFalse, deployerv1.PipelinePausedReason, "deployment pipeline is paused", nil)
+		if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
+			logger.Error(err, "failed to update deployer status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	kustomizationObjectKey := deployer.KustomizationKey()
+	if r.NoCrossNamespaceRefs && kustomizationObjectKey.Namespace != deployer.GetNamespace() {
+		logger.Info("cross-namespace references are disabled", "kustomization", kustomizationObjectKey)
+		r.setReadiness(&deployer, metav1.ConditionFalse, deployerv1.AccessDeniedReason, fmt.Sprintf("cannot reference Kustomization %s, cross-namespace references are disabled", kustomizationObjectKey), nil)
+		if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
+			logger.Error(err, "failed to update deployer status")
+			return ctrl.Result{}, err
+		}
+
 		return ctrl.Result{}, nil
 	}
 
 	var kustomization kustomizev1.Kustomization
-	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: req.Namespace, Name: deployer.Spec.KustomizationRef.Name}, &kustomization); err != nil {
+	if err := r.Client.Get(ctx, kustomizationObjectKey, &kustomization); err != nil {
 		logger.Error(err, "loading Kustomization for KustomizationAutoDeployer")
-		setDeployerReadiness(&deployer, metav1.ConditionFalse, deployerv1.FailedToLoadKustomizationReason, "referenced Kustomization could not be loaded", nil)
+		r.setReadiness(&deployer, metav1.ConditionFalse, deployerv1.FailedToLoadKustomizationReason, "referenced Kustomization could not be loaded", nil)
 		if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
 			logger.Error(err, "failed to update deployer status")
 		}
-		return ctrl.Result{}, fmt.Errorf("failed to load kustomizationRef %s: %w", deployer.Spec.KustomizationRef.Name, err)
+		return ctrl.Result{}, fmt.Errorf("failed to load kustomizationRef %s: %w", kustomizationObjectKey, err)
 	}
 
 	// TODO: What if the Kustomization hasn't applied!
 	kustomizationBranch, kustomizationCommitID := parseRevision(kustomization.Status.LastAppliedRevision)
 	logger.Info("kustomization loaded", "branch", kustomizationBranch, "commitID", kustomizationCommitID)
+	span.SetAttributes(attribute.String("kustomization.commit", kustomizationCommitID))
 
 	var gitRepository sourcev1.GitRepository
-	sourceNamespace := kustomization.Spec.SourceRef.Namespace
-	if sourceNamespace == "" {
-		sourceNamespace = req.Namespace
-	}
-
 	// TODO: Check that the SourceRef is to a GitRepository
-	sourceRefObjectKey := client.ObjectKey{Namespace: sourceNamespace, Name: kustomization.Spec.SourceRef.Name}
+	sourceRefObjectKey := sourceRefKey(&kustomization)
 	if err := r.Client.Get(ctx, sourceRefObjectKey, &gitRepository); err != nil {
 		logger.Error(err, "loading GitRepository for KustomizationAutoDeployerReconciler")
 		return ctrl.Result{}, fmt.Errorf("failed to load sourceRef %s: %w", sourceRefObjectKey, err)
 	}
 
+	if deployer.Spec.Strategy.PinnedCommit != "" {
+		if err := r.releaseConcurrencyGroup(ctx, &deployer, nil); err != nil {
+			logger.Error(err, "releasing concurrency group", "concurrencyGroup", deployer.Spec.ConcurrencyGroup)
+			return ctrl.Result{}, err
+		}
+		return r.pinCommit(ctx, req, &deployer, &gitRepository, kustomizationCommitID)
+	}
+
 	// TODO: if the GitRepository is using a branch and not a ref, this is an error
 	// TODO: What if the Artifact is not available - this will panic!
 	if gitRepository.Status.Artifact == nil {
 		logger.Info("git repository status not yet populated")
-		setDeployerReadiness(&deployer, metav1.ConditionFalse, deployerv1.GitRepositoryNotPopulatedReason, fmt.Sprintf("GitRepository %s does not have an artifact", sourceRefObjectKey), nil)
+		r.setReadiness(&deployer, metav1.ConditionFalse, deployerv1.GitRepositoryNotPopulatedReason, fmt.Sprintf("GitRepository %s does not have an artifact", sourceRefObjectKey), nil)
 		if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
 			logger.Error(err, "failed to update deployer status")
 		}
@@ -120,6 +256,7 @@ func (r *KustomizationAutoDeployerReconciler) Reconcile(ctx context.Context, req
 
 	repoBranch, repoCommitID := parseRevision(gitRepository.Status.Artifact.Revision)
 	logger.Info("gitRepository loaded", "branch", repoBranch, "commitID", repoCommitID, "desiredCommitID", gitRepository.Spec.Reference.Commit)
+	span.SetAttributes(attribute.String("gitrepository.commit", repoCommitID))
 
 	if repoBranch != kustomizationBranch {
 		logger.Info("kustomization branch does not match repo branch - no further processing", "gitRepositoryBranch", repoBranch, "kustomizationBranch", kustomizationBranch)
@@ -131,24 +268,62 @@ func (r *KustomizationAutoDeployerReconciler) Reconcile(ctx context.Context, req
 	// TODO: is this right?
 	if kustomizationCommitID != repoCommitID {
 		logger.Info("kustomization commit does not match git repository commit no further processing", "gitRepositoryCommitID", repoCommitID, "kustomizationCommitID", kustomizationCommitID)
+		span.AddEvent("waiting for the Kustomization to apply the commit")
+		if deployer.Status.LastAdvancedTime != nil && deployer.Status.LatestCommit == gitRepository.Status.Artifact.Revision &&
+			kustomization.Status.LastAttemptedRevision == gitRepository.Status.Artifact.Revision &&
+			apimeta.IsStatusConditionFalse(kustomization.Status.Conditions, meta.ReadyCondition) {
+			logger.Info("kustomization failed to apply commit", "commitID", repoCommitID)
+			failed := deployerv1.DeploymentRecord{Commit: repoCommitID, DeployedTime: deployedTime(&kustomization, deployer.Status.LastAdvancedTime, time.Now()), Failed: true}
+			if recordDeployment(&deployer, failed, time.Now()) {
+				if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
+					logger.Error(err, "failed to update deployer status")
+					return ctrl.Result{}, err
+				}
+			}
+		}
 		return ctrl.Result{}, nil
 	}
 
-	revisions, err := r.RevisionLister(ctx, gitRepository.Spec.URL, git.ListOptions{MaxCommits: deployer.Spec.CommitLimit})
+	// The commit in flight for the ConcurrencyGroup has been applied.
+	if err := r.releaseConcurrencyGroup(ctx, &deployer, &kustomization); err != nil {
+		logger.Error(err, "releasing concurrency group", "concurrencyGroup", deployer.Spec.ConcurrencyGroup)
+		return ctrl.Result{}, err
+	}
+
+	listed, err := r.listRevisions(ctx, gitRepository.Spec.URL, git.ListOptions{MaxCommits: deployer.Spec.CommitLimit})
 	if err != nil {
 		logger.Error(err, "listing revisions", "url", gitRepository.Spec.URL)
-		setDeployerReadiness(&deployer, metav1.ConditionFalse, deployerv1.RevisionsErrorReason, err.Error(), nil)
+		r.setReadiness(&deployer, metav1.ConditionFalse, deployerv1.RevisionsErrorReason, err.Error(), nil)
 		if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
 			logger.Error(err, "failed to update deployer status")
 		}
 		return ctrl.Result{}, fmt.Errorf("failed to list revisions in repo %s: %w", gitRepository.Spec.URL, err)
 	}
+	revisions := revisionIDs(listed)
+
+	// The Kustomization has applied the commit that the GitRepository was
+	// last advanced to.
+	if deployer.Status.LastAdvancedTime != nil && deployer.Status.LatestCommit == commitReference(repoBranch, repoCommitID) {
+		commitDeployDuration.WithLabelValues(deployer.GetNamespace(), deployer.GetName()).Observe(time.Since(deployer.Status.LastAdvancedTime.Time).Seconds())
+		deployment := deployerv1.DeploymentRecord{Commit: repoCommitID, DeployedTime: deployedTime(&kustomization, deployer.Status.LastAdvancedTime, time.Now())}
+		if i := stringIndex(repoCommitID, revisions); i >= 0 {
+			deployment.CommitTime = &metav1.Time{Time: listed[i].Time}
+		}
+		recordDeployment(&deployer, deployment, time.Now())
+		deployer.Status.LastAdvancedTime = nil
+	}
 
-	// TODO: We could indicate how many commits behind we are.
 	currentCommitIndex := stringIndex(kustomizationCommitID, revisions)
+	if currentCommitIndex >= 0 {
+		commitsBehind.WithLabelValues(deployer.GetNamespace(), deployer.GetName()).Set(float64(currentCommitIndex))
+	} else {
+		// The applied commit is older than the listed commits.
+		commitsBehind.DeleteLabelValues(deployer.GetNamespace(), deployer.GetName())
+	}
 	if currentCommitIndex < 1 {
 		logger.Info("no changes to deploy")
 		// TODO: Refactor this to avoid duplication!
+		r.setReadiness(&deployer, metav1.ConditionTrue, deployerv1.UpToDateReason, fmt.Sprintf("commit %s is deployed", repoCommitID), nil)
 		deployer.Status.LatestCommit = commitReference(repoBranch, repoCommitID)
 		deployer.Status.ObservedGeneration = deployer.Generation
 		if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
@@ -163,14 +338,28 @@ func (r *KustomizationAutoDeployerReconciler) Reconcile(ctx context.Context, req
 	}
 
 	nextCommitToDeploy := revisions[currentCommitIndex-1]
+	span.SetAttributes(attribute.Int("deployer.commits_behind", currentCommitIndex), attribute.String("deployer.next_commit", nextCommitToDeploy))
 	if repoCommitID == nextCommitToDeploy {
 		logger.Info("already deployed, nothing to do")
+		// The Event is only recorded when the Ready condition changes.
+		r.setReadiness(&deployer, metav1.ConditionTrue, deployerv1.UpToDateReason, fmt.Sprintf("commit %s is deployed", repoCommitID), nil)
+		if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
+			logger.Error(err, "failed to reconcile")
+			return ctrl.Result{}, err
+		}
 
 		return ctrl.Result{}, nil
 	}
 
 	if gitRepository.Spec.Reference.Commit == nextCommitToDeploy {
 		logger.Info("already requested deploy, nothing to do")
+		// The CommitAdvanced Event already reports the commit requested by
+		// the deployer, otherwise the Event is only recorded when the Ready
+		// condition changes.
+		current := apimeta.FindStatusCondition(deployer.Status.Conditions, meta.ReadyCondition)
+		if current == nil || current.Reason != deployerv1.CommitAdvancedReason || current.Message != fmt.Sprintf("advanced to commit %s", nextCommitToDeploy) {
+			r.setReadiness(&deployer, metav1.ConditionTrue, deployerv1.CommitRequestedReason, fmt.Sprintf("waiting for GitRepository %s to fetch commit %s", sourceRefObjectKey, nextCommitToDeploy), nil)
+		}
 		// TODO: Refactor this to avoid duplication!
 		deployer.Status.LatestCommit = commitReference(repoBranch, nextCommitToDeploy)
 		deployer.Status.ObservedGeneration = deployer.Generation
@@ -182,59 +371,381 @@ func (r *KustomizationAutoDeployerReconciler) Reconcile(ctx context.Context, req
 		return ctrl.Result{}, nil
 	}
 
+	if targetCommit := deployer.Spec.Strategy.TargetCommit; targetCommit != "" {
+		targetIndex := stringIndex(targetCommit, revisions)
+		if targetIndex < 0 {
+			logger.Info("target commit not found", "targetCommitID", targetCommit)
+			r.setReadiness(&deployer, metav1.ConditionFalse, deployerv1.TargetCommitNotFoundReason, fmt.Sprintf("target commit %s not found in the latest %d commits", targetCommit, len(revisions)), nil)
+			if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
+				logger.Error(err, "failed to reconcile")
+				return ctrl.Result{}, err
+			}
+
+			return ctrl.Result{RequeueAfter: deployer.Spec.Interval.Duration}, nil
+		}
+
+		if targetIndex >= currentCommitIndex {
+			logger.Info("target commit reached", "targetCommitID", targetCommit)
+			r.setReadiness(&deployer, metav1.ConditionTrue, deployerv1.TargetCommitReachedReason, fmt.Sprintf("target commit %s reached", targetCommit), nil)
+			deployer.Status.LatestCommit = commitReference(repoBranch, repoCommitID)
+			if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
+				logger.Error(err, "failed to reconcile")
+				return ctrl.Result{}, err
+			}
+
+			return ctrl.Result{}, nil
+		}
+	}
+
+	if promotedCommit, ok := deployer.GetAnnotations()[deployerv1.PromotedCommitAnnotation]; ok {
+		// The deployer is in a DeploymentPipeline stage, and can only advance
+		// as far as the commit promoted from the previous stage.
+		promotedIndex := stringIndex(promotedCommit, revisions)
+		if promotedCommit == "" || promotedIndex < 0 || promotedIndex >= currentCommitIndex {
+			logger.Info("waiting for promotion", "promotedCommitID", promotedCommit, "nextCommitID", nextCommitToDeploy)
+			r.setReadiness(&deployer, metav1.ConditionFalse, deployerv1.WaitingForPromotionReason, fmt.Sprintf("waiting for commit %s to be promoted", nextCommitToDeploy), nil)
+			if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
+				logger.Error(err, "failed to reconcile")
+				return ctrl.Result{}, err
+			}
+
+			return ctrl.Result{}, nil
+		}
+	}
+
 	patchHelper, err := patch.NewHelper(&gitRepository, r.Client)
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to create patch helper for GitRepository: %w", err)
 	}
 
-	instantiatedGates := map[string]gates.Gate{}
-	for k, factory := range r.GateFactories {
-		instantiatedGates[k] = factory(logger, r.Client)
+	policies, err := r.policiesFor(ctx, &deployer)
+	if err != nil {
+		logger.Error(err, "failed to select DeploymentPolicies")
+		return ctrl.Result{}, err
 	}
 
-	open, gatesStatus, err := gates.Check(ctx, &deployer, instantiatedGates)
+	now := time.Now()
+	freezes, err := r.activeFreezes(ctx, &deployer, now)
 	if err != nil {
-		logger.Error(err, "error checking gates")
+		logger.Error(err, "failed to select DeploymentFreezes")
 		return ctrl.Result{}, err
 	}
+	bg, err := breakGlassFor(&deployer, now)
+	if err != nil {
+		// An invalid annotation doesn't bypass the freezes.
+		logger.Error(err, "ignoring the break glass annotation")
+	}
+	freezeStatus := freezeGates(&deployer, freezes, bg, nextCommitToDeploy, now)
+
+	// The gates aren't checked while a freeze is closed, so that checks with
+	// side-effects e.g. callbacks aren't made for a commit that can't be
+	// deployed.
+	open, gatesStatus := false, freezeStatus
+	if len(gates.ClosedGates(freezeStatus)) == 0 {
+		open, gatesStatus, err = gates.Check(ctx, &deployer, listed[currentCommitIndex-1], listed[currentCommitIndex], r.Gates.Instantiate(logger, r.Client), policies...)
+		if err != nil {
+			logger.Error(err, "error checking gates")
+			return ctrl.Result{}, err
+		}
+		gatesStatus = append(gatesStatus, freezeStatus...)
+	}
+
+	recordGateMetrics(&deployer, gatesStatus)
+	checked := newGatesStatus(open, nextCommitToDeploy, gatesStatus)
 
 	if !open {
 		logger.Info("gates are currently closed")
-		// TODO: identify the closed gates from the response.
-		setDeployerReadiness(&deployer, metav1.ConditionFalse, deployerv1.GatesClosedReason, "gates are currently closed", gatesStatus)
+		// Only record an Event when the gates have changed state.
+		current := apimeta.FindStatusCondition(deployer.Status.Conditions, meta.ReadyCondition)
+		if current == nil || current.Reason != deployerv1.GatesClosedReason || summariseGates(deployer.Status.GateResults()) != summariseGates(gatesStatus) {
+			r.revisionEventf(&deployer, commitReference(repoBranch, nextCommitToDeploy), corev1.EventTypeNormal, deployerv1.GatesClosedReason, "gates closed for commit %s: %s", nextCommitToDeploy, summariseGates(gatesStatus))
+		}
+		setDeployerReadiness(&deployer, metav1.ConditionFalse, deployerv1.GatesClosedReason, fmt.Sprintf("gates are currently closed: %s", strings.Join(gates.ClosedGates(gatesStatus), ", ")), checked)
 		// TODO: Refactor this to avoid duplication!
 		if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
 			logger.Error(err, "failed to reconcile")
 			return ctrl.Result{}, err
 		}
 
-		requeueAfter, err := calculateInterval(&deployer, instantiatedGates)
+		return ctrl.Result{RequeueAfter: calculateInterval(gatesStatus, time.Now())}, nil
+	}
+
+	if deployer.Spec.ConcurrencyGroup != "" {
+		holder, holderCommit, err := r.acquireConcurrencyGroup(ctx, &deployer, nextCommitToDeploy)
 		if err != nil {
-			return ctrl.Result{}, fmt.Errorf("failed to calculate requeue interval: %w", err)
+			logger.Error(err, "acquiring concurrency group", "concurrencyGroup", deployer.Spec.ConcurrencyGroup)
+			return ctrl.Result{}, err
 		}
+		if holder != "" {
+			logger.Info("waiting for concurrency group", "concurrencyGroup", deployer.Spec.ConcurrencyGroup, "holder", holder, "holderCommitID", holderCommit)
+			message := fmt.Sprintf("waiting for %s to deploy commit %s in concurrency group %s", holder, holderCommit, deployer.Spec.ConcurrencyGroup)
+			r.setReadiness(&deployer, metav1.ConditionFalse, deployerv1.WaitingForConcurrencyGroupReason, message, checked)
+			apimeta.SetStatusCondition(&deployer.Status.Conditions, metav1.Condition{
+				Type:    deployerv1.WaitingCondition,
+				Status:  metav1.ConditionTrue,
+				Reason:  deployerv1.WaitingForConcurrencyGroupReason,
+				Message: message,
+			})
+			if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
+				logger.Error(err, "failed to reconcile")
+				return ctrl.Result{}, err
+			}
 
-		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+			return ctrl.Result{RequeueAfter: deployer.Spec.Interval.Duration}, nil
+		}
 	}
 
 	logger.Info("identified next commit - patching GitRepository", "nextCommitID", nextCommitToDeploy, "repositoryName", gitRepository.GetName(), "repositoryNamespace", gitRepository.GetNamespace())
 
 	gitRepository.Spec.Reference.Commit = nextCommitToDeploy
 	if err := patchHelper.Patch(ctx, &gitRepository); err != nil {
+		// The commit in the Lease won't be applied.
+		if err := r.releaseConcurrencyGroup(ctx, &deployer, nil); err != nil {
+			logger.Error(err, "releasing concurrency group", "concurrencyGroup", deployer.Spec.ConcurrencyGroup)
+		}
 		return ctrl.Result{}, fmt.Errorf("failed to update GitRepository: %w", err)
 	}
 
-	// TODO: Setup condition
+	advancedMessage := fmt.Sprintf("advanced from commit %s to commit %s", repoCommitID, nextCommitToDeploy)
+	if len(gatesStatus) > 0 {
+		advancedMessage += ": " + summariseGates(gatesStatus)
+	}
+	r.revisionEventf(&deployer, commitReference(repoBranch, nextCommitToDeploy), corev1.EventTypeNormal, deployerv1.CommitAdvancedReason, "%s", advancedMessage)
+	advancesTotal.WithLabelValues(deployer.GetNamespace(), deployer.GetName()).Inc()
+	deployer.Status.LastAdvancedTime = &metav1.Time{Time: time.Now()}
+	recordAdvancedTime(&deployer.Status, deployer.Status.LastAdvancedTime.Time)
+	if bg != nil && len(freezes) > 0 {
+		deployer.Status.BreakGlass = &deployerv1.BreakGlassStatus{
+			User:        bg.user,
+			ExpiresTime: metav1.NewTime(bg.expires),
+			UsedTime:    *deployer.Status.LastAdvancedTime,
+			Commit:      nextCommitToDeploy,
+			Freezes:     freezeNames(freezes),
+		}
+		r.revisionEventf(&deployer, commitReference(repoBranch, nextCommitToDeploy), corev1.EventTypeWarning, deployerv1.BreakGlassUsedReason, "%s", breakGlassEventMessage(deployer.Status.BreakGlass))
+	}
+
 	// TODO: Refactor this to avoid duplication!
+	setDeployerReadiness(&deployer, metav1.ConditionTrue, deployerv1.CommitAdvancedReason, fmt.Sprintf("advanced to commit %s", nextCommitToDeploy), nil)
 	deployer.Status.LatestCommit = commitReference(repoBranch, nextCommitToDeploy)
 	deployer.Status.ObservedGeneration = deployer.Generation
-	deployer.Status.Gates = gatesStatus
+	deployer.Status.Gates = checked
+	if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
+		logger.Error(err, "failed to reconcile")
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileDelete applies the CleanupPolicy to the GitRepository and removes
+// the finalizer from the deployer.
+func (r *KustomizationAutoDeployerReconciler) reconcileDelete(ctx context.Context, deployer *deployerv1.KustomizationAutoDeployer) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(deployer, deployerv1.KustomizationAutoDeployerFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	deleteDeployerMetrics(deployer)
+
+	if err := r.cleanupGitRepository(ctx, deployer); err != nil {
+		logger.Error(err, "applying cleanup policy", "cleanupPolicy", deployer.Spec.CleanupPolicy)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.releaseConcurrencyGroup(ctx, deployer, nil); err != nil {
+		logger.Error(err, "releasing concurrency group", "concurrencyGroup", deployer.Spec.ConcurrencyGroup)
+		return ctrl.Result{}, err
+	}
+
+	patchHelper := client.MergeFrom(deployer.DeepCopy())
+	controllerutil.RemoveFinalizer(deployer, deployerv1.KustomizationAutoDeployerFinalizer)
+	if err := r.Patch(ctx, deployer, patchHelper); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to remove finalizer: %w", err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KustomizationAutoDeployerReconciler) cleanupGitRepository(ctx context.Context, deployer *deployerv1.KustomizationAutoDeployer) error {
+	logger := log.FromContext(ctx)
+
+	policy := deployer.Spec.CleanupPolicy
+	if policy == "" || policy == deployerv1.RetainCleanupPolicy {
+		return nil
+	}
+
+	var kustomization kustomizev1.Kustomization
+	kustomizationObjectKey := deployer.KustomizationKey()
+	if r.NoCrossNamespaceRefs && kustomizationObjectKey.Namespace != deployer.GetNamespace() {
+		logger.Info("cross-namespace references are disabled, skipping cleanup policy", "cleanupPolicy", policy)
+		return nil
+	}
+	if err := r.Client.Get(ctx, kustomizationObjectKey, &kustomization); err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.Info("kustomization not found, skipping cleanup policy", "cleanupPolicy", policy)
+			return nil
+		}
+		return fmt.Errorf("failed to load kustomizationRef %s: %w", kustomizationObjectKey, err)
+	}
+
+	var gitRepository sourcev1.GitRepository
+	sourceRefObjectKey := sourceRefKey(&kustomization)
+	if err := r.Client.Get(ctx, sourceRefObjectKey, &gitRepository); err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.Info("git repository not found, skipping cleanup policy", "cleanupPolicy", policy)
+			return nil
+		}
+		return fmt.Errorf("failed to load sourceRef %s: %w", sourceRefObjectKey, err)
+	}
+
+	if gitRepository.Spec.Reference == nil {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(&gitRepository, r.Client)
+	if err != nil {
+		return fmt.Errorf("failed to create patch helper for GitRepository: %w", err)
+	}
+
+	switch policy {
+	case deployerv1.UnpinCleanupPolicy:
+		gitRepository.Spec.Reference.Commit = ""
+	case deployerv1.LastVerifiedCleanupPolicy:
+		_, commitID := parseRevision(kustomization.Status.LastAppliedRevision)
+		if commitID == "" {
+			logger.Info("kustomization has not applied a commit, retaining the current commit", "cleanupPolicy", policy)
+			return nil
+		}
+		gitRepository.Spec.Reference.Commit = commitID
+	}
+
+	logger.Info("applying cleanup policy", "cleanupPolicy", policy, "commitID", gitRepository.Spec.Reference.Commit)
+	if err := patchHelper.Patch(ctx, &gitRepository); err != nil {
+		return fmt.Errorf("failed to update GitRepository: %w", err)
+	}
+	r.EventRecorder.Eventf(deployer, corev1.EventTypeNormal, deployerv1.CleanupPolicyAppliedReason, "applied %s cleanup policy to GitRepository %s, commit %q", policy, sourceRefObjectKey, gitRepository.Spec.Reference.Commit)
+
+	return nil
+}
+
+// pinCommit forces the GitRepository to the deployer's pinned commit.
+//
+// The pinned commit must be at or before the applied commit in the listed
+// revisions, pinning can't be used to deploy a commit that has not passed the
+// gates.
+func (r *KustomizationAutoDeployerReconciler) pinCommit(ctx context.Context, req ctrl.Request, deployer *deployerv1.KustomizationAutoDeployer, gitRepository *sourcev1.GitRepository, appliedCommitID string) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	pinnedCommit := deployer.Spec.Strategy.PinnedCommit
+
+	if gitRepository.Spec.Reference == nil || gitRepository.Spec.Reference.Commit != pinnedCommit {
+		listed, err := r.listRevisions(ctx, gitRepository.Spec.URL, git.ListOptions{MaxCommits: deployer.Spec.CommitLimit})
+		if err != nil {
+			logger.Error(err, "listing revisions", "url", gitRepository.Spec.URL)
+			r.setReadiness(deployer, metav1.ConditionFalse, deployerv1.RevisionsErrorReason, err.Error(), nil)
+			if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
+				logger.Error(err, "failed to update deployer status")
+			}
+			return ctrl.Result{}, fmt.Errorf("failed to list revisions in repo %s: %w", gitRepository.Spec.URL, err)
+		}
+		revisions := revisionIDs(listed)
+		pinnedIndex, appliedIndex := stringIndex(pinnedCommit, revisions), stringIndex(appliedCommitID, revisions)
+		if pinnedIndex < 0 || appliedIndex < 0 || pinnedIndex < appliedIndex {
+			logger.Info("pinned commit rejected", "pinnedCommitID", pinnedCommit, "appliedCommitID", appliedCommitID)
+			r.setReadiness(deployer, metav1.ConditionFalse, deployerv1.PinnedCommitRejectedReason, fmt.Sprintf("pinned commit %s is not at or before the applied commit %s in the latest %d commits", pinnedCommit, appliedCommitID, len(revisions)), nil)
+			if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
+				logger.Error(err, "failed to reconcile")
+				return ctrl.Result{}, err
+			}
+
+			return ctrl.Result{RequeueAfter: deployer.Spec.Interval.Duration}, nil
+		}
+
+		previousCommit := ""
+		if gitRepository.Spec.Reference != nil {
+			previousCommit = gitRepository.Spec.Reference.Commit
+		}
+		logger.Info("pinning GitRepository to commit", "pinnedCommitID", pinnedCommit, "repositoryName", gitRepository.GetName(), "repositoryNamespace", gitRepository.GetNamespace())
+		patchHelper, err := patch.NewHelper(gitRepository, r.Client)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to create patch helper for GitRepository: %w", err)
+		}
+
+		if gitRepository.Spec.Reference == nil {
+			gitRepository.Spec.Reference = &sourcev1.GitRepositoryRef{}
+		}
+		gitRepository.Spec.Reference.Commit = pinnedCommit
+		if err := patchHelper.Patch(ctx, gitRepository); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update GitRepository: %w", err)
+		}
+		r.revisionEventf(deployer, commitReference(gitRepository.Spec.Reference.Branch, pinnedCommit), corev1.EventTypeNormal, deployerv1.CommitPinnedReason, "pinned to commit %s from commit %s", pinnedCommit, previousCommit)
+		rollbacksTotal.WithLabelValues(deployer.GetNamespace(), deployer.GetName()).Inc()
+	}
+
+	// Rolling back to the pinned commit restores any failed deployments.
+	if appliedCommitID == pinnedCommit && hasUnrestoredFailure(deployer.Status.DORA) {
+		recordDeployment(deployer, deployerv1.DeploymentRecord{Commit: pinnedCommit, DeployedTime: metav1.Now()}, time.Now())
+	}
+
+	setDeployerReadiness(deployer, metav1.ConditionTrue, deployerv1.CommitPinnedReason, fmt.Sprintf("pinned to commit %s", pinnedCommit), nil)
+	deployer.Status.LatestCommit = commitReference(gitRepository.Spec.Reference.Branch, pinnedCommit)
 	if err := r.patchStatus(ctx, req, deployer.Status); err != nil {
 		logger.Error(err, "failed to reconcile")
+		return ctrl.Result{}, err
 	}
 
 	return ctrl.Result{}, nil
 }
 
+// listRevisions lists the revisions in the repository, recording the duration
+// and failures.
+func (r *KustomizationAutoDeployerReconciler) listRevisions(ctx context.Context, url string, options git.ListOptions) ([]git.Revision, error) {
+	ctx, span := tracer.Start(ctx, "RevisionLister", trace.WithAttributes(
+		attribute.String("git.url", url),
+		attribute.Int("git.max_commits", options.MaxCommits),
+	))
+	defer span.End()
+
+	start := time.Now()
+	revisions, err := r.RevisionLister(ctx, url, options)
+	gitListDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		gitListFailures.Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("git.revisions", len(revisions)))
+
+	return revisions, nil
+}
+
+// revisionEventf records an Event with the revision in the metadata, this is
+// included in the notifications sent by the Flux notification-controller.
+func (r *KustomizationAutoDeployerReconciler) revisionEventf(deployer *deployerv1.KustomizationAutoDeployer, revision, eventType, reason, messageFmt string, args ...any) {
+	annotations := map[string]string{
+		deployerv1.GroupVersion.Group + "/" + eventv1.MetaRevisionKey: revision,
+	}
+	r.EventRecorder.AnnotatedEventf(deployer, annotations, eventType, reason, messageFmt, args...)
+}
+
+// setReadiness sets the Ready condition on the deployer and records an Event
+// if the condition has changed.
+func (r *KustomizationAutoDeployerReconciler) setReadiness(deployer *deployerv1.KustomizationAutoDeployer, status metav1.ConditionStatus, reason, message string, gates *deployerv1.GatesStatus) {
+	current := apimeta.FindStatusCondition(deployer.Status.Conditions, meta.ReadyCondition)
+	changed := current == nil || current.Status != status || current.Reason != reason || current.Message != message
+	setDeployerReadiness(deployer, status, reason, message, gates)
+	if !changed {
+		return
+	}
+
+	eventType := corev1.EventTypeNormal
+	if warningReasons.Has(reason) {
+		eventType = corev1.EventTypeWarning
+	}
+	r.EventRecorder.Event(deployer, eventType, reason, message)
+}
+
 func (r *KustomizationAutoDeployerReconciler) patchStatus(ctx context.Context, req ctrl.Request, newStatus deployerv1.KustomizationAutoDeployerStatus) error {
 	var deployer deployerv1.KustomizationAutoDeployer
 	if err := r.Get(ctx, req.NamespacedName, &deployer); err != nil {
@@ -242,11 +753,29 @@ func (r *KustomizationAutoDeployerReconciler) patchStatus(ctx context.Context, r
 	}
 
 	patch := client.MergeFrom(deployer.DeepCopy())
+	newStatus.Callbacks = preserveCompletedCallbacks(deployer.Status.Callbacks, newStatus.Callbacks)
 	deployer.Status = newStatus
 
 	return r.Status().Patch(ctx, &deployer, patch)
 }
 
+// preserveCompletedCallbacks keeps callback results recorded by the callback
+// Handler while the deployer was being reconciled.
+func preserveCompletedCallbacks(current, updated []deployerv1.CallbackStatus) []deployerv1.CallbackStatus {
+	result := make([]deployerv1.CallbackStatus, len(updated))
+	for i := range updated {
+		result[i] = updated[i]
+		if updated[i].Result != deployerv1.CallbackPending {
+			continue
+		}
+		if completed := callback.FindCallback(current, updated[i].Gate); completed != nil && completed.ID == updated[i].ID && completed.Result != deployerv1.CallbackPending {
+			result[i] = *completed
+		}
+	}
+
+	return result
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *KustomizationAutoDeployerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	// Index the KustomizationAutoDeployer by the Kustomization references they point at.
@@ -257,17 +786,39 @@ func (r *KustomizationAutoDeployerReconciler) SetupWithManager(mgr ctrl.Manager)
 				panic(fmt.Sprintf("Expected a KustomizationAutoDeployer, got %T", o))
 			}
 
-			return []string{fmt.Sprintf("%s/%s", gt.GetNamespace(), gt.Spec.KustomizationRef.Name)}
+			return []string{gt.KustomizationKey().String()}
 		}); err != nil {
 		return fmt.Errorf("failed setting index fields for Kustomizations: %w", err)
 	}
 
+	// Index the KustomizationAutoDeployer by the GateTemplates their gates reference.
+	if err := mgr.GetCache().IndexField(
+		context.TODO(), &deployerv1.KustomizationAutoDeployer{}, templateIndexKey, indexTemplateRefs); err != nil {
+		return fmt.Errorf("failed setting index fields for GateTemplates: %w", err)
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&deployerv1.KustomizationAutoDeployer{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		For(&deployerv1.KustomizationAutoDeployer{}, builder.WithPredicates(
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
 		Watches(
 			&kustomizev1.Kustomization{},
 			handler.EnqueueRequestsFromMapFunc(r.kustomizationToAutoDeployer),
 		).
+		Watches(
+			&deployerv1.DeploymentPolicy{},
+			handler.EnqueueRequestsFromMapFunc(r.allAutoDeployers),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(
+			&deployerv1.DeploymentFreeze{},
+			handler.EnqueueRequestsFromMapFunc(r.allAutoDeployers),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(
+			&deployerv1.GateTemplate{},
+			handler.EnqueueRequestsFromMapFunc(r.templateToAutoDeployers),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
 		Complete(r)
 }
 
@@ -306,6 +857,17 @@ func parseRevision(s string) (string, string) {
 	return "", revision
 }
 
+// sourceRefKey returns the key for the Kustomization's source, defaulting to
+// the namespace of the Kustomization.
+func sourceRefKey(kustomization *kustomizev1.Kustomization) client.ObjectKey {
+	sourceNamespace := kustomization.Spec.SourceRef.Namespace
+	if sourceNamespace == "" {
+		sourceNamespace = kustomization.GetNamespace()
+	}
+
+	return client.ObjectKey{Namespace: sourceNamespace, Name: kustomization.Spec.SourceRef.Name}
+}
+
 func stringIndex(s string, ss []string) int {
 	for i := range ss {
 		if ss[i] == s {
@@ -316,11 +878,20 @@ func stringIndex(s string, ss []string) int {
 	return -1
 }
 
+func revisionIDs(revisions []git.Revision) []string {
+	ids := []string{}
+	for _, revision := range revisions {
+		ids = append(ids, revision.ID)
+	}
+
+	return ids
+}
+
 func commitReference(branch, commitID string) string {
 	return branch + "@sha1:" + commitID
 }
 
-func setDeployerReadiness(deployer *deployerv1.KustomizationAutoDeployer, status metav1.ConditionStatus, reason, message string, gates deployerv1.GatesStatus) {
+func setDeployerReadiness(deployer *deployerv1.KustomizationAutoDeployer, status metav1.ConditionStatus, reason, message string, gates *deployerv1.GatesStatus) {
 	deployer.Status.ObservedGeneration = deployer.ObjectMeta.Generation
 	newCondition := metav1.Condition{
 		Type:    meta.ReadyCondition,
@@ -332,34 +903,66 @@ func setDeployerReadiness(deployer *deployerv1.KustomizationAutoDeployer, status
 		deployer.Status.Gates = gates
 	}
 	apimeta.SetStatusCondition(&deployer.Status.Conditions, newCondition)
+	// The deployer is only Waiting while it is not Ready because of its
+	// ConcurrencyGroup.
+	if reason != deployerv1.WaitingForConcurrencyGroupReason {
+		apimeta.RemoveStatusCondition(&deployer.Status.Conditions, deployerv1.WaitingCondition)
+	}
 }
 
-func calculateInterval(gs *deployerv1.KustomizationAutoDeployer, g map[string]gates.Gate) (time.Duration, error) {
-	res := []time.Duration{}
-	for _, mg := range gs.Spec.Gates {
-		relevantGates, err := gates.FindRelevantGates(mg, g)
-		if err != nil {
-			return gates.NoRequeueInterval, err
+// newGatesStatus returns the status of the gates checked for the candidate
+// commit, this is nil if no gates are configured.
+func newGatesStatus(open bool, candidate string, results []deployerv1.GateStatus) *deployerv1.GatesStatus {
+	if results == nil {
+		return nil
+	}
+
+	return &deployerv1.GatesStatus{
+		Open:          open,
+		Commit:        candidate,
+		LastCheckTime: &metav1.Time{Time: time.Now()},
+		Results:       results,
+	}
+}
+
+// summariseGates formats the state of the gates for use in Events.
+func summariseGates(gatesStatus []deployerv1.GateStatus) string {
+	summaries := []string{}
+	for _, gate := range gatesStatus {
+		checks := []string{}
+		for _, check := range gate.Checks {
+			state := "closed"
+			if check.Open {
+				state = "open"
+			}
+			checks = append(checks, check.Name+"="+state)
 		}
+		summaries = append(summaries, fmt.Sprintf("%s (%s)", gate.Name, strings.Join(checks, ", ")))
+	}
 
-		for _, rg := range relevantGates {
-			d, err := rg.Interval(&mg)
-			if err != nil {
-				return gates.NoRequeueInterval, err
+	return strings.Join(summaries, ", ")
+}
+
+func calculateInterval(gatesStatus []deployerv1.GateStatus, now time.Time) time.Duration {
+	res := []time.Duration{}
+	for _, gate := range gatesStatus {
+		for _, check := range gate.Checks {
+			if check.NextCheckTime == nil {
+				continue
 			}
 
-			if d > gates.NoRequeueInterval {
+			if d := check.NextCheckTime.Sub(now); d > gates.NoRequeueInterval {
 				res = append(res, d)
 			}
 		}
 	}
 
 	if len(res) == 0 {
-		return gates.NoRequeueInterval, nil
+		return gates.NoRequeueInterval
 	}
 
-	// Find the lowest requeue interval provided by a gate
+	// Find the earliest time that a check will be made again.
 	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
 
-	return res[0], nil
+	return res[0]
 }
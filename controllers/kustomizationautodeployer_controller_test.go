@@ -18,32 +18,47 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	eventv1 "github.com/fluxcd/pkg/apis/event/v1beta1"
 	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/events"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	"github.com/go-logr/logr/testr"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
-	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/healthcheck"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/ratelimit"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/scheduled"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/soaktime"
 	"github.com/gitops-tools/kustomization-auto-deployer/pkg/git"
 	"github.com/gitops-tools/kustomization-auto-deployer/test"
 )
@@ -80,11 +95,9 @@ func TestReconciliation(t *testing.T) {
 	reconciler := &KustomizationAutoDeployerReconciler{
 		Client:         k8sClient,
 		Scheme:         scheme,
+		EventRecorder:  &record.FakeRecorder{},
 		RevisionLister: testRevisionLister(test.CommitIDs),
-		GateFactories: map[string]gates.GateFactory{
-			"HealthCheck": healthcheck.Factory(http.DefaultClient),
-			"Scheduled":   scheduled.Factory,
-		},
+		Gates:          testGateRegistry(),
 	}
 
 	test.AssertNoError(t, reconciler.SetupWithManager(mgr))
@@ -98,7 +111,7 @@ func TestReconciliation(t *testing.T) {
 		defer cleanupResource(t, k8sClient, deployer)
 
 		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
-		test.AssertErrorMatch(t, "failed to load kustomizationRef missing-kustomization-name", err)
+		test.AssertErrorMatch(t, "failed to load kustomizationRef default/missing-kustomization-name", err)
 
 		reload(t, k8sClient, deployer)
 		assertDeployerCondition(t, deployer, metav1.ConditionFalse, meta.ReadyCondition, deployerv1.FailedToLoadKustomizationReason, "referenced Kustomization could not be loaded")
@@ -158,6 +171,10 @@ func TestReconciliation(t *testing.T) {
 
 	t.Run("reconciling GitRepository with non-head commit", func(t *testing.T) {
 		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		recorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = recorder
+		defer func() { reconciler.EventRecorder = &record.FakeRecorder{} }()
+
 		deployer := test.NewKustomizationAutoDeployer()
 		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
 		defer cleanupResource(t, k8sClient, deployer)
@@ -193,6 +210,227 @@ func TestReconciliation(t *testing.T) {
 		if repo.Spec.Reference.Commit != test.CommitIDs[3] {
 			t.Errorf("failed to configure the GitRepository with the correct commit got %q, want %q", repo.Spec.Reference.Commit, test.CommitIDs[3])
 		}
+
+		if v := testutil.ToFloat64(commitsBehind.WithLabelValues(deployer.Namespace, deployer.Name)); v != 4 {
+			t.Errorf("got %v commits behind, want 4", v)
+		}
+		if v := testutil.ToFloat64(advancesTotal.WithLabelValues(deployer.Namespace, deployer.Name)); v < 1 {
+			t.Errorf("failed to count the advance, got %v", v)
+		}
+		if deployer.Status.LastAdvancedTime == nil {
+			t.Error("failed to record the LastAdvancedTime")
+		}
+
+		assertEvents(t, recorder, []string{
+			fmt.Sprintf("Normal CommitAdvanced advanced from commit %s to commit %s map[flux.gitops.pro/revision:main@sha1:%s]", test.CommitIDs[4], test.CommitIDs[3], test.CommitIDs[3]),
+		})
+	})
+
+	t.Run("reconciling with a commit that is not listed", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		deployer := test.NewKustomizationAutoDeployer()
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+		commitsBehind.WithLabelValues(deployer.Namespace, deployer.Name).Set(4)
+
+		// The commit is older than the listed commits.
+		oldCommit := strings.Repeat("a", 40)
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + oldCommit,
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + oldCommit
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		if commitsBehind.DeleteLabelValues(deployer.Namespace, deployer.Name) {
+			t.Error("failed to delete the commits behind for a commit that is not listed")
+		}
+	})
+
+	t.Run("posting events to the notification-controller", func(t *testing.T) {
+		received := make(chan eventv1.Event, 10)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var event eventv1.Event
+			if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			received <- event
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		t.Cleanup(ts.Close)
+
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		recorder, err := events.NewRecorderForScheme(scheme, &record.FakeRecorder{}, testr.New(t), ts.URL, "kustomization-auto-deployer")
+		test.AssertNoError(t, err)
+		reconciler.EventRecorder = recorder
+		defer func() { reconciler.EventRecorder = &record.FakeRecorder{} }()
+
+		deployer := test.NewKustomizationAutoDeployer()
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[4],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		var event eventv1.Event
+		select {
+		case event = <-received:
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for an event to be posted")
+		}
+
+		if event.InvolvedObject.Kind != "KustomizationAutoDeployer" || event.InvolvedObject.Name != deployer.Name || event.InvolvedObject.Namespace != deployer.Namespace {
+			t.Errorf("got involved object %#v", event.InvolvedObject)
+		}
+		if event.Severity != eventv1.EventSeverityInfo {
+			t.Errorf("got Severity %q, want %q", event.Severity, eventv1.EventSeverityInfo)
+		}
+		if event.Reason != deployerv1.CommitAdvancedReason {
+			t.Errorf("got Reason %q, want %q", event.Reason, deployerv1.CommitAdvancedReason)
+		}
+		wantMetadata := map[string]string{
+			"flux.gitops.pro/revision": "main@sha1:" + test.CommitIDs[3],
+		}
+		if diff := cmp.Diff(wantMetadata, event.Metadata); diff != "" {
+			t.Errorf("failed to post revision metadata:\n%s", diff)
+		}
+	})
+
+	t.Run("recording the time to deploy an advanced commit", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		deployer := test.NewKustomizationAutoDeployer()
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+		deployer.Status.LatestCommit = "main@sha1:" + test.CommitIDs[4]
+		deployer.Status.LastAdvancedTime = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, deployer))
+
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[4],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+		readyTime := metav1.NewTime(time.Now().Add(-time.Second * 30).Truncate(time.Second))
+		kustomization.Status.Conditions = []metav1.Condition{
+			{Type: meta.ReadyCondition, Status: metav1.ConditionTrue, Reason: meta.ReconciliationSucceededReason, LastTransitionTime: readyTime},
+		}
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		if c := testutil.CollectAndCount(commitDeployDuration, "kustomization_auto_deployer_commit_deploy_duration_seconds"); c != 1 {
+			t.Errorf("failed to observe the time to deploy, got %d series", c)
+		}
+
+		reload(t, k8sClient, deployer)
+		// This is the time the deployer advanced to the next commit.
+		if deployer.Status.LastAdvancedTime == nil || time.Since(deployer.Status.LastAdvancedTime.Time) > time.Minute/2 {
+			t.Errorf("failed to reset the LastAdvancedTime, got %v", deployer.Status.LastAdvancedTime)
+		}
+
+		// The test commits are each an hour older than the previous one.
+		if deployer.Status.DORA == nil || len(deployer.Status.DORA.RecentDeployments) != 1 {
+			t.Fatalf("failed to record the deployment, got %#v", deployer.Status.DORA)
+		}
+		if c := deployer.Status.DORA.RecentDeployments[0].Commit; c != test.CommitIDs[4] {
+			t.Errorf("got deployed commit %s, want %s", c, test.CommitIDs[4])
+		}
+		if d := deployer.Status.DORA.RecentDeployments[0].DeployedTime; !d.Equal(&readyTime) {
+			t.Errorf("got deployed time %v, want the Ready transition time %v", d, readyTime)
+		}
+		if lt := deployer.Status.DORA.LeadTimeForChanges; lt == nil || lt.Duration.Round(time.Hour) != time.Hour*5 {
+			t.Errorf("got lead time for changes %v, want 5h", lt)
+		}
+	})
+
+	t.Run("recording a failed deployment", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		deployer := test.NewKustomizationAutoDeployer()
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+		deployer.Status.LatestCommit = "main@sha1:" + test.CommitIDs[3]
+		deployer.Status.LastAdvancedTime = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, deployer))
+
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[3],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+		kustomization.Status.LastAttemptedRevision = "main@sha1:" + test.CommitIDs[3]
+		failedTime := metav1.NewTime(time.Now().Add(-time.Second * 30).Truncate(time.Second))
+		apimeta.SetStatusCondition(&kustomization.Status.Conditions, metav1.Condition{
+			Type:               meta.ReadyCondition,
+			Status:             metav1.ConditionFalse,
+			Reason:             meta.ReconciliationFailedReason,
+			Message:            "failed to apply",
+			LastTransitionTime: failedTime,
+		})
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		for range 2 {
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+			test.AssertNoError(t, err)
+		}
+
+		reload(t, k8sClient, deployer)
+		if deployer.Status.DORA == nil || len(deployer.Status.DORA.RecentDeployments) != 1 {
+			t.Fatalf("failed to record the failed deployment once, got %#v", deployer.Status.DORA)
+		}
+		if d := deployer.Status.DORA.RecentDeployments[0]; d.Commit != test.CommitIDs[3] || !d.Failed || !d.DeployedTime.Equal(&failedTime) {
+			t.Errorf("got deployment %#v, want a failed deployment of %s at %v", d, test.CommitIDs[3], failedTime)
+		}
+		if r := deployer.Status.DORA.ChangeFailureRate; r == nil || r.Cmp(resource.MustParse("1")) != 0 {
+			t.Errorf("got change failure rate %v, want 1", r)
+		}
 	})
 
 	t.Run("reconciling GitRepository with head commit", func(t *testing.T) {
@@ -243,6 +481,65 @@ func TestReconciliation(t *testing.T) {
 		}
 	})
 
+	t.Run("reconciling with a Kustomization in another namespace", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+		test.AssertNoError(t, k8sClient.Create(ctx, ns))
+
+		deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Spec.KustomizationRef.Namespace = ns.Name
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		repo := test.NewGitRepository(func(r *sourcev1.GitRepository) {
+			r.Namespace = ns.Name
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[1],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo, func(k *kustomizev1.Kustomization) {
+			k.Namespace = ns.Name
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[1]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		updatedRepo := &sourcev1.GitRepository{}
+		test.AssertNoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(repo), updatedRepo))
+		if updatedRepo.Spec.Reference.Commit != test.CommitIDs[0] {
+			t.Errorf("failed to configure the GitRepository with the correct commit got %q, want %q", updatedRepo.Spec.Reference.Commit, test.CommitIDs[0])
+		}
+	})
+
+	t.Run("reconciling with cross-namespace references disabled", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		reconciler.NoCrossNamespaceRefs = true
+		defer func() { reconciler.NoCrossNamespaceRefs = false }()
+
+		deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Spec.KustomizationRef.Namespace = "apps"
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionFalse, meta.ReadyCondition, deployerv1.AccessDeniedReason, "cannot reference Kustomization apps/test-kustomization, cross-namespace references are disabled")
+	})
+
 	t.Run("reconciling with deployed HEAD commit", func(t *testing.T) {
 		ctx := log.IntoContext(context.TODO(), testr.New(t))
 		deployer := test.NewKustomizationAutoDeployer()
@@ -287,6 +584,10 @@ func TestReconciliation(t *testing.T) {
 
 	t.Run("reconciling GitRepository with desired commit configured", func(t *testing.T) {
 		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		recorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = recorder
+		defer func() { reconciler.EventRecorder = &record.FakeRecorder{} }()
+
 		deployer := test.NewKustomizationAutoDeployer()
 		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
 		defer cleanupResource(t, k8sClient, deployer)
@@ -328,6 +629,60 @@ func TestReconciliation(t *testing.T) {
 		if updatedRepo.Spec.Reference.Commit != test.CommitIDs[0] {
 			t.Errorf("failed to configure the GitRepository with the correct commit got %q, want %q", updatedRepo.Spec.Reference.Commit, test.CommitIDs[0])
 		}
+
+		// The Event is only recorded when the commit is first requested.
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		wantMessage := fmt.Sprintf("waiting for GitRepository %s to fetch commit %s", client.ObjectKeyFromObject(repo), test.CommitIDs[0])
+		assertDeployerCondition(t, deployer, metav1.ConditionTrue, meta.ReadyCondition, deployerv1.CommitRequestedReason, wantMessage)
+		assertEvents(t, recorder, []string{
+			"Normal CommitRequested " + wantMessage,
+		})
+	})
+
+	t.Run("reconciling GitRepository with the advanced commit configured", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		recorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = recorder
+		defer func() { reconciler.EventRecorder = &record.FakeRecorder{} }()
+
+		deployer := test.NewKustomizationAutoDeployer()
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+		apimeta.SetStatusCondition(&deployer.Status.Conditions, metav1.Condition{
+			Type:    meta.ReadyCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  deployerv1.CommitAdvancedReason,
+			Message: "advanced to commit " + test.CommitIDs[0],
+		})
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, deployer))
+
+		repo := test.NewGitRepository(func(gr *sourcev1.GitRepository) {
+			gr.Spec.Reference.Commit = test.CommitIDs[0]
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[1],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[1]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		// The CommitAdvanced Event reported the requested commit.
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionTrue, meta.ReadyCondition, deployerv1.CommitAdvancedReason, "advanced to commit "+test.CommitIDs[0])
+		assertEvents(t, recorder, nil)
 	})
 
 	t.Run("reconciling GitRepository when the Kustomization hasn't reconciled", func(t *testing.T) {
@@ -373,33 +728,17 @@ func TestReconciliation(t *testing.T) {
 		}
 	})
 
-	t.Run("reconciling with open gates", func(t *testing.T) {
-		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			fmt.Fprintln(w, "Successful response")
-		}))
-		t.Cleanup(ts.Close)
-
+	t.Run("reconciling suspended deployer", func(t *testing.T) {
 		ctx := log.IntoContext(context.TODO(), testr.New(t))
-		deployer := test.NewKustomizationAutoDeployer(func(kd *deployerv1.KustomizationAutoDeployer) {
-			kd.Spec.Gates = []deployerv1.KustomizationGate{
-				{
-					Name: "accessing a test server",
-					HealthCheck: &deployerv1.HealthCheck{
-						URL:      ts.URL,
-						Interval: metav1.Duration{Duration: time.Minute * 7},
-					},
-				},
-			}
+		deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Spec.Suspend = true
 		})
-
 		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
 		defer cleanupResource(t, k8sClient, deployer)
 
-		// both use the same commit IDs test.CommitIDs[4]
 		repo := test.NewGitRepository()
 		test.AssertNoError(t, k8sClient.Create(ctx, repo))
 		defer cleanupResource(t, k8sClient, repo)
-
 		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
 			r.Status.Artifact = &meta.Artifact{
 				Revision: "main@sha1:" + test.CommitIDs[4],
@@ -415,51 +754,894 @@ func TestReconciliation(t *testing.T) {
 		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
 		test.AssertNoError(t, err)
 
-		test.AssertNoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(deployer), deployer))
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionTrue, deployerv1.SuspendedCondition, deployerv1.SuspendedReason, "reconciliation is suspended")
 
-		// one closer to HEAD
-		want := "main@sha1:" + test.CommitIDs[3]
-		if deployer.Status.LatestCommit != want {
-			t.Errorf("failed to update with latest commit, got %q, want %q", deployer.Status.LatestCommit, want)
+		reload(t, k8sClient, repo)
+		if repo.Spec.Reference.Commit != test.CommitIDs[0] {
+			t.Errorf("GitRepository commit updated to %q when suspended", repo.Spec.Reference.Commit)
 		}
 
-		test.AssertNoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(repo), repo))
+		// Resuming the deployer removes the condition and advances the commit.
+		deployer.Spec.Suspend = false
+		test.AssertNoError(t, k8sClient.Update(ctx, deployer))
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		if cond := apimeta.FindStatusCondition(deployer.Status.Conditions, deployerv1.SuspendedCondition); cond != nil {
+			t.Errorf("Suspended condition was not removed: %#v", cond)
+		}
+
+		reload(t, k8sClient, repo)
 		if repo.Spec.Reference.Commit != test.CommitIDs[3] {
 			t.Errorf("failed to configure the GitRepository with the correct commit got %q, want %q", repo.Spec.Reference.Commit, test.CommitIDs[3])
 		}
+	})
 
-		assertDeployerGatesEqual(t, deployer, map[string]map[string]bool{
-			"accessing a test server": {"HealthCheckGate": true},
+	t.Run("reconciling deployer with target commit", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Spec.Strategy.TargetCommit = test.CommitIDs[3]
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[3],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[3]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionTrue, meta.ReadyCondition, deployerv1.TargetCommitReachedReason, "target commit "+test.CommitIDs[3]+" reached")
+
+		reload(t, k8sClient, repo)
+		if repo.Spec.Reference.Commit != test.CommitIDs[0] {
+			t.Errorf("GitRepository commit updated to %q beyond the target commit", repo.Spec.Reference.Commit)
+		}
+	})
+
+	t.Run("reconciling deployer with unknown target commit", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Spec.Strategy.TargetCommit = "unknown"
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[4],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		if result.RequeueAfter != deployer.Spec.Interval.Duration {
+			t.Errorf("got RequeueAfter %v, want %v", result.RequeueAfter, deployer.Spec.Interval.Duration)
+		}
+
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionFalse, meta.ReadyCondition, deployerv1.TargetCommitNotFoundReason, "target commit unknown not found in the latest 17 commits")
+	})
+
+	t.Run("reconciling deployer with pinned commit", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Spec.Strategy.PinnedCommit = test.CommitIDs[6]
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[2],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[2]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, repo)
+		if repo.Spec.Reference.Commit != test.CommitIDs[6] {
+			t.Errorf("failed to pin the GitRepository commit got %q, want %q", repo.Spec.Reference.Commit, test.CommitIDs[6])
+		}
+
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionTrue, meta.ReadyCondition, deployerv1.CommitPinnedReason, "pinned to commit "+test.CommitIDs[6])
+		if want := "main@sha1:" + test.CommitIDs[6]; deployer.Status.LatestCommit != want {
+			t.Errorf("failed to update with latest commit, got %q, want %q", deployer.Status.LatestCommit, want)
+		}
+
+		// Flux applies the pinned commit, and the pin is removed.
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[6],
+			}
+		})
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[6]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+		deployer.Spec.Strategy.PinnedCommit = ""
+		test.AssertNoError(t, k8sClient.Update(ctx, deployer))
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, repo)
+		if repo.Spec.Reference.Commit != test.CommitIDs[5] {
+			t.Errorf("failed to resume from the pinned commit got %q, want %q", repo.Spec.Reference.Commit, test.CommitIDs[5])
+		}
+	})
+
+	t.Run("reconciling deployer with pinned commit after the applied commit", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Spec.Strategy.PinnedCommit = test.CommitIDs[1]
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[4],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, repo)
+		if repo.Spec.Reference.Commit == test.CommitIDs[1] {
+			t.Errorf("GitRepository was pinned to commit %q after the applied commit", repo.Spec.Reference.Commit)
+		}
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionFalse, meta.ReadyCondition, deployerv1.PinnedCommitRejectedReason,
+			fmt.Sprintf("pinned commit %s is not at or before the applied commit %s in the latest 17 commits", test.CommitIDs[1], test.CommitIDs[4]))
+		if res.RequeueAfter != deployer.Spec.Interval.Duration {
+			t.Errorf("got RequeueAfter %v, want %v", res.RequeueAfter, deployer.Spec.Interval.Duration)
+		}
+
+		// Pinning an earlier commit is allowed.
+		deployer.Spec.Strategy.PinnedCommit = test.CommitIDs[5]
+		test.AssertNoError(t, k8sClient.Update(ctx, deployer))
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, repo)
+		if repo.Spec.Reference.Commit != test.CommitIDs[5] {
+			t.Errorf("failed to pin the GitRepository commit got %q, want %q", repo.Spec.Reference.Commit, test.CommitIDs[5])
+		}
+	})
+
+	cleanupTests := []struct {
+		policy     deployerv1.CleanupPolicy
+		wantCommit string
+	}{
+		{policy: deployerv1.RetainCleanupPolicy, wantCommit: test.CommitIDs[3]},
+		{policy: deployerv1.UnpinCleanupPolicy, wantCommit: ""},
+		{policy: deployerv1.LastVerifiedCleanupPolicy, wantCommit: test.CommitIDs[4]},
+	}
+	for _, tt := range cleanupTests {
+		t.Run(fmt.Sprintf("deleting deployer with %s cleanup policy", tt.policy), func(t *testing.T) {
+			ctx := log.IntoContext(context.TODO(), testr.New(t))
+			deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Spec.CleanupPolicy = tt.policy
+			})
+			test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+
+			repo := test.NewGitRepository()
+			test.AssertNoError(t, k8sClient.Create(ctx, repo))
+			defer cleanupResource(t, k8sClient, repo)
+			test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+				r.Status.Artifact = &meta.Artifact{
+					Revision: "main@sha1:" + test.CommitIDs[4],
+				}
+			})
+
+			kustomization := test.NewKustomization(repo)
+			test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+			defer cleanupResource(t, k8sClient, kustomization)
+			kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+			test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+			test.AssertNoError(t, err)
+
+			reload(t, k8sClient, deployer)
+			if !controllerutil.ContainsFinalizer(deployer, deployerv1.KustomizationAutoDeployerFinalizer) {
+				t.Fatalf("deployer finalizer not added, got %v", deployer.GetFinalizers())
+			}
+
+			test.AssertNoError(t, k8sClient.Delete(ctx, deployer))
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+			test.AssertNoError(t, err)
+
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(deployer), deployer); !apierrors.IsNotFound(err) {
+				t.Fatalf("deployer was not deleted: %v", err)
+			}
+
+			reload(t, k8sClient, repo)
+			if repo.Spec.Reference.Commit != tt.wantCommit {
+				t.Errorf("got GitRepository commit %q, want %q", repo.Spec.Reference.Commit, tt.wantCommit)
+			}
+		})
+	}
+
+	t.Run("reconciling deployer paused by a pipeline", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.SetAnnotations(map[string]string{deployerv1.PipelinePausedAnnotation: "true"})
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionFalse, meta.ReadyCondition, deployerv1.PipelinePausedReason, "deployment pipeline is paused")
+
+		updatedRepo := &sourcev1.GitRepository{}
+		test.AssertNoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(repo), updatedRepo))
+		if diff := cmp.Diff(repo.Spec.Reference, updatedRepo.Spec.Reference); diff != "" {
+			t.Errorf("GitRepository reference has been updated when paused:\n%s", diff)
+		}
+	})
+
+	t.Run("reconciling deployer waiting for promotion", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.SetAnnotations(map[string]string{deployerv1.PromotedCommitAnnotation: test.CommitIDs[4]})
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[4],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionFalse, meta.ReadyCondition, deployerv1.WaitingForPromotionReason, "waiting for commit "+test.CommitIDs[3]+" to be promoted")
+
+		reload(t, k8sClient, repo)
+		if repo.Spec.Reference.Commit != test.CommitIDs[0] {
+			t.Errorf("GitRepository commit updated to %q when waiting for promotion", repo.Spec.Reference.Commit)
+		}
+	})
+
+	t.Run("reconciling deployer with promoted commit", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.SetAnnotations(map[string]string{deployerv1.PromotedCommitAnnotation: test.CommitIDs[2]})
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[4],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, repo)
+		if repo.Spec.Reference.Commit != test.CommitIDs[3] {
+			t.Errorf("failed to configure the GitRepository with the correct commit got %q, want %q", repo.Spec.Reference.Commit, test.CommitIDs[3])
+		}
+	})
+
+	t.Run("reconciling with open gates", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, "Successful response")
+		}))
+		t.Cleanup(ts.Close)
+
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		deployer := test.NewKustomizationAutoDeployer(func(kd *deployerv1.KustomizationAutoDeployer) {
+			kd.Spec.Gates = []deployerv1.KustomizationGate{
+				{
+					Name: "accessing a test server",
+					HealthCheck: &deployerv1.HealthCheck{
+						URL:      ts.URL,
+						Interval: metav1.Duration{Duration: time.Minute * 7},
+					},
+				},
+			}
+		})
+
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		// both use the same commit IDs test.CommitIDs[4]
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[4],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		test.AssertNoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(deployer), deployer))
+
+		// one closer to HEAD
+		want := "main@sha1:" + test.CommitIDs[3]
+		if deployer.Status.LatestCommit != want {
+			t.Errorf("failed to update with latest commit, got %q, want %q", deployer.Status.LatestCommit, want)
+		}
+
+		test.AssertNoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(repo), repo))
+		if repo.Spec.Reference.Commit != test.CommitIDs[3] {
+			t.Errorf("failed to configure the GitRepository with the correct commit got %q, want %q", repo.Spec.Reference.Commit, test.CommitIDs[3])
+		}
+
+		assertDeployerGatesEqual(t, deployer, []deployerv1.GateStatus{
+			{
+				Name: "accessing a test server",
+				Open: true,
+				Checks: []deployerv1.GateCheckStatus{
+					{
+						Name:               "HealthCheck",
+						Open:               true,
+						Message:            ts.URL + " returned 200 OK",
+						Commit:             test.CommitIDs[3],
+						ObservedGeneration: deployer.Generation,
+					},
+				},
+			},
+		})
+	})
+
+	t.Run("reconciling with closed gates", func(t *testing.T) {
+		var requests atomic.Int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			http.Error(w, "gate is closed", http.StatusInternalServerError)
+		}))
+		t.Cleanup(ts.Close)
+
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		recorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = recorder
+		defer func() { reconciler.EventRecorder = &record.FakeRecorder{} }()
+
+		deployer := test.NewKustomizationAutoDeployer(func(kd *deployerv1.KustomizationAutoDeployer) {
+			kd.Spec.Gates = []deployerv1.KustomizationGate{
+				{
+					Name: "accessing a closed test server",
+					HealthCheck: &deployerv1.HealthCheck{
+						URL:      ts.URL,
+						Interval: metav1.Duration{Duration: time.Minute * 13},
+					},
+				},
+			}
+		})
+
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		// both use the same commit IDs test.CommitIDs[4]
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[4],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+
+		if res.RequeueAfter > time.Minute*13 || res.RequeueAfter < time.Minute*13-time.Second {
+			t.Errorf("failed to set the RequeuAfter from the HealthCheck, got %v, want %v", res.RequeueAfter, time.Minute*13)
+		}
+
+		if deployer.Status.LatestCommit != "" {
+			t.Errorf("Status.LatestCommit has been populated with %s, it should be empty", deployer.Status.LatestCommit)
+		}
+
+		updatedRepo := &sourcev1.GitRepository{}
+		test.AssertNoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(repo), updatedRepo))
+		if diff := cmp.Diff(repo.Spec.Reference, updatedRepo.Spec.Reference); diff != "" {
+			t.Errorf("GitRepository reference has been updated when gates are closed:\n%s", diff)
+		}
+
+		assertDeployerGatesEqual(t, deployer, []deployerv1.GateStatus{
+			{
+				Name: "accessing a closed test server",
+				Open: false,
+				Checks: []deployerv1.GateCheckStatus{
+					{
+						Name:               "HealthCheck",
+						Open:               false,
+						Message:            ts.URL + " returned 500 Internal Server Error",
+						Commit:             test.CommitIDs[3],
+						ObservedGeneration: deployer.Generation,
+					},
+				},
+			},
+		})
+		assertDeployerCondition(t, deployer, metav1.ConditionFalse, meta.ReadyCondition, deployerv1.GatesClosedReason, "gates are currently closed: accessing a closed test server")
+		if deployer.Status.Gates.Open || deployer.Status.Gates.Commit != test.CommitIDs[3] || deployer.Status.Gates.LastCheckTime == nil {
+			t.Errorf("failed to summarise the gates, got %#v", deployer.Status.Gates)
+		}
+		lastTransitionTime := deployer.Status.Gates.Results[0].Checks[0].LastTransitionTime
+		if deployer.Status.Gates.Results[0].Checks[0].NextCheckTime == nil {
+			t.Error("failed to set the NextCheckTime for the check")
+		}
+
+		assertEvents(t, recorder, []string{
+			fmt.Sprintf("Normal GatesClosed gates closed for commit %s: accessing a closed test server (HealthCheck=closed) map[flux.gitops.pro/revision:main@sha1:%s]", test.CommitIDs[3], test.CommitIDs[3]),
+		})
+
+		// Reconciling again before the interval has elapsed reuses the result
+		// of the check, and doesn't record a duplicate Event.
+		lastCheckTime := deployer.Status.Gates.Results[0].Checks[0].LastCheckTime
+		time.Sleep(time.Second)
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+		assertEvents(t, recorder, nil)
+
+		reload(t, k8sClient, deployer)
+		if n := requests.Load(); n != 1 {
+			t.Errorf("got %d requests to the health check, want 1", n)
+		}
+		if check := deployer.Status.Gates.Results[0].Checks[0]; !check.LastCheckTime.Equal(&lastCheckTime) {
+			t.Errorf("LastCheckTime changed from %v to %v for a cached check", lastCheckTime, check.LastCheckTime)
+		}
+
+		// Changing the spec checks the gates again.
+		deployer.Spec.Gates[0].HealthCheck.Interval = metav1.Duration{Duration: time.Minute * 14}
+		test.AssertNoError(t, k8sClient.Update(ctx, deployer))
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+		assertEvents(t, recorder, nil)
+
+		reload(t, k8sClient, deployer)
+		if n := requests.Load(); n != 2 {
+			t.Errorf("got %d requests to the health check, want 2", n)
+		}
+		check := deployer.Status.Gates.Results[0].Checks[0]
+		if check.ObservedGeneration != deployer.Generation {
+			t.Errorf("got check ObservedGeneration %d, want %d", check.ObservedGeneration, deployer.Generation)
+		}
+		if !check.LastTransitionTime.Equal(&lastTransitionTime) {
+			t.Errorf("LastTransitionTime changed from %v to %v without a transition", lastTransitionTime, check.LastTransitionTime)
+		}
+		if !check.LastCheckTime.After(lastTransitionTime.Time) {
+			t.Errorf("failed to update the LastCheckTime, got %v", check.LastCheckTime)
+		}
+	})
+
+	t.Run("reconciling with a DeploymentPolicy", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "gate is closed", http.StatusInternalServerError)
+		}))
+		t.Cleanup(ts.Close)
+
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		deployer := test.NewKustomizationAutoDeployer(func(kd *deployerv1.KustomizationAutoDeployer) {
+			kd.SetLabels(map[string]string{"environment": "production"})
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		policy := test.NewDeploymentPolicy(func(p *deployerv1.DeploymentPolicy) {
+			p.Spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: test.DefaultNamespace}}
+			p.Spec.DeployerSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "production"}}
+			p.Spec.Gates = []deployerv1.KustomizationGate{
+				{
+					Name:        "change approval",
+					HealthCheck: &deployerv1.HealthCheck{URL: ts.URL},
+				},
+			}
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, policy))
+		defer cleanupResource(t, k8sClient, policy)
+
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[4],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionFalse, meta.ReadyCondition, deployerv1.GatesClosedReason, "gates are currently closed: demo-policy/change approval")
+		assertDeployerGatesEqual(t, deployer, []deployerv1.GateStatus{
+			{
+				Name:   "demo-policy/change approval",
+				Source: "DeploymentPolicy/demo-policy",
+				Open:   false,
+				Checks: []deployerv1.GateCheckStatus{
+					{
+						Name:               "HealthCheck",
+						Open:               false,
+						Message:            ts.URL + " returned 500 Internal Server Error",
+						Commit:             test.CommitIDs[3],
+						ObservedGeneration: deployer.Generation,
+					},
+				},
+			},
+		})
+
+		// Deployers that are not selected by the policy don't check its gates.
+		policy.Spec.DeployerSelector.MatchLabels["environment"] = "staging"
+		test.AssertNoError(t, k8sClient.Update(ctx, policy))
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionTrue, meta.ReadyCondition, deployerv1.CommitAdvancedReason, "advanced to commit "+test.CommitIDs[3])
+		if deployer.Status.Gates != nil {
+			t.Errorf("got gates status %#v for a deployer without gates", deployer.Status.Gates)
+		}
+	})
+
+	t.Run("reconciling with a DeploymentFreeze", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		recorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = recorder
+		defer func() { reconciler.EventRecorder = &record.FakeRecorder{} }()
+
+		// The gates aren't checked during the freeze.
+		var checks int
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			checks++
+		}))
+		t.Cleanup(ts.Close)
+
+		deployer := test.NewKustomizationAutoDeployer(func(kd *deployerv1.KustomizationAutoDeployer) {
+			kd.Spec.Gates = []deployerv1.KustomizationGate{
+				{
+					Name:        "health",
+					HealthCheck: &deployerv1.HealthCheck{URL: ts.URL},
+				},
+			}
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		end := metav1.NewTime(time.Now().Add(time.Hour).Truncate(time.Second))
+		freeze := &deployerv1.DeploymentFreeze{
+			ObjectMeta: metav1.ObjectMeta{Name: "incident"},
+			Spec: deployerv1.DeploymentFreezeSpec{
+				End:    &end,
+				Reason: "incident in progress",
+			},
+		}
+		test.AssertNoError(t, k8sClient.Create(ctx, freeze))
+		defer cleanupResource(t, k8sClient, freeze)
+
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[4],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		if res.RequeueAfter > time.Hour || res.RequeueAfter < time.Hour-time.Second*2 {
+			t.Errorf("failed to requeue at the end of the freeze, got %v", res.RequeueAfter)
+		}
+		assertDeployerCondition(t, deployer, metav1.ConditionFalse, meta.ReadyCondition, deployerv1.GatesClosedReason, "gates are currently closed: freeze/incident")
+		assertDeployerGatesEqual(t, deployer, []deployerv1.GateStatus{
+			{
+				Name:   "freeze/incident",
+				Source: "DeploymentFreeze/incident",
+				Open:   false,
+				Checks: []deployerv1.GateCheckStatus{
+					{
+						Name:               "DeploymentFreeze",
+						Open:               false,
+						Message:            "frozen until " + end.Format(time.RFC3339) + ": incident in progress",
+						Commit:             test.CommitIDs[3],
+						ObservedGeneration: deployer.Generation,
+						NextCheckTime:      &end,
+					},
+				},
+			},
+		})
+		assertEvents(t, recorder, []string{
+			fmt.Sprintf("Normal GatesClosed gates closed for commit %s: freeze/incident (DeploymentFreeze=closed) map[flux.gitops.pro/revision:main@sha1:%s]", test.CommitIDs[3], test.CommitIDs[3]),
+		})
+		if checks != 0 {
+			t.Errorf("checked the gates %d times during the freeze", checks)
+		}
+
+		// The break glass annotation bypasses the freeze.
+		expires := time.Now().Add(time.Minute * 30).Truncate(time.Second).UTC()
+		deployer.SetAnnotations(map[string]string{
+			deployerv1.BreakGlassAnnotation:     expires.Format(time.RFC3339),
+			deployerv1.BreakGlassUserAnnotation: "incident-commander",
+		})
+		test.AssertNoError(t, k8sClient.Update(ctx, deployer))
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionTrue, meta.ReadyCondition, deployerv1.CommitAdvancedReason, "advanced to commit "+test.CommitIDs[3])
+		want := &deployerv1.BreakGlassStatus{
+			User:        "incident-commander",
+			ExpiresTime: metav1.NewTime(expires),
+			Commit:      test.CommitIDs[3],
+			Freezes:     []string{"incident"},
+		}
+		if diff := cmp.Diff(want, deployer.Status.BreakGlass, cmpopts.IgnoreFields(deployerv1.BreakGlassStatus{}, "UsedTime")); diff != "" {
+			t.Fatalf("failed to record the break glass:\n%s", diff)
+		}
+		if checks != 1 {
+			t.Errorf("got %d gate checks after bypassing the freeze, want 1", checks)
+		}
+		if check := deployer.Status.Gates.Results[1].Checks[0]; !check.Open || check.Message != "bypassed by incident-commander until "+expires.Format(time.RFC3339)+": incident in progress" {
+			t.Errorf("failed to bypass the freeze, got %#v", check)
+		}
+		assertEvents(t, recorder, []string{
+			fmt.Sprintf("Normal CommitAdvanced advanced from commit %s to commit %s: health (HealthCheck=open), freeze/incident (DeploymentFreeze=open) map[flux.gitops.pro/revision:main@sha1:%s]", test.CommitIDs[4], test.CommitIDs[3], test.CommitIDs[3]),
+			fmt.Sprintf("Warning BreakGlassUsed commit %s advanced during DeploymentFreeze incident by incident-commander, break glass expires at %s map[flux.gitops.pro/revision:main@sha1:%s]", test.CommitIDs[3], expires.Format(time.RFC3339), test.CommitIDs[3]),
 		})
 	})
 
-	t.Run("reconciling with closed gates", func(t *testing.T) {
-		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			http.Error(w, "gate is closed", http.StatusInternalServerError)
-		}))
-		t.Cleanup(ts.Close)
-
+	t.Run("reconciling with a concurrency group", func(t *testing.T) {
 		ctx := log.IntoContext(context.TODO(), testr.New(t))
 		deployer := test.NewKustomizationAutoDeployer(func(kd *deployerv1.KustomizationAutoDeployer) {
-			kd.Spec.Gates = []deployerv1.KustomizationGate{
-				{
-					Name: "accessing a closed test server",
-					HealthCheck: &deployerv1.HealthCheck{
-						URL:      ts.URL,
-						Interval: metav1.Duration{Duration: time.Minute * 13},
-					},
-				},
+			kd.Spec.ConcurrencyGroup = "databases"
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		other := test.NewKustomizationAutoDeployer(func(kd *deployerv1.KustomizationAutoDeployer) {
+			kd.Name = "other-region"
+			kd.Spec.ConcurrencyGroup = "databases"
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, other))
+		defer cleanupResource(t, k8sClient, other)
+
+		// The other deployer has a commit in flight.
+		holder := "default/other-region"
+		lease := &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "concurrency-group-databases",
+				Namespace:   test.DefaultNamespace,
+				Annotations: map[string]string{deployerv1.ConcurrencyCommitAnnotation: test.CommitIDs[1]},
+			},
+			Spec: coordinationv1.LeaseSpec{HolderIdentity: &holder},
+		}
+		test.AssertNoError(t, k8sClient.Create(ctx, lease))
+		defer cleanupResource(t, k8sClient, lease)
+
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[4],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionFalse, meta.ReadyCondition, deployerv1.WaitingForConcurrencyGroupReason,
+			fmt.Sprintf("waiting for default/other-region to deploy commit %s in concurrency group databases", test.CommitIDs[1]))
+		assertDeployerCondition(t, deployer, metav1.ConditionTrue, deployerv1.WaitingCondition, deployerv1.WaitingForConcurrencyGroupReason,
+			fmt.Sprintf("waiting for default/other-region to deploy commit %s in concurrency group databases", test.CommitIDs[1]))
+		if !apimeta.IsStatusConditionTrue(deployer.Status.Conditions, deployerv1.WaitingCondition) {
+			t.Errorf("Waiting condition is not True: %#v", deployer.Status.Conditions)
+		}
+		if res.RequeueAfter != deployer.Spec.Interval.Duration {
+			t.Errorf("got RequeueAfter %v, want %v", res.RequeueAfter, deployer.Spec.Interval.Duration)
+		}
+		updatedRepo := &sourcev1.GitRepository{}
+		test.AssertNoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(repo), updatedRepo))
+		if diff := cmp.Diff(repo.Spec.Reference, updatedRepo.Spec.Reference); diff != "" {
+			t.Errorf("GitRepository reference has been updated while waiting:\n%s", diff)
+		}
+
+		// The Lease is taken when the holder leaves the group.
+		other.Spec.ConcurrencyGroup = ""
+		test.AssertNoError(t, k8sClient.Update(ctx, other))
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionTrue, meta.ReadyCondition, deployerv1.CommitAdvancedReason, "advanced to commit "+test.CommitIDs[3])
+		if cond := apimeta.FindStatusCondition(deployer.Status.Conditions, deployerv1.WaitingCondition); cond != nil {
+			t.Errorf("Waiting condition was not removed: %#v", cond)
+		}
+		reload(t, k8sClient, lease)
+		if h := *lease.Spec.HolderIdentity; h != "default/demo-deployer" {
+			t.Errorf("got Lease holder %q, want default/demo-deployer", h)
+		}
+		if c := lease.Annotations[deployerv1.ConcurrencyCommitAnnotation]; c != test.CommitIDs[3] {
+			t.Errorf("got Lease commit %q, want %q", c, test.CommitIDs[3])
+		}
+
+		// The Lease is released when the Kustomization is Ready with the
+		// commit applied, the target commit stops the deployer advancing and
+		// taking the Lease again.
+		deployer.Spec.Strategy.TargetCommit = test.CommitIDs[3]
+		test.AssertNoError(t, k8sClient.Update(ctx, deployer))
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[3],
 			}
 		})
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[3]
+		kustomization.Status.Conditions = []metav1.Condition{
+			{Type: meta.ReadyCondition, Status: metav1.ConditionTrue, Reason: "ReconciliationSucceeded", LastTransitionTime: metav1.Now()},
+		}
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
 
+		reload(t, k8sClient, lease)
+		if lease.Spec.HolderIdentity != nil {
+			t.Errorf("failed to release the Lease, got holder %q with commit %q", *lease.Spec.HolderIdentity, lease.Annotations[deployerv1.ConcurrencyCommitAnnotation])
+		}
+	})
+
+	t.Run("reconciling with an expired concurrency group Lease", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		reconciler.ConcurrencyLeaseDuration = time.Hour
+		defer func() { reconciler.ConcurrencyLeaseDuration = 0 }()
+		deployer := test.NewKustomizationAutoDeployer(func(kd *deployerv1.KustomizationAutoDeployer) {
+			kd.Spec.ConcurrencyGroup = "databases"
+		})
 		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
 		defer cleanupResource(t, k8sClient, deployer)
 
-		// both use the same commit IDs test.CommitIDs[4]
+		other := test.NewKustomizationAutoDeployer(func(kd *deployerv1.KustomizationAutoDeployer) {
+			kd.Name = "other-region"
+			kd.Spec.ConcurrencyGroup = "databases"
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, other))
+		defer cleanupResource(t, k8sClient, other)
+
+		// The other deployer's commit failed to apply within the lease
+		// duration.
+		holder := "default/other-region"
+		renewed := metav1.NewMicroTime(time.Now().Add(-time.Hour * 2))
+		duration := int32(3600)
+		lease := &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "concurrency-group-databases",
+				Namespace:   test.DefaultNamespace,
+				Annotations: map[string]string{deployerv1.ConcurrencyCommitAnnotation: test.CommitIDs[1]},
+			},
+			Spec: coordinationv1.LeaseSpec{HolderIdentity: &holder, LeaseDurationSeconds: &duration, RenewTime: &renewed},
+		}
+		test.AssertNoError(t, k8sClient.Create(ctx, lease))
+		defer cleanupResource(t, k8sClient, lease)
+
 		repo := test.NewGitRepository()
 		test.AssertNoError(t, k8sClient.Create(ctx, repo))
 		defer cleanupResource(t, k8sClient, repo)
-
 		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
 			r.Status.Artifact = &meta.Artifact{
 				Revision: "main@sha1:" + test.CommitIDs[4],
@@ -472,34 +1654,376 @@ func TestReconciliation(t *testing.T) {
 		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
 		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
 
-		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
 		test.AssertNoError(t, err)
 
 		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionTrue, meta.ReadyCondition, deployerv1.CommitAdvancedReason, "advanced to commit "+test.CommitIDs[3])
+		reload(t, k8sClient, lease)
+		if h := *lease.Spec.HolderIdentity; h != "default/demo-deployer" {
+			t.Errorf("got Lease holder %q, want default/demo-deployer", h)
+		}
+		if d := *lease.Spec.LeaseDurationSeconds; d != 3600 {
+			t.Errorf("got LeaseDurationSeconds %d, want 3600", d)
+		}
 
-		if res.RequeueAfter != time.Minute*13 {
-			t.Errorf("failed to set the RequeuAfter from the HealthCheck, got %v, want %v", res.RequeueAfter, time.Minute*13)
+		// Suspending the holder releases the Lease.
+		deployer.Spec.Suspend = true
+		test.AssertNoError(t, k8sClient.Update(ctx, deployer))
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, lease)
+		if lease.Spec.HolderIdentity != nil {
+			t.Errorf("failed to release the Lease when suspended, got holder %q", *lease.Spec.HolderIdentity)
 		}
+	})
 
-		if deployer.Status.LatestCommit != "" {
-			t.Errorf("Status.LatestCommit has been populated with %s, it should be empty", deployer.Status.LatestCommit)
+	t.Run("reconciling with a concurrency group Lease held past the lease duration", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		deployer := test.NewKustomizationAutoDeployer(func(kd *deployerv1.KustomizationAutoDeployer) {
+			kd.Spec.ConcurrencyGroup = "databases"
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		other := test.NewKustomizationAutoDeployer(func(kd *deployerv1.KustomizationAutoDeployer) {
+			kd.Name = "other-region"
+			kd.Spec.ConcurrencyGroup = "databases"
+			kd.Spec.KustomizationRef.Name = "other-kustomization"
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, other))
+		defer cleanupResource(t, k8sClient, other)
+
+		// The other deployer's commit is still being applied after the
+		// lease duration, the lease duration is not configured so the
+		// Lease doesn't expire.
+		holder := "default/other-region"
+		renewed := metav1.NewMicroTime(time.Now().Add(-time.Hour * 2))
+		duration := int32(3600)
+		lease := &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "concurrency-group-databases",
+				Namespace:   test.DefaultNamespace,
+				Annotations: map[string]string{deployerv1.ConcurrencyCommitAnnotation: test.CommitIDs[1]},
+			},
+			Spec: coordinationv1.LeaseSpec{HolderIdentity: &holder, LeaseDurationSeconds: &duration, RenewTime: &renewed},
+		}
+		test.AssertNoError(t, k8sClient.Create(ctx, lease))
+		defer cleanupResource(t, k8sClient, lease)
+
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[4],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		otherKustomization := test.NewKustomization(repo, func(k *kustomizev1.Kustomization) {
+			k.Name = "other-kustomization"
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, otherKustomization))
+		defer cleanupResource(t, k8sClient, otherKustomization)
+		otherKustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[2]
+		otherKustomization.Status.LastAttemptedRevision = "main@sha1:" + test.CommitIDs[1]
+		otherKustomization.Status.Conditions = []metav1.Condition{
+			{Type: meta.ReadyCondition, Status: metav1.ConditionUnknown, Reason: "Progressing", LastTransitionTime: metav1.Now()},
 		}
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, otherKustomization))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
 
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionFalse, meta.ReadyCondition, deployerv1.WaitingForConcurrencyGroupReason,
+			fmt.Sprintf("waiting for default/other-region to deploy commit %s in concurrency group databases", test.CommitIDs[1]))
 		updatedRepo := &sourcev1.GitRepository{}
 		test.AssertNoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(repo), updatedRepo))
 		if diff := cmp.Diff(repo.Spec.Reference, updatedRepo.Spec.Reference); diff != "" {
-			t.Errorf("GitRepository reference has been updated when gates are closed:\n%s", diff)
+			t.Errorf("GitRepository reference has been updated while waiting:\n%s", diff)
+		}
+		reload(t, k8sClient, lease)
+		if h := *lease.Spec.HolderIdentity; h != holder {
+			t.Errorf("got Lease holder %q, want %q", h, holder)
+		}
+
+		// The Lease is taken when the other deployer's Kustomization fails
+		// to apply the commit.
+		otherKustomization.Status.Conditions = []metav1.Condition{
+			{Type: meta.ReadyCondition, Status: metav1.ConditionFalse, Reason: "ReconciliationFailed", LastTransitionTime: metav1.Now()},
+		}
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, otherKustomization))
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionTrue, meta.ReadyCondition, deployerv1.CommitAdvancedReason, "advanced to commit "+test.CommitIDs[3])
+		reload(t, k8sClient, lease)
+		if h := *lease.Spec.HolderIdentity; h != "default/demo-deployer" {
+			t.Errorf("got Lease holder %q, want default/demo-deployer", h)
 		}
+		if lease.Spec.LeaseDurationSeconds != nil {
+			t.Errorf("got LeaseDurationSeconds %d, want no duration", *lease.Spec.LeaseDurationSeconds)
+		}
+	})
+
+	t.Run("reconciling with a rate limit gate", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		deployer := test.NewKustomizationAutoDeployer(func(kd *deployerv1.KustomizationAutoDeployer) {
+			kd.Spec.Gates = []deployerv1.KustomizationGate{
+				{
+					Name:      "hourly",
+					RateLimit: &deployerv1.RateLimitCheck{Limit: 2, Window: metav1.Duration{Duration: time.Hour}},
+				},
+			}
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		// One commit was advanced in the last hour.
+		previous := metav1.NewTime(time.Now().Add(-time.Minute * 50).Truncate(time.Second))
+		deployer.Status.AdvancedTimes = []metav1.Time{previous}
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, deployer))
+
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[4],
+			}
+		})
+
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionTrue, meta.ReadyCondition, deployerv1.CommitAdvancedReason, "advanced to commit "+test.CommitIDs[3])
+		if n := len(deployer.Status.AdvancedTimes); n != 2 || !deployer.Status.AdvancedTimes[0].Equal(&previous) {
+			t.Fatalf("failed to record the advanced time, got %v", deployer.Status.AdvancedTimes)
+		}
+
+		// The limit is reached until the earlier commit leaves the window.
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[3],
+			}
+		})
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[3]
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		nextSlot := previous.Add(time.Hour)
+		want := time.Until(nextSlot)
+		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionFalse, meta.ReadyCondition, deployerv1.GatesClosedReason, "gates are currently closed: hourly")
+		if res.RequeueAfter > want || res.RequeueAfter < want-time.Second*10 {
+			t.Errorf("failed to requeue when the next slot is free, got %v, want %v", res.RequeueAfter, want)
+		}
+		check := deployer.Status.Gates.Results[0].Checks[0]
+		if want := fmt.Sprintf("2 of 2 commits advanced in the last 1h0m0s, closed until %s", nextSlot.UTC().Format(time.RFC3339)); check.Message != want {
+			t.Errorf("got check message %q, want %q", check.Message, want)
+		}
+	})
 
-		assertDeployerGatesEqual(t, deployer, map[string]map[string]bool{
-			"accessing a closed test server": {"HealthCheckGate": false},
+	t.Run("reconciling with a soak time gate", func(t *testing.T) {
+		ctx := log.IntoContext(context.TODO(), testr.New(t))
+		deployer := test.NewKustomizationAutoDeployer(func(kd *deployerv1.KustomizationAutoDeployer) {
+			kd.Spec.Gates = []deployerv1.KustomizationGate{
+				{
+					Name:     "soak",
+					SoakTime: &deployerv1.SoakTimeCheck{Duration: metav1.Duration{Duration: time.Minute * 30}},
+				},
+			}
+		})
+		test.AssertNoError(t, k8sClient.Create(ctx, deployer))
+		defer cleanupResource(t, k8sClient, deployer)
+
+		repo := test.NewGitRepository()
+		test.AssertNoError(t, k8sClient.Create(ctx, repo))
+		defer cleanupResource(t, k8sClient, repo)
+		test.UpdateRepoStatus(t, k8sClient, repo, func(r *sourcev1.GitRepository) {
+			r.Status.Artifact = &meta.Artifact{
+				Revision: "main@sha1:" + test.CommitIDs[4],
+			}
 		})
+
+		// The current commit became Ready ten minutes ago.
+		readySince := metav1.NewTime(time.Now().Add(-time.Minute * 10).Truncate(time.Second))
+		kustomization := test.NewKustomization(repo)
+		test.AssertNoError(t, k8sClient.Create(ctx, kustomization))
+		defer cleanupResource(t, k8sClient, kustomization)
+		kustomization.Status.LastAppliedRevision = "main@sha1:" + test.CommitIDs[4]
+		kustomization.Status.Conditions = []metav1.Condition{
+			{Type: meta.ReadyCondition, Status: metav1.ConditionTrue, Reason: "ReconciliationSucceeded", LastTransitionTime: readySince},
+		}
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+
+		want := time.Until(readySince.Add(time.Minute * 30))
+		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionFalse, meta.ReadyCondition, deployerv1.GatesClosedReason, "gates are currently closed: soak")
+		if res.RequeueAfter > want || res.RequeueAfter < want-time.Second*10 {
+			t.Errorf("failed to requeue when the commit has soaked, got %v, want %v", res.RequeueAfter, want)
+		}
+
+		// The gate opens when the commit has been Ready for the duration, the
+		// duration is changed so that the closed result is not reused.
+		kustomization.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-time.Hour))
+		test.AssertNoError(t, k8sClient.Status().Update(ctx, kustomization))
+		deployer.Spec.Gates[0].SoakTime.Duration = metav1.Duration{Duration: time.Minute * 45}
+		test.AssertNoError(t, k8sClient.Update(ctx, deployer))
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployer)})
+		test.AssertNoError(t, err)
+
+		reload(t, k8sClient, deployer)
+		assertDeployerCondition(t, deployer, metav1.ConditionTrue, meta.ReadyCondition, deployerv1.CommitAdvancedReason, "advanced to commit "+test.CommitIDs[3])
 	})
+}
+
+func TestSummariseGates(t *testing.T) {
+	gatesStatus := []deployerv1.GateStatus{
+		{
+			Name:   "first gate",
+			Open:   true,
+			Checks: []deployerv1.GateCheckStatus{{Name: "HealthCheck", Open: true}},
+		},
+		{
+			Name: "second gate",
+			Checks: []deployerv1.GateCheckStatus{
+				{Name: "HealthCheck", Open: false},
+				{Name: "Scheduled", Open: true},
+			},
+		},
+	}
+
+	want := "first gate (HealthCheck=open), second gate (HealthCheck=closed, Scheduled=open)"
+	if got := summariseGates(gatesStatus); got != want {
+		t.Errorf("summariseGates() got %q, want %q", got, want)
+	}
+}
+
+func TestPreserveCompletedCallbacks(t *testing.T) {
+	requested := metav1.NewTime(time.Date(2023, time.May, 14, 9, 0, 0, 0, time.UTC))
+	completed := metav1.NewTime(time.Date(2023, time.May, 14, 9, 20, 0, 0, time.UTC))
+	current := []deployerv1.CallbackStatus{
+		{Gate: "integration tests", ID: "callback-1", RequestedTime: requested, Result: deployerv1.CallbackPassed, CompletedTime: &completed},
+		{Gate: "load tests", ID: "callback-2", RequestedTime: requested, Result: deployerv1.CallbackFailed, CompletedTime: &completed},
+	}
+	updated := []deployerv1.CallbackStatus{
+		{Gate: "integration tests", ID: "callback-1", RequestedTime: requested, Result: deployerv1.CallbackPending},
+		{Gate: "load tests", ID: "callback-3", RequestedTime: completed, Result: deployerv1.CallbackPending},
+	}
+
+	want := []deployerv1.CallbackStatus{
+		{Gate: "integration tests", ID: "callback-1", RequestedTime: requested, Result: deployerv1.CallbackPassed, CompletedTime: &completed},
+		{Gate: "load tests", ID: "callback-3", RequestedTime: completed, Result: deployerv1.CallbackPending},
+	}
+	if diff := cmp.Diff(want, preserveCompletedCallbacks(current, updated)); diff != "" {
+		t.Fatalf("failed to preserve callbacks:\n%s", diff)
+	}
+}
+
+func TestCalculateInterval(t *testing.T) {
+	now := time.Date(2023, time.May, 14, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *metav1.Time {
+		return &metav1.Time{Time: now.Add(d)}
+	}
+
+	intervalTests := []struct {
+		name   string
+		checks []deployerv1.GateCheckStatus
+		want   time.Duration
+	}{
+		{
+			name: "no next check times",
+			checks: []deployerv1.GateCheckStatus{
+				{Name: "HealthCheck"},
+			},
+			want: gates.NoRequeueInterval,
+		},
+		{
+			name: "earliest next check time",
+			checks: []deployerv1.GateCheckStatus{
+				{Name: "HealthCheck", NextCheckTime: at(time.Minute * 13)},
+				{Name: "Scheduled", NextCheckTime: at(time.Minute * 5)},
+				{Name: "External"},
+			},
+			want: time.Minute * 5,
+		},
+		{
+			name: "next check times in the past",
+			checks: []deployerv1.GateCheckStatus{
+				{Name: "HealthCheck", NextCheckTime: at(-time.Minute)},
+				{Name: "Scheduled", NextCheckTime: at(time.Minute * 5)},
+			},
+			want: time.Minute * 5,
+		},
+	}
+
+	for _, tt := range intervalTests {
+		t.Run(tt.name, func(t *testing.T) {
+			gatesStatus := []deployerv1.GateStatus{{Name: "test gate", Checks: tt.checks}}
+
+			if got := calculateInterval(gatesStatus, now); got != tt.want {
+				t.Errorf("calculateInterval() got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
 
+func assertEvents(t *testing.T, recorder *record.FakeRecorder, want []string) {
+	t.Helper()
+	got := []string{}
+	for {
+		select {
+		case event := <-recorder.Events:
+			got = append(got, event)
+			continue
+		default:
+		}
+		break
+	}
+
+	if diff := cmp.Diff(want, got, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("failed to record events:\n%s", diff)
+	}
 }
 
 func cleanupResource(t *testing.T, cl client.Client, obj client.Object) {
 	t.Helper()
+	// There's no controller running to process the finalizers.
+	if err := cl.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj); err != nil {
+		t.Fatal(err)
+	}
+	if len(obj.GetFinalizers()) > 0 {
+		obj.SetFinalizers(nil)
+		if err := cl.Update(context.TODO(), obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
 	if err := cl.Delete(context.TODO(), obj); err != nil {
 		t.Fatal(err)
 	}
@@ -507,11 +2031,15 @@ func cleanupResource(t *testing.T, cl client.Client, obj client.Object) {
 
 // Make this an interface!
 func testRevisionLister(commitIDs []string) RevisionLister {
-	return func(ctx context.Context, url string, options git.ListOptions) ([]string, error) {
+	return func(ctx context.Context, url string, options git.ListOptions) ([]git.Revision, error) {
 		if options.MaxCommits > len(commitIDs) {
 			return nil, errors.New("not enough commit IDs to fulfill request")
 		}
-		return commitIDs, nil
+		revisions := []git.Revision{}
+		for i, commitID := range commitIDs {
+			revisions = append(revisions, git.Revision{ID: commitID, Time: time.Now().Add(-time.Hour * time.Duration(i+1))})
+		}
+		return revisions, nil
 	}
 }
 
@@ -531,8 +2059,9 @@ func assertDeployerCondition(t *testing.T, kd *deployerv1.KustomizationAutoDeplo
 	}
 }
 
-func assertDeployerGatesEqual(t *testing.T, deployer *deployerv1.KustomizationAutoDeployer, want deployerv1.GatesStatus) {
-	if diff := cmp.Diff(want, deployer.Status.Gates); diff != "" {
+func assertDeployerGatesEqual(t *testing.T, deployer *deployerv1.KustomizationAutoDeployer, want []deployerv1.GateStatus) {
+	t.Helper()
+	if diff := cmp.Diff(want, deployer.Status.GateResults(), cmpopts.IgnoreFields(deployerv1.GateCheckStatus{}, "LastCheckTime", "LastTransitionTime", "NextCheckTime")); diff != "" {
 		t.Fatalf("deployer gates do not match:\n%s", diff)
 	}
 }
@@ -540,3 +2069,17 @@ func assertDeployerGatesEqual(t *testing.T, deployer *deployerv1.KustomizationAu
 func reload(t *testing.T, k8sClient client.Client, obj client.Object) {
 	test.AssertNoError(t, k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj))
 }
+
+func testGateRegistry() *gates.Registry {
+	registry, err := gates.NewRegistry(
+		healthcheck.Definition(healthcheck.Factory(http.DefaultClient)),
+		scheduled.Definition(scheduled.Factory),
+		ratelimit.Definition(ratelimit.Factory),
+		soaktime.Definition(soaktime.Factory),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return registry
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+)
+
+var (
+	commitsBehind = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kustomization_auto_deployer_commits_behind",
+			Help: "Number of commits between the commit applied by the Kustomization and the HEAD of the branch.",
+		},
+		[]string{"namespace", "name"},
+	)
+
+	gateOpen = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kustomization_auto_deployer_gate_open",
+			Help: "State of each gate check, 1 if the check is open and 0 if closed.",
+		},
+		[]string{"namespace", "name", "gate", "check"},
+	)
+
+	gitListDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "kustomization_auto_deployer_git_list_duration_seconds",
+			Help:    "Duration of listing the commits in a git repository.",
+			Buckets: prometheus.DefBuckets,
+		},
+	)
+
+	gitListFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "kustomization_auto_deployer_git_list_failures_total",
+			Help: "Number of failures listing the commits in a git repository.",
+		},
+	)
+
+	commitDeployDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "kustomization_auto_deployer_commit_deploy_duration_seconds",
+			Help:    "Time between advancing the GitRepository to a commit and the Kustomization applying it.",
+			Buckets: prometheus.ExponentialBuckets(5, 2, 12),
+		},
+		[]string{"namespace", "name"},
+	)
+
+	advancesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kustomization_auto_deployer_advances_total",
+			Help: "Number of times the GitRepository has been advanced to the next commit.",
+		},
+		[]string{"namespace", "name"},
+	)
+
+	leadTime = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "kustomization_auto_deployer_lead_time_seconds",
+			Help:    "Time between a commit being made and the Kustomization applying it.",
+			Buckets: prometheus.ExponentialBuckets(60, 2, 14),
+		},
+		[]string{"namespace", "name"},
+	)
+
+	timeToRestore = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "kustomization_auto_deployer_time_to_restore_seconds",
+			Help:    "Time between a failed deployment and the next successful deployment.",
+			Buckets: prometheus.ExponentialBuckets(60, 2, 14),
+		},
+		[]string{"namespace", "name"},
+	)
+
+	deploymentsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kustomization_auto_deployer_deployments_total",
+			Help: "Number of deployments observed, by result.",
+		},
+		[]string{"namespace", "name", "result"},
+	)
+
+	deploymentFrequency = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kustomization_auto_deployer_deployments_per_day",
+			Help: "Mean number of successful deployments per day over the recent deployments.",
+		},
+		[]string{"namespace", "name"},
+	)
+
+	changeFailureRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kustomization_auto_deployer_change_failure_ratio",
+			Help: "Ratio of the recent deployments that failed.",
+		},
+		[]string{"namespace", "name"},
+	)
+
+	rollbacksTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kustomization_auto_deployer_rollbacks_total",
+			Help: "Number of times the GitRepository has been pinned to a commit.",
+		},
+		[]string{"namespace", "name"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		commitsBehind,
+		gateOpen,
+		gitListDuration,
+		gitListFailures,
+		commitDeployDuration,
+		advancesTotal,
+		rollbacksTotal,
+		leadTime,
+		timeToRestore,
+		deploymentsTotal,
+		deploymentFrequency,
+		changeFailureRatio,
+	)
+}
+
+// recordGateMetrics records the state of each of the gate checks for the
+// deployer.
+func recordGateMetrics(deployer *deployerv1.KustomizationAutoDeployer, gatesStatus []deployerv1.GateStatus) {
+	gateOpen.DeletePartialMatch(deployerLabels(deployer))
+	for _, gate := range gatesStatus {
+		for _, check := range gate.Checks {
+			value := 0.0
+			if check.Open {
+				value = 1.0
+			}
+			gateOpen.WithLabelValues(deployer.GetNamespace(), deployer.GetName(), gate.Name, check.Name).Set(value)
+		}
+	}
+}
+
+// deleteDeployerMetrics removes the per-deployer metrics when a deployer is
+// deleted.
+func deleteDeployerMetrics(deployer *deployerv1.KustomizationAutoDeployer) {
+	labels := deployerLabels(deployer)
+	commitsBehind.DeletePartialMatch(labels)
+	gateOpen.DeletePartialMatch(labels)
+	commitDeployDuration.DeletePartialMatch(labels)
+	advancesTotal.DeletePartialMatch(labels)
+	rollbacksTotal.DeletePartialMatch(labels)
+	leadTime.DeletePartialMatch(labels)
+	timeToRestore.DeletePartialMatch(labels)
+	deploymentsTotal.DeletePartialMatch(labels)
+	deploymentFrequency.DeletePartialMatch(labels)
+	changeFailureRatio.DeletePartialMatch(labels)
+	gates.DeleteMetrics(deployer)
+}
+
+func deployerLabels(deployer *deployerv1.KustomizationAutoDeployer) prometheus.Labels {
+	return prometheus.Labels{"namespace": deployer.GetNamespace(), "name": deployer.GetName()}
+}
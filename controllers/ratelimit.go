@@ -0,0 +1,46 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+// recordAdvancedTime adds the time that the GitRepository was advanced to the
+// AdvancedTimes in the status.
+//
+// Only the times that a RateLimit gate could count are kept, the most recent
+// MaxRateLimit times within the MaxRateLimitWindow.
+func recordAdvancedTime(status *deployerv1.KustomizationAutoDeployerStatus, now time.Time) {
+	windowStart := now.Add(-deployerv1.MaxRateLimitWindow)
+	advanced := []metav1.Time{}
+	for _, t := range status.AdvancedTimes {
+		if t.Time.After(windowStart) {
+			advanced = append(advanced, t)
+		}
+	}
+	advanced = append(advanced, metav1.NewTime(now))
+	if n := len(advanced); n > deployerv1.MaxRateLimit {
+		advanced = advanced[n-deployerv1.MaxRateLimit:]
+	}
+
+	status.AdvancedTimes = advanced
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+func TestRecordAdvancedTime(t *testing.T) {
+	now := time.Date(2023, time.May, 14, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) metav1.Time {
+		return metav1.NewTime(now.Add(-d))
+	}
+
+	recordTests := []struct {
+		name     string
+		advanced []metav1.Time
+		want     []metav1.Time
+	}{
+		{
+			name: "first advance",
+			want: []metav1.Time{at(0)},
+		},
+		{
+			name:     "recent advances are kept",
+			advanced: []metav1.Time{at(time.Hour * 2), at(time.Hour)},
+			want:     []metav1.Time{at(time.Hour * 2), at(time.Hour), at(0)},
+		},
+		{
+			name:     "advances outside the longest window are removed",
+			advanced: []metav1.Time{at(deployerv1.MaxRateLimitWindow + time.Hour), at(deployerv1.MaxRateLimitWindow), at(time.Hour)},
+			want:     []metav1.Time{at(time.Hour), at(0)},
+		},
+		{
+			name: "at most the largest limit of advances are kept",
+			advanced: func() []metav1.Time {
+				res := []metav1.Time{}
+				for i := deployerv1.MaxRateLimit; i > 0; i-- {
+					res = append(res, at(time.Minute*time.Duration(i)))
+				}
+				return res
+			}(),
+			want: func() []metav1.Time {
+				res := []metav1.Time{}
+				for i := deployerv1.MaxRateLimit - 1; i >= 0; i-- {
+					res = append(res, at(time.Minute*time.Duration(i)))
+				}
+				return res
+			}(),
+		},
+	}
+
+	for _, tt := range recordTests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &deployerv1.KustomizationAutoDeployerStatus{AdvancedTimes: tt.advanced}
+
+			recordAdvancedTime(status, now)
+
+			if diff := cmp.Diff(tt.want, status.AdvancedTimes); diff != "" {
+				t.Fatalf("failed to record the advanced time:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+// deployerSelection matches the namespace and deployer selectors of cluster
+// resources e.g. DeploymentPolicies against a deployer.
+//
+// The Namespace of the deployer is only fetched if a namespace selector needs
+// it, and is fetched at most once.
+type deployerSelection struct {
+	client    client.Client
+	deployer  *deployerv1.KustomizationAutoDeployer
+	namespace *corev1.Namespace
+}
+
+// matches returns true if the deployer is selected by both selectors.
+func (s *deployerSelection) matches(ctx context.Context, namespaceSelector, deployerSelector *metav1.LabelSelector) (bool, error) {
+	matches, err := selectorMatches(deployerSelector, s.deployer.GetLabels())
+	if err != nil {
+		return false, fmt.Errorf("invalid deployerSelector: %w", err)
+	}
+	if !matches || emptySelector(namespaceSelector) {
+		return matches, nil
+	}
+
+	if s.namespace == nil {
+		namespace := &corev1.Namespace{}
+		if err := s.client.Get(ctx, client.ObjectKey{Name: s.deployer.GetNamespace()}, namespace); err != nil {
+			return false, fmt.Errorf("failed to get Namespace %s: %w", s.deployer.GetNamespace(), err)
+		}
+		s.namespace = namespace
+	}
+
+	matches, err = selectorMatches(namespaceSelector, s.namespace.GetLabels())
+	if err != nil {
+		return false, fmt.Errorf("invalid namespaceSelector: %w", err)
+	}
+
+	return matches, nil
+}
+
+func emptySelector(selector *metav1.LabelSelector) bool {
+	return selector == nil || (len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0)
+}
+
+// selectorMatches returns true if the labels match the selector, an empty or
+// nil selector matches everything.
+func selectorMatches(selector *metav1.LabelSelector, l map[string]string) (bool, error) {
+	if emptySelector(selector) {
+		return true, nil
+	}
+
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false, err
+	}
+
+	return s.Matches(labels.Set(l)), nil
+}
@@ -17,15 +17,18 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"net/http"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/fluxcd/pkg/runtime/events"
 	"github.com/fluxcd/pkg/runtime/pprof"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -37,11 +40,18 @@ import (
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	fluxv1alpha1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	fluxv1beta1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/callback"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/external"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/healthcheck"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/ratelimit"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/scheduled"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/soaktime"
 	"github.com/gitops-tools/kustomization-auto-deployer/pkg/git"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/tracing"
+	"github.com/gitops-tools/kustomization-auto-deployer/webhooks"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -56,6 +66,7 @@ func init() {
 	utilruntime.Must(sourcev1.AddToScheme(scheme))
 	utilruntime.Must(kustomizev1.AddToScheme(scheme))
 	utilruntime.Must(fluxv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(fluxv1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -63,8 +74,24 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var eventsAddr string
+	var callbackAddr string
+	var callbackURL string
+	var noCrossNamespaceRefs bool
+	var concurrencyGroupNamespace string
+	var concurrencyLeaseDuration time.Duration
+	var tracingOptions tracing.Options
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&eventsAddr, "events-addr", "", "The address of the Flux notification-controller events endpoint.")
+	flag.StringVar(&callbackAddr, "callback-bind-address", "", "The address the callback endpoint for Callback gates binds to, Callback gates are disabled if this is not set.")
+	flag.StringVar(&callbackURL, "callback-url", "", "The external URL of the callback endpoint, this is sent to systems that are asked for a callback.")
+	flag.BoolVar(&noCrossNamespaceRefs, "no-cross-namespace-refs", false, "Prevent deployers from referencing Kustomizations in other namespaces.")
+	flag.StringVar(&concurrencyGroupNamespace, "concurrency-group-namespace", "", "The namespace of the Leases for concurrency groups, groups are shared by all namespaces if this is set, otherwise each namespace has its own groups.")
+	flag.DurationVar(&concurrencyLeaseDuration, "concurrency-lease-duration", 0, "How long a deployer can hold the Lease for its concurrency group before another deployer in the group can take it, if this is not set the Lease is held until the holder's Kustomization applies or fails to apply the commit.")
+	flag.StringVar(&tracingOptions.Endpoint, "otlp-endpoint", "", "The host:port of the OTLP gRPC collector to export traces to.")
+	flag.BoolVar(&tracingOptions.Insecure, "otlp-insecure", false, "Disable TLS when exporting traces to the OTLP collector.")
+	flag.Float64Var(&tracingOptions.SampleRatio, "otlp-sample-ratio", 1.0, "The fraction of traces to sample.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -76,6 +103,19 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	ctx := ctrl.SetupSignalHandler()
+	tracingOptions.ServiceName = "kustomization-auto-deployer"
+	shutdownTracing, err := tracing.Setup(ctx, tracingOptions)
+	if err != nil {
+		setupLog.Error(err, "unable to configure tracing")
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			setupLog.Error(err, "problem shutting down tracing")
+		}
+	}()
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
@@ -102,18 +142,78 @@ func main() {
 		os.Exit(1)
 	}
 
+	eventRecorder, err := events.NewRecorder(mgr, ctrl.Log, eventsAddr, "kustomization-auto-deployer")
+	if err != nil {
+		setupLog.Error(err, "unable to create event recorder")
+		os.Exit(1)
+	}
+
+	var callbackFactory gates.GateFactory
+	if callbackAddr != "" {
+		if err := mgr.Add(&callback.Server{
+			Addr:    callbackAddr,
+			Handler: callback.Handler(mgr.GetClient(), ctrl.Log.WithName("callbacks")),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up callback server")
+			os.Exit(1)
+		}
+		callbackFactory = callback.Factory(http.DefaultClient, callbackURL)
+	}
+
+	gateRegistry, err := gates.NewRegistry(
+		healthcheck.Definition(healthcheck.Factory(http.DefaultClient)),
+		scheduled.Definition(scheduled.Factory),
+		external.Definition(external.Factory(http.DefaultClient)),
+		callback.Definition(callbackFactory),
+		ratelimit.Definition(ratelimit.Factory),
+		soaktime.Definition(soaktime.Factory),
+	)
+	if err != nil {
+		setupLog.Error(err, "unable to create gate registry")
+		os.Exit(1)
+	}
+
 	if err = (&controllers.KustomizationAutoDeployerReconciler{
 		Client:         mgr.GetClient(),
 		Scheme:         mgr.GetScheme(),
+		EventRecorder:  eventRecorder,
 		RevisionLister: git.ListRevisionsInRepository,
-		GateFactories: map[string]gates.GateFactory{
-			"HealthCheck": healthcheck.Factory(http.DefaultClient),
-			"Scheduled":   scheduled.Factory,
-		},
+		Gates:          gateRegistry,
+
+		NoCrossNamespaceRefs:      noCrossNamespaceRefs,
+		ConcurrencyGroupNamespace: concurrencyGroupNamespace,
+		ConcurrencyLeaseDuration:  concurrencyLeaseDuration,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KustomizationAutoDeployer")
 		os.Exit(1)
 	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&webhooks.KustomizationAutoDeployerWebhook{
+			Gates: gateRegistry,
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "KustomizationAutoDeployer")
+			os.Exit(1)
+		}
+		if err = (&webhooks.DeploymentPolicyWebhook{
+			Gates: gateRegistry,
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "DeploymentPolicy")
+			os.Exit(1)
+		}
+		if err = (&webhooks.GateTemplateWebhook{
+			Gates: gateRegistry,
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "GateTemplate")
+			os.Exit(1)
+		}
+	}
+	if err = (&controllers.DeploymentPipelineReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DeploymentPipeline")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -126,7 +226,7 @@ func main() {
 	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
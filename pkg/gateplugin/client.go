@@ -0,0 +1,84 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateplugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// maxErrorBody limits the amount of an error response that is included in
+// errors.
+const maxErrorBody = 1024
+
+// Client sends checks to gate servers.
+type Client struct {
+	HTTPClient *http.Client
+}
+
+// NewClient creates and returns a new Client.
+func NewClient(httpClient *http.Client) *Client {
+	return &Client{HTTPClient: httpClient}
+}
+
+// Check sends the CheckRequest to the gate server at the URL and returns the
+// response.
+//
+// The APIVersion is set on the request before sending.
+func (c *Client) Check(ctx context.Context, url string, checkReq CheckRequest) (CheckResponse, error) {
+	checkReq.APIVersion = APIVersion
+	b, err := json.Marshal(checkReq)
+	if err != nil {
+		return CheckResponse{}, fmt.Errorf("failed to marshal check request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return CheckResponse{}, fmt.Errorf("failed to create check request for %s: %w", url, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return CheckResponse{}, fmt.Errorf("failed to send check request to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return CheckResponse{}, fmt.Errorf("gate server %s returned %s: %s", url, resp.Status, bytes.TrimSpace(body))
+	}
+
+	var checkResp CheckResponse
+	if err := json.NewDecoder(resp.Body).Decode(&checkResp); err != nil {
+		return CheckResponse{}, fmt.Errorf("failed to decode check response from %s: %w", url, err)
+	}
+	if checkResp.RequeueAfterSeconds < 0 {
+		return CheckResponse{}, fmt.Errorf("gate server %s returned negative requeueAfterSeconds %d", url, checkResp.RequeueAfterSeconds)
+	}
+
+	return checkResp, nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateplugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	"github.com/gitops-tools/kustomization-auto-deployer/test"
+)
+
+func TestClient_Check(t *testing.T) {
+	var received CheckRequest
+	ts := httptest.NewServer(Handler(func(ctx context.Context, req CheckRequest) (CheckResponse, error) {
+		received = req
+
+		return CheckResponse{Open: true, Message: "approved", RequeueAfterSeconds: 60}, nil
+	}))
+	defer ts.Close()
+
+	req := CheckRequest{
+		Gate:      &deployerv1.KustomizationGate{Name: "testing"},
+		Candidate: Commit{ID: "6f935147b28e38a99a700843e4893a801c3c8148"},
+	}
+	resp, err := NewClient(ts.Client()).Check(context.TODO(), ts.URL, req)
+	test.AssertNoError(t, err)
+
+	if diff := cmp.Diff(CheckResponse{Open: true, Message: "approved", RequeueAfterSeconds: 60}, resp); diff != "" {
+		t.Errorf("failed to decode the response:\n%s", diff)
+	}
+
+	req.APIVersion = APIVersion
+	if diff := cmp.Diff(req, received); diff != "" {
+		t.Errorf("failed to send the request:\n%s", diff)
+	}
+}
+
+func TestClient_Check_errors(t *testing.T) {
+	errorTests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr string
+	}{
+		{
+			name: "error response",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "ticket system unavailable", http.StatusServiceUnavailable)
+			},
+			wantErr: "returned 503 Service Unavailable: ticket system unavailable",
+		},
+		{
+			name: "invalid JSON",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"open":`))
+			},
+			wantErr: "failed to decode check response",
+		},
+		{
+			name: "negative requeue",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"open":true,"requeueAfterSeconds":-10}`))
+			},
+			wantErr: "negative requeueAfterSeconds -10",
+		},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(tt.handler)
+			defer ts.Close()
+
+			_, err := NewClient(ts.Client()).Check(context.TODO(), ts.URL, CheckRequest{})
+
+			test.AssertErrorMatch(t, tt.wantErr, err)
+		})
+	}
+}
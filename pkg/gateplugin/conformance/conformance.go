@@ -0,0 +1,196 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance is a test kit for gate servers that implement the gate
+// plugin protocol.
+//
+// Gate server implementations can run the conformance tests from their own
+// tests:
+//
+//	func TestConformance(t *testing.T) {
+//		ts := httptest.NewServer(newGateServer())
+//		defer ts.Close()
+//
+//		conformance.Run(t, ts.URL, conformance.NewCheckRequest())
+//	}
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/gateplugin"
+)
+
+// NewCheckRequest returns a CheckRequest that can be used to test gate
+// servers.
+//
+// The request can be modified by the provided functions e.g. to add
+// parameters that the gate server requires.
+func NewCheckRequest(opts ...func(*gateplugin.CheckRequest)) gateplugin.CheckRequest {
+	req := gateplugin.CheckRequest{
+		APIVersion: gateplugin.APIVersion,
+		Deployer: &deployerv1.KustomizationAutoDeployer{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: deployerv1.GroupVersion.String(),
+				Kind:       "KustomizationAutoDeployer",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-deployer",
+				Namespace: "default",
+			},
+		},
+		Gate: &deployerv1.KustomizationGate{
+			Name:     "conformance",
+			External: &deployerv1.ExternalCheck{URL: "http://gate-server.example.com/check"},
+		},
+		Candidate: gateplugin.Commit{
+			ID:      "6f935147b28e38a99a700843e4893a801c3c8148",
+			Time:    time.Now().Add(-time.Hour).UTC().Truncate(time.Second),
+			Author:  "Test User <test@example.com>",
+			Message: "Add a new feature",
+		},
+		Current: gateplugin.Commit{
+			ID:      "dd587268153ad335545a53f15efee4ecfabcd1c8",
+			Time:    time.Now().Add(-time.Hour * 2).UTC().Truncate(time.Second),
+			Author:  "Test User <test@example.com>",
+			Message: "Initial commit",
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&req)
+	}
+
+	return req
+}
+
+// Run runs the conformance tests against the gate server at the URL.
+//
+// The CheckRequest must be a request that the gate server accepts.
+func Run(t *testing.T, url string, checkReq gateplugin.CheckRequest) {
+	t.Helper()
+	checkReq.APIVersion = gateplugin.APIVersion
+
+	t.Run("responds to a check", func(t *testing.T) {
+		resp := post(t, url, marshal(t, checkReq))
+		assertStatusCode(t, resp, http.StatusOK)
+		assertJSONResponse(t, resp)
+
+		var checkResp gateplugin.CheckResponse
+		if err := json.Unmarshal(resp.body, &checkResp); err != nil {
+			t.Fatalf("failed to decode the check response %q: %s", resp.body, err)
+		}
+		if checkResp.Message == "" {
+			t.Error("check response has no message")
+		}
+		if checkResp.RequeueAfterSeconds < 0 {
+			t.Errorf("check response has negative requeueAfterSeconds %d", checkResp.RequeueAfterSeconds)
+		}
+	})
+
+	t.Run("ignores unknown fields", func(t *testing.T) {
+		var raw map[string]any
+		if err := json.Unmarshal(marshal(t, checkReq), &raw); err != nil {
+			t.Fatal(err)
+		}
+		raw["unknownField"] = map[string]any{"from": "a newer controller"}
+
+		resp := post(t, url, marshal(t, raw))
+		assertStatusCode(t, resp, http.StatusOK)
+		assertJSONResponse(t, resp)
+	})
+
+	t.Run("rejects methods other than POST", func(t *testing.T) {
+		httpResp, err := http.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer httpResp.Body.Close()
+
+		if httpResp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("got status code %d, want %d", httpResp.StatusCode, http.StatusMethodNotAllowed)
+		}
+	})
+
+	t.Run("rejects malformed requests", func(t *testing.T) {
+		resp := post(t, url, []byte(`{"apiVersion":`))
+		assertStatusCode(t, resp, http.StatusBadRequest)
+	})
+
+	t.Run("rejects unsupported apiVersions", func(t *testing.T) {
+		unsupported := checkReq
+		unsupported.APIVersion = "gates.flux.gitops.pro/v0"
+
+		resp := post(t, url, marshal(t, unsupported))
+		assertStatusCode(t, resp, http.StatusBadRequest)
+	})
+}
+
+type response struct {
+	statusCode  int
+	contentType string
+	body        []byte
+}
+
+func post(t *testing.T, url string, body []byte) response {
+	t.Helper()
+	httpResp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to POST to the gate server: %s", err)
+	}
+	defer httpResp.Body.Close()
+
+	b, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		t.Fatalf("failed to read the response: %s", err)
+	}
+
+	return response{statusCode: httpResp.StatusCode, contentType: httpResp.Header.Get("Content-Type"), body: b}
+}
+
+func marshal(t *testing.T, v any) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func assertStatusCode(t *testing.T, resp response, want int) {
+	t.Helper()
+	if resp.statusCode != want {
+		t.Fatalf("got status code %d, want %d: %s", resp.statusCode, want, resp.body)
+	}
+}
+
+func assertJSONResponse(t *testing.T, resp response) {
+	t.Helper()
+	mediaType, _, err := mime.ParseMediaType(resp.contentType)
+	if err != nil || mediaType != "application/json" {
+		t.Errorf("got Content-Type %q, want application/json", resp.contentType)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateplugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// maxRequestBody limits the size of check requests accepted by the Handler.
+const maxRequestBody = 1 << 20
+
+// CheckFunc implements a gate in a gate server.
+//
+// Errors are returned to the controller as an error response, and the check is
+// recorded as failed.
+type CheckFunc func(context.Context, CheckRequest) (CheckResponse, error)
+
+// Handler returns an http.Handler that decodes CheckRequests, and responds
+// with the result of calling the CheckFunc.
+func Handler(check CheckFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+
+		var checkReq CheckRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&checkReq); err != nil {
+			http.Error(w, fmt.Sprintf("failed to decode check request: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		if checkReq.APIVersion != APIVersion {
+			http.Error(w, fmt.Sprintf("unsupported apiVersion %q, want %q", checkReq.APIVersion, APIVersion), http.StatusBadRequest)
+			return
+		}
+
+		if checkReq.Gate == nil {
+			http.Error(w, "check request has no gate", http.StatusBadRequest)
+			return
+		}
+
+		checkResp, err := check(r.Context(), checkReq)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, err := json.Marshal(checkResp)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode check response: %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b)
+	})
+}
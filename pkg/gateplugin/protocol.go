@@ -0,0 +1,85 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gateplugin implements the protocol between the controller and
+// out-of-process gate servers.
+//
+// The controller POSTs a CheckRequest as JSON to the gate server, and the gate
+// server responds with a CheckResponse.
+package gateplugin
+
+import (
+	"time"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+)
+
+// APIVersion is the version of the protocol sent in each CheckRequest.
+const APIVersion = "gates.flux.gitops.pro/v1alpha1"
+
+// CheckRequest is sent to the gate server to check the gate.
+type CheckRequest struct {
+	// APIVersion is the version of the protocol.
+	APIVersion string `json:"apiVersion"`
+
+	// Deployer is the KustomizationAutoDeployer that is being reconciled, this
+	// is the v1alpha1 representation of the deployer.
+	Deployer *deployerv1.KustomizationAutoDeployer `json:"deployer"`
+
+	// Gate is the configuration of the gate being checked.
+	Gate *deployerv1.KustomizationGate `json:"gate"`
+
+	// Candidate is the commit that will be deployed if the gates are open.
+	Candidate Commit `json:"candidate"`
+
+	// Current is the commit that is currently deployed.
+	Current Commit `json:"current"`
+}
+
+// Commit is a commit in the repository.
+type Commit struct {
+	// ID is the commit hash.
+	ID string `json:"id"`
+
+	// Time is when the commit was committed.
+	Time time.Time `json:"time"`
+
+	// Author is the name and email of the author of the commit.
+	Author string `json:"author,omitempty"`
+
+	// Message is the commit message.
+	Message string `json:"message,omitempty"`
+}
+
+// CheckResponse is returned by the gate server.
+type CheckResponse struct {
+	// Open is true if the gate is open.
+	Open bool `json:"open"`
+
+	// Message is a human-readable explanation of the result.
+	Message string `json:"message,omitempty"`
+
+	// RequeueAfterSeconds is the number of seconds after which the gate
+	// should be checked again.
+	//
+	// Zero indicates that the gate server has no preference.
+	RequeueAfterSeconds int64 `json:"requeueAfterSeconds,omitempty"`
+}
+
+// RequeueAfter returns the suggested requeue interval as a time.Duration.
+func (r CheckResponse) RequeueAfter() time.Duration {
+	return time.Duration(r.RequeueAfterSeconds) * time.Second
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package reference is a reference implementation of a gate server.
+//
+// The gate is closed if the candidate commit message contains the hold
+// pattern, or if the candidate commit is younger than the minimum commit age.
+package reference
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/gateplugin"
+)
+
+const (
+	// HoldPatternParameter is the parameter that configures the text that
+	// holds a commit when it appears in the commit message.
+	HoldPatternParameter = "holdPattern"
+
+	// MinCommitAgeParameter is the parameter that configures the minimum age
+	// of a commit before it can be deployed, e.g. "30m".
+	MinCommitAgeParameter = "minCommitAge"
+
+	// DefaultHoldPattern is used when no hold pattern is configured.
+	DefaultHoldPattern = "[hold]"
+)
+
+// Gate is the reference gate.
+type Gate struct {
+	Clock func() time.Time
+}
+
+// New creates and returns a new reference Gate.
+func New() *Gate {
+	return &Gate{Clock: time.Now}
+}
+
+// Check implements gateplugin.CheckFunc.
+func (g *Gate) Check(ctx context.Context, req gateplugin.CheckRequest) (gateplugin.CheckResponse, error) {
+	var params map[string]string
+	if req.Gate.External != nil {
+		params = req.Gate.External.Parameters
+	}
+
+	holdPattern := DefaultHoldPattern
+	if v, ok := params[HoldPatternParameter]; ok && v != "" {
+		holdPattern = v
+	}
+	if strings.Contains(req.Candidate.Message, holdPattern) {
+		return gateplugin.CheckResponse{Open: false, Message: fmt.Sprintf("commit %s is held by %q", req.Candidate.ID, holdPattern)}, nil
+	}
+
+	if v, ok := params[MinCommitAgeParameter]; ok {
+		minAge, err := time.ParseDuration(v)
+		if err != nil {
+			return gateplugin.CheckResponse{}, fmt.Errorf("failed to parse %s %q: %w", MinCommitAgeParameter, v, err)
+		}
+
+		if age := g.Clock().Sub(req.Candidate.Time); age < minAge {
+			return gateplugin.CheckResponse{
+				Open:                false,
+				Message:             fmt.Sprintf("commit %s is younger than %s", req.Candidate.ID, minAge),
+				RequeueAfterSeconds: int64(math.Ceil((minAge - age).Seconds())),
+			}, nil
+		}
+	}
+
+	return gateplugin.CheckResponse{Open: true, Message: fmt.Sprintf("commit %s can be deployed", req.Candidate.ID)}, nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/gateplugin"
+	"github.com/gitops-tools/kustomization-auto-deployer/pkg/gateplugin/conformance"
+	"github.com/gitops-tools/kustomization-auto-deployer/test"
+)
+
+func TestGate_Check(t *testing.T) {
+	now := time.Date(2023, time.May, 14, 9, 0, 0, 0, time.UTC)
+
+	checkTests := []struct {
+		name       string
+		parameters map[string]string
+		message    string
+		want       gateplugin.CheckResponse
+	}{
+		{
+			name:    "no parameters",
+			message: "Add a new feature",
+			want:    gateplugin.CheckResponse{Open: true, Message: "commit abc123 can be deployed"},
+		},
+		{
+			name:    "default hold pattern",
+			message: "Add a new feature [hold]",
+			want:    gateplugin.CheckResponse{Open: false, Message: `commit abc123 is held by "[hold]"`},
+		},
+		{
+			name:       "configured hold pattern",
+			parameters: map[string]string{HoldPatternParameter: "DO NOT DEPLOY"},
+			message:    "Add a new feature\n\nDO NOT DEPLOY",
+			want:       gateplugin.CheckResponse{Open: false, Message: `commit abc123 is held by "DO NOT DEPLOY"`},
+		},
+		{
+			name:       "commit younger than the minimum age",
+			parameters: map[string]string{MinCommitAgeParameter: "90m"},
+			message:    "Add a new feature",
+			want:       gateplugin.CheckResponse{Open: false, Message: "commit abc123 is younger than 1h30m0s", RequeueAfterSeconds: 1800},
+		},
+		{
+			name:       "commit older than the minimum age",
+			parameters: map[string]string{MinCommitAgeParameter: "30m"},
+			message:    "Add a new feature",
+			want:       gateplugin.CheckResponse{Open: true, Message: "commit abc123 can be deployed"},
+		},
+	}
+
+	for _, tt := range checkTests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New()
+			g.Clock = func() time.Time { return now }
+
+			got, err := g.Check(context.TODO(), gateplugin.CheckRequest{
+				Gate: &deployerv1.KustomizationGate{
+					Name:     "testing",
+					External: &deployerv1.ExternalCheck{URL: "http://example.com/", Parameters: tt.parameters},
+				},
+				Candidate: gateplugin.Commit{ID: "abc123", Time: now.Add(-time.Hour), Message: tt.message},
+			})
+			test.AssertNoError(t, err)
+
+			if got != tt.want {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGate_Check_errors(t *testing.T) {
+	_, err := New().Check(context.TODO(), gateplugin.CheckRequest{
+		Gate: &deployerv1.KustomizationGate{
+			Name:     "testing",
+			External: &deployerv1.ExternalCheck{URL: "http://example.com/", Parameters: map[string]string{MinCommitAgeParameter: "a while"}},
+		},
+	})
+
+	test.AssertErrorMatch(t, `failed to parse minCommitAge "a while"`, err)
+}
+
+func TestConformance(t *testing.T) {
+	ts := httptest.NewServer(gateplugin.Handler(New().Check))
+	defer ts.Close()
+
+	conformance.Run(t, ts.URL, conformance.NewCheckRequest())
+}
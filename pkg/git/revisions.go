@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
@@ -17,11 +18,23 @@ type ListOptions struct {
 	MaxCommits int
 }
 
+// Revision is a commit in the repository.
+type Revision struct {
+	// ID is the commit hash.
+	ID string
+	// Time is when the commit was committed.
+	Time time.Time
+	// Author is the name and email of the author of the commit.
+	Author string
+	// Message is the commit message.
+	Message string
+}
+
 // ListRevisionsInRepository lists the revisions in the repository.
 //
 // It will clone the repository to a configurable depth and list all revisions
-// in the clone.
-func ListRevisionsInRepository(ctx context.Context, url string, options ListOptions) (result []string, listErr error) {
+// in the clone, newest first.
+func ListRevisionsInRepository(ctx context.Context, url string, options ListOptions) (result []Revision, listErr error) {
 	dir, err := ioutil.TempDir(os.TempDir(), "tracker")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create tempdir opening repository %s: %w", url, err)
@@ -57,9 +70,14 @@ func ListRevisionsInRepository(ctx context.Context, url string, options ListOpti
 		return nil, fmt.Errorf("failed to list commits for HEAD %s: %w", url, err)
 	}
 
-	commits := []string{}
+	commits := []Revision{}
 	err = commitIter.ForEach(func(c *object.Commit) error {
-		commits = append(commits, c.Hash.String())
+		commits = append(commits, Revision{
+			ID:      c.Hash.String(),
+			Time:    c.Committer.When,
+			Author:  c.Author.String(),
+			Message: c.Message,
+		})
 
 		return nil
 	})
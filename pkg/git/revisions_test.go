@@ -20,7 +20,20 @@ func TestListRevisions(t *testing.T) {
 	test.AssertNoError(t, err)
 
 	want := []string{commit2, commit1, head}
-	if diff := cmp.Diff(want, revisions); diff != "" {
+	ids := []string{}
+	for _, revision := range revisions {
+		ids = append(ids, revision.ID)
+		if revision.Time.IsZero() {
+			t.Errorf("revision %s has no commit time", revision.ID)
+		}
+		if revision.Author != "test user <testing@example.com>" {
+			t.Errorf("revision %s got author %q", revision.ID, revision.Author)
+		}
+		if revision.Message == "" {
+			t.Errorf("revision %s has no commit message", revision.ID)
+		}
+	}
+	if diff := cmp.Diff(want, ids); diff != "" {
 		t.Fatalf("failed to generate revisions:\n%s", diff)
 	}
 }
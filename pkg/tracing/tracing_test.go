@@ -0,0 +1,55 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/gitops-tools/kustomization-auto-deployer/test"
+)
+
+func TestSetup(t *testing.T) {
+	original := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(original) })
+
+	shutdown, err := Setup(context.TODO(), Options{Endpoint: "localhost:4317", Insecure: true, SampleRatio: 1, ServiceName: "testing"})
+	test.AssertNoError(t, err)
+
+	if _, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider); !ok {
+		t.Errorf("got TracerProvider %T, want an SDK TracerProvider", otel.GetTracerProvider())
+	}
+
+	test.AssertNoError(t, shutdown(context.TODO()))
+}
+
+func TestSetup_no_endpoint(t *testing.T) {
+	original := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(original) })
+
+	shutdown, err := Setup(context.TODO(), Options{})
+	test.AssertNoError(t, err)
+
+	if _, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider); ok {
+		t.Error("configured an SDK TracerProvider without an endpoint")
+	}
+
+	test.AssertNoError(t, shutdown(context.TODO()))
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/fluxcd/pkg/apis/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
 )
 
 // NewKustomizationAutoDeployer creates and returns a new KustomizationDeployer.
@@ -19,7 +19,7 @@ func NewKustomizationAutoDeployer(opts ...func(*deployerv1.KustomizationAutoDepl
 		Spec: deployerv1.KustomizationAutoDeployerSpec{
 			CommitLimit: 10,
 			Interval:    metav1.Duration{Duration: time.Minute * 3},
-			KustomizationRef: meta.LocalObjectReference{
+			KustomizationRef: meta.NamespacedObjectReference{
 				Name: "test-kustomization",
 			},
 		},
@@ -0,0 +1,38 @@
+package test
+
+import (
+	"github.com/fluxcd/pkg/apis/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+// NewDeploymentPipeline creates and returns a new DeploymentPipeline.
+//
+// The default pipeline has two stages, "dev" and "production".
+func NewDeploymentPipeline(opts ...func(*deployerv1.DeploymentPipeline)) *deployerv1.DeploymentPipeline {
+	p := &deployerv1.DeploymentPipeline{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "demo-pipeline",
+			Namespace: DefaultNamespace,
+		},
+		Spec: deployerv1.DeploymentPipelineSpec{
+			Stages: []deployerv1.PipelineStage{
+				{
+					Name:        "dev",
+					DeployerRef: meta.LocalObjectReference{Name: "dev-deployer"},
+				},
+				{
+					Name:        "production",
+					DeployerRef: meta.LocalObjectReference{Name: "prod-deployer"},
+				},
+			},
+		},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
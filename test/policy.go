@@ -0,0 +1,36 @@
+package test
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+)
+
+// NewDeploymentPolicy creates and returns a new DeploymentPolicy.
+//
+// The default policy selects all deployers and has a single "business hours"
+// Scheduled gate.
+func NewDeploymentPolicy(opts ...func(*deployerv1.DeploymentPolicy)) *deployerv1.DeploymentPolicy {
+	p := &deployerv1.DeploymentPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "demo-policy",
+		},
+		Spec: deployerv1.DeploymentPolicySpec{
+			Gates: []deployerv1.KustomizationGate{
+				{
+					Name: "business hours",
+					Scheduled: &deployerv1.ScheduledCheck{
+						Open:  "08:00",
+						Close: "16:00",
+					},
+				},
+			},
+		},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
@@ -16,7 +16,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
-	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
 )
 
 var kustomizationGVK = schema.GroupVersionKind{
@@ -127,7 +127,7 @@ func TestReconciling_with_gates(t *testing.T) {
 			return false
 		}
 
-		return cond.Message == "gates are currently closed"
+		return cond.Message == "gates are currently closed: accessing a test server"
 	})
 }
 
@@ -15,7 +15,7 @@ import (
 
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
-	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
 	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/healthcheck"
@@ -52,11 +52,9 @@ func TestMain(m *testing.M) {
 	if err := (&controllers.KustomizationAutoDeployerReconciler{
 		Client:         testEnv,
 		Scheme:         testEnv.GetScheme(),
+		EventRecorder:  testEnv.GetEventRecorderFor("kustomization-auto-deployer"),
 		RevisionLister: testRevisionLister(test.CommitIDs),
-		GateFactories: map[string]gates.GateFactory{
-			"HealthCheck": healthcheck.Factory(http.DefaultClient),
-			"Scheduled":   scheduled.Factory,
-		},
+		Gates:          testGateRegistry(),
 	}).SetupWithManager(testEnv); err != nil {
 		panic(fmt.Sprintf("Failed to start KustomizationAutoDeployerReconciler: %v", err))
 	}
@@ -81,10 +79,26 @@ func TestMain(m *testing.M) {
 
 // Make this an interface!
 func testRevisionLister(commitIDs []string) controllers.RevisionLister {
-	return func(ctx context.Context, url string, options git.ListOptions) ([]string, error) {
+	return func(ctx context.Context, url string, options git.ListOptions) ([]git.Revision, error) {
 		if options.MaxCommits > len(commitIDs) {
 			return nil, errors.New("not enough commit IDs to fulfill request")
 		}
-		return commitIDs, nil
+		revisions := []git.Revision{}
+		for i, commitID := range commitIDs {
+			revisions = append(revisions, git.Revision{ID: commitID, Time: time.Now().Add(-time.Hour * time.Duration(i+1))})
+		}
+		return revisions, nil
 	}
 }
+
+func testGateRegistry() *gates.Registry {
+	registry, err := gates.NewRegistry(
+		healthcheck.Definition(healthcheck.Factory(http.DefaultClient)),
+		scheduled.Definition(scheduled.Factory),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return registry
+}
@@ -0,0 +1,249 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/test"
+)
+
+// These tests check the validation in the CRD, which applies when the
+// admission webhook is not deployed.
+func TestCRDValidation(t *testing.T) {
+	validTests := []struct {
+		name  string
+		gates []deployerv1.KustomizationGate
+	}{
+		{
+			name: "one check in each gate",
+			gates: []deployerv1.KustomizationGate{
+				{Name: "business hours", Scheduled: &deployerv1.ScheduledCheck{Open: "09:00", Close: "17:00"}},
+				{Name: "health", HealthCheck: &deployerv1.HealthCheck{URL: "https://example.com/", Interval: metav1.Duration{}}},
+			},
+		},
+		{
+			name: "checks list",
+			gates: []deployerv1.KustomizationGate{
+				{
+					Name: "health",
+					Checks: []deployerv1.GateCheck{
+						{Name: "api", Kind: "HealthCheck", Config: &apiextensionsv1.JSON{Raw: []byte(`{"url":"https://api.example.com/"}`)}},
+						{Name: "ui", Kind: "HealthCheck", Config: &apiextensionsv1.JSON{Raw: []byte(`{"url":"https://ui.example.com/"}`)}},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range validTests {
+		t.Run(tt.name+" is accepted", func(t *testing.T) {
+			deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Spec.Gates = tt.gates
+			})
+			test.AssertNoError(t, testEnv.Create(context.TODO(), deployer, client.DryRunAll))
+		})
+	}
+
+	invalidTests := []struct {
+		name   string
+		update func(*deployerv1.KustomizationAutoDeployer)
+		want   string
+	}{
+		{
+			name: "gate with no checks",
+			update: func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Spec.Gates = []deployerv1.KustomizationGate{{Name: "empty"}}
+			},
+			want: "exactly one of healthCheck, scheduled, external, callback, rateLimit, soakTime, templateRef or checks must be set",
+		},
+		{
+			name: "gate with two checks",
+			update: func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Spec.Gates = []deployerv1.KustomizationGate{
+					{
+						Name:        "two",
+						HealthCheck: &deployerv1.HealthCheck{URL: "https://example.com/"},
+						Scheduled:   &deployerv1.ScheduledCheck{Open: "09:00", Close: "17:00"},
+					},
+				}
+			},
+			want: "exactly one of healthCheck, scheduled, external, callback, rateLimit, soakTime, templateRef or checks must be set",
+		},
+		{
+			name: "duplicate gate names",
+			update: func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Spec.Gates = []deployerv1.KustomizationGate{
+					{Name: "hours", Scheduled: &deployerv1.ScheduledCheck{Open: "09:00", Close: "17:00"}},
+					{Name: "hours", Scheduled: &deployerv1.ScheduledCheck{Open: "10:00", Close: "16:00"}},
+				}
+			},
+			want: "gate names must be unique",
+		},
+		{
+			name: "duplicate check names",
+			update: func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Spec.Gates = []deployerv1.KustomizationGate{
+					{
+						Name: "health",
+						Checks: []deployerv1.GateCheck{
+							{Name: "api", Kind: "HealthCheck"},
+							{Name: "api", Kind: "HealthCheck"},
+						},
+					},
+				}
+			},
+			want: "check names must be unique",
+		},
+		{
+			name: "invalid time",
+			update: func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Spec.Gates = []deployerv1.KustomizationGate{
+					{Name: "hours", Scheduled: &deployerv1.ScheduledCheck{Open: "9am", Close: "17:00"}},
+				}
+			},
+			want: `spec.gates[0].scheduled.open: Invalid value: "9am"`,
+		},
+		{
+			name: "open after close",
+			update: func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Spec.Gates = []deployerv1.KustomizationGate{
+					{Name: "hours", Scheduled: &deployerv1.ScheduledCheck{Open: "17:00", Close: "09:00"}},
+				}
+			},
+			want: "close must be after open",
+		},
+		{
+			name: "long rate limit window",
+			update: func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Spec.Gates = []deployerv1.KustomizationGate{
+					{Name: "weekly", RateLimit: &deployerv1.RateLimitCheck{Limit: 5, Window: metav1.Duration{Duration: time.Hour * 200}}},
+				}
+			},
+			want: "window must be greater than 0s and at most 168h",
+		},
+		{
+			name: "zero interval",
+			update: func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Spec.Interval = metav1.Duration{}
+			},
+			want: "interval must be at least 1s",
+		},
+		{
+			name: "short check interval",
+			update: func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Spec.Gates = []deployerv1.KustomizationGate{
+					{Name: "health", HealthCheck: &deployerv1.HealthCheck{URL: "https://example.com/", Interval: metav1.Duration{Duration: 1000000}}},
+				}
+			},
+			want: "interval must be 0s or at least 1s",
+		},
+		{
+			name: "zero gate timeout",
+			update: func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Spec.Gates = []deployerv1.KustomizationGate{
+					{Name: "hours", Timeout: &metav1.Duration{}, Scheduled: &deployerv1.ScheduledCheck{Open: "09:00", Close: "17:00"}},
+				}
+			},
+			want: "timeout must be greater than 0s",
+		},
+	}
+
+	for _, tt := range invalidTests {
+		t.Run(tt.name+" is rejected", func(t *testing.T) {
+			deployer := test.NewKustomizationAutoDeployer(tt.update)
+			err := testEnv.Create(context.TODO(), deployer, client.DryRunAll)
+			if !apierrors.IsInvalid(err) {
+				t.Fatalf("Create() got error %v, want invalid", err)
+			}
+			test.AssertErrorMatch(t, regexp.QuoteMeta(tt.want), err)
+		})
+	}
+}
+
+func TestDeploymentFreezeValidation(t *testing.T) {
+	start := metav1.Date(2023, time.May, 14, 9, 0, 0, 0, time.UTC)
+	freeze := &deployerv1.DeploymentFreeze{
+		ObjectMeta: metav1.ObjectMeta{Name: "incident"},
+		Spec: deployerv1.DeploymentFreezeSpec{
+			Start:  &start,
+			End:    &metav1.Time{Time: start.Add(time.Hour)},
+			Reason: "incident in progress",
+		},
+	}
+	test.AssertNoError(t, testEnv.Create(context.TODO(), freeze.DeepCopy(), client.DryRunAll))
+
+	freeze.Spec.End = &metav1.Time{Time: start.Add(-time.Hour)}
+	err := testEnv.Create(context.TODO(), freeze, client.DryRunAll)
+	if !apierrors.IsInvalid(err) {
+		t.Fatalf("Create() got error %v, want invalid", err)
+	}
+	test.AssertErrorMatch(t, "end must be after start", err)
+}
+
+func TestDeploymentPolicyValidation(t *testing.T) {
+	policy := test.NewDeploymentPolicy()
+	test.AssertNoError(t, testEnv.Create(context.TODO(), policy.DeepCopy(), client.DryRunAll))
+
+	policy.Spec.Gates = []deployerv1.KustomizationGate{
+		{Name: "mandatory", TemplateRef: &meta.LocalObjectReference{Name: "standard-gates"}},
+	}
+	err := testEnv.Create(context.TODO(), policy, client.DryRunAll)
+	if !apierrors.IsInvalid(err) {
+		t.Fatalf("Create() got error %v, want invalid", err)
+	}
+	test.AssertErrorMatch(t, "gates in a DeploymentPolicy can't reference a GateTemplate", err)
+}
+
+func TestExamplesAreValid(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "examples", "*.yaml"))
+	test.AssertNoError(t, err)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			for _, obj := range readDeployers(t, file) {
+				obj.SetNamespace("default")
+				test.AssertNoError(t, testEnv.Create(context.TODO(), obj, client.DryRunAll, client.FieldValidation("Strict")))
+			}
+		})
+	}
+}
+
+// readDeployers returns the KustomizationAutoDeployers in a YAML file.
+func readDeployers(t *testing.T, filename string) []*unstructured.Unstructured {
+	t.Helper()
+	b, err := os.ReadFile(filename)
+	test.AssertNoError(t, err)
+
+	var deployers []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(b), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			t.Fatal(err)
+		}
+		if obj.GetKind() == "KustomizationAutoDeployer" {
+			deployers = append(deployers, obj)
+		}
+	}
+
+	return deployers
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+)
+
+//+kubebuilder:webhook:path=/mutate-flux-gitops-pro-v1beta1-deploymentpolicy,mutating=true,failurePolicy=fail,sideEffects=None,groups=flux.gitops.pro,resources=deploymentpolicies,verbs=create;update,versions=v1beta1,name=mdeploymentpolicy.flux.gitops.pro,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-flux-gitops-pro-v1beta1-deploymentpolicy,mutating=false,failurePolicy=fail,sideEffects=None,groups=flux.gitops.pro,resources=deploymentpolicies,verbs=create;update,versions=v1beta1,name=vdeploymentpolicy.flux.gitops.pro,admissionReviewVersions=v1
+
+// DeploymentPolicyWebhook sets defaults for and validates the gates in
+// DeploymentPolicies, in the same way as the gates in deployers.
+type DeploymentPolicyWebhook struct {
+	Gates *gates.Registry
+}
+
+// SetupWebhookWithManager registers the webhooks with the manager.
+func (w *DeploymentPolicyWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &deployerv1.DeploymentPolicy{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+// Default sets the defaults for the gates.
+func (w *DeploymentPolicyWebhook) Default(ctx context.Context, policy *deployerv1.DeploymentPolicy) error {
+	for i := range policy.Spec.Gates {
+		w.Gates.Default(&policy.Spec.Gates[i])
+	}
+
+	return nil
+}
+
+// ValidateCreate validates a new DeploymentPolicy.
+func (w *DeploymentPolicyWebhook) ValidateCreate(ctx context.Context, policy *deployerv1.DeploymentPolicy) (admission.Warnings, error) {
+	return nil, w.validate(policy)
+}
+
+// ValidateUpdate validates an updated DeploymentPolicy.
+func (w *DeploymentPolicyWebhook) ValidateUpdate(ctx context.Context, _, policy *deployerv1.DeploymentPolicy) (admission.Warnings, error) {
+	return nil, w.validate(policy)
+}
+
+// ValidateDelete allows all deletions.
+func (w *DeploymentPolicyWebhook) ValidateDelete(ctx context.Context, policy *deployerv1.DeploymentPolicy) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (w *DeploymentPolicyWebhook) validate(policy *deployerv1.DeploymentPolicy) error {
+	path := field.NewPath("spec")
+	opts := metav1validation.LabelSelectorValidationOptions{}
+	errs := metav1validation.ValidateLabelSelector(policy.Spec.NamespaceSelector, opts, path.Child("namespaceSelector"))
+	errs = append(errs, metav1validation.ValidateLabelSelector(policy.Spec.DeployerSelector, opts, path.Child("deployerSelector"))...)
+	errs = append(errs, validateGates(w.Gates, policy.Spec.Gates, path.Child("gates"))...)
+	for i, gate := range policy.Spec.Gates {
+		if gate.TemplateRef != nil {
+			errs = append(errs, field.Forbidden(path.Child("gates").Index(i).Child("templateRef"), "gates in a DeploymentPolicy can't reference a GateTemplate"))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(deployerv1.GroupVersion.WithKind("DeploymentPolicy").GroupKind(), policy.Name, errs)
+}
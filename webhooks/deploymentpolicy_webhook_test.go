@@ -0,0 +1,142 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/google/go-cmp/cmp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/callback"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/healthcheck"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/scheduled"
+	"github.com/gitops-tools/kustomization-auto-deployer/test"
+)
+
+func TestDeploymentPolicyWebhook(t *testing.T) {
+	k8sClient := startWebhookEnvironment(t)
+
+	t.Run("defaults are set", func(t *testing.T) {
+		policy := test.NewDeploymentPolicy(func(p *deployerv1.DeploymentPolicy) {
+			p.Spec.Gates = []deployerv1.KustomizationGate{
+				{
+					Name:     "approval",
+					Callback: &deployerv1.CallbackCheck{URL: "https://example.com/approve"},
+				},
+			}
+		})
+		test.AssertNoError(t, k8sClient.Create(context.TODO(), policy))
+		defer func() {
+			test.AssertNoError(t, k8sClient.Delete(context.TODO(), policy))
+		}()
+
+		want := &metav1.Duration{Duration: callback.DefaultTimeout}
+		if diff := cmp.Diff(want, policy.Spec.Gates[0].Callback.Timeout); diff != "" {
+			t.Fatalf("failed to set default timeout:\n%s", diff)
+		}
+	})
+
+	t.Run("gate not enabled is rejected", func(t *testing.T) {
+		policy := test.NewDeploymentPolicy(func(p *deployerv1.DeploymentPolicy) {
+			p.Spec.Gates = []deployerv1.KustomizationGate{
+				{Name: "external", External: &deployerv1.ExternalCheck{URL: "https://example.com/check"}},
+			}
+		})
+		err := k8sClient.Create(context.TODO(), policy)
+		if !apierrors.IsInvalid(err) {
+			t.Fatalf("Create() got error %v, want invalid", err)
+		}
+		test.AssertErrorMatch(t, regexp.QuoteMeta(`spec.gates[0].external: Forbidden: gate External is not enabled in the controller`), err)
+	})
+}
+
+func TestDeploymentPolicyWebhook_ValidateCreate(t *testing.T) {
+	registry, err := gates.NewRegistry(
+		healthcheck.Definition(healthcheck.Factory(nil)),
+		scheduled.Definition(scheduled.Factory),
+	)
+	test.AssertNoError(t, err)
+	w := &DeploymentPolicyWebhook{Gates: registry}
+
+	validateTests := []struct {
+		name   string
+		policy func(*deployerv1.DeploymentPolicy)
+		want   []string
+	}{
+		{
+			name: "invalid time",
+			policy: func(p *deployerv1.DeploymentPolicy) {
+				p.Spec.Gates[0].Scheduled.Open = "9am"
+			},
+			want: []string{`spec.gates[0].scheduled.open: Invalid value: "9am": must be a time in the format hh:mm`},
+		},
+		{
+			name: "duplicate gate names",
+			policy: func(p *deployerv1.DeploymentPolicy) {
+				p.Spec.Gates = append(p.Spec.Gates, p.Spec.Gates[0])
+			},
+			want: []string{`spec.gates[1].name: Duplicate value: "business hours"`},
+		},
+		{
+			name: "template reference",
+			policy: func(p *deployerv1.DeploymentPolicy) {
+				p.Spec.Gates[0] = deployerv1.KustomizationGate{Name: "mandatory", TemplateRef: &meta.LocalObjectReference{Name: "standard-gates"}}
+			},
+			want: []string{`spec.gates[0].templateRef: Forbidden: gates in a DeploymentPolicy can't reference a GateTemplate`},
+		},
+		{
+			name: "invalid namespace selector",
+			policy: func(p *deployerv1.DeploymentPolicy) {
+				p.Spec.NamespaceSelector = &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "environment", Operator: metav1.LabelSelectorOpIn}},
+				}
+			},
+			want: []string{`spec.namespaceSelector.matchExpressions[0].values: Required value: must be specified when ` + "`operator`" + ` is 'In' or 'NotIn'`},
+		},
+		{
+			name: "invalid deployer selector",
+			policy: func(p *deployerv1.DeploymentPolicy) {
+				p.Spec.DeployerSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "not valid"}}
+			},
+			want: []string{`spec.deployerSelector.matchLabels: Invalid value: "not valid": a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')`},
+		},
+	}
+
+	for _, tt := range validateTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := w.ValidateCreate(context.TODO(), test.NewDeploymentPolicy(tt.policy))
+			statusErr, ok := err.(*apierrors.StatusError)
+			if !ok || !apierrors.IsInvalid(err) {
+				t.Fatalf("ValidateCreate() got error %v, want invalid", err)
+			}
+			var got []string
+			for _, cause := range statusErr.ErrStatus.Details.Causes {
+				got = append(got, cause.Field+": "+cause.Message)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("failed to validate:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+)
+
+//+kubebuilder:webhook:path=/mutate-flux-gitops-pro-v1beta1-gatetemplate,mutating=true,failurePolicy=fail,sideEffects=None,groups=flux.gitops.pro,resources=gatetemplates,verbs=create;update,versions=v1beta1,name=mgatetemplate.flux.gitops.pro,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-flux-gitops-pro-v1beta1-gatetemplate,mutating=false,failurePolicy=fail,sideEffects=None,groups=flux.gitops.pro,resources=gatetemplates,verbs=create;update,versions=v1beta1,name=vgatetemplate.flux.gitops.pro,admissionReviewVersions=v1
+
+// GateTemplateWebhook sets defaults for and validates the gates in
+// GateTemplates, gates in templates cannot reference other templates.
+type GateTemplateWebhook struct {
+	Gates *gates.Registry
+}
+
+// SetupWebhookWithManager registers the webhooks with the manager.
+func (w *GateTemplateWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &deployerv1.GateTemplate{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+// Default sets the defaults for the gates.
+func (w *GateTemplateWebhook) Default(ctx context.Context, template *deployerv1.GateTemplate) error {
+	for i := range template.Spec.Gates {
+		w.Gates.Default(&template.Spec.Gates[i])
+	}
+
+	return nil
+}
+
+// ValidateCreate validates a new GateTemplate.
+func (w *GateTemplateWebhook) ValidateCreate(ctx context.Context, template *deployerv1.GateTemplate) (admission.Warnings, error) {
+	return nil, w.validate(template)
+}
+
+// ValidateUpdate validates an updated GateTemplate.
+func (w *GateTemplateWebhook) ValidateUpdate(ctx context.Context, _, template *deployerv1.GateTemplate) (admission.Warnings, error) {
+	return nil, w.validate(template)
+}
+
+// ValidateDelete allows all deletions.
+func (w *GateTemplateWebhook) ValidateDelete(ctx context.Context, template *deployerv1.GateTemplate) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (w *GateTemplateWebhook) validate(template *deployerv1.GateTemplate) error {
+	path := field.NewPath("spec", "gates")
+	errs := validateGates(w.Gates, template.Spec.Gates, path)
+	for i, gate := range template.Spec.Gates {
+		if gate.TemplateRef != nil {
+			errs = append(errs, field.Forbidden(path.Index(i).Child("templateRef"), "gates in a template cannot reference templates"))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(deployerv1.GroupVersion.WithKind("GateTemplate").GroupKind(), template.Name, errs)
+}
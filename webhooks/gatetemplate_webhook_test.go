@@ -0,0 +1,94 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/google/go-cmp/cmp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/callback"
+	"github.com/gitops-tools/kustomization-auto-deployer/test"
+)
+
+func TestGateTemplateWebhook(t *testing.T) {
+	k8sClient := startWebhookEnvironment(t)
+
+	t.Run("defaults are set", func(t *testing.T) {
+		template := newGateTemplate([]deployerv1.KustomizationGate{
+			{
+				Name:     "approval",
+				Callback: &deployerv1.CallbackCheck{URL: "https://example.com/approve"},
+			},
+		})
+		test.AssertNoError(t, k8sClient.Create(context.TODO(), template))
+		defer func() {
+			test.AssertNoError(t, k8sClient.Delete(context.TODO(), template))
+		}()
+
+		want := &metav1.Duration{Duration: callback.DefaultTimeout}
+		if diff := cmp.Diff(want, template.Spec.Gates[0].Callback.Timeout); diff != "" {
+			t.Fatalf("failed to set default timeout:\n%s", diff)
+		}
+	})
+
+	t.Run("gate not enabled is rejected", func(t *testing.T) {
+		template := newGateTemplate([]deployerv1.KustomizationGate{
+			{Name: "external", External: &deployerv1.ExternalCheck{URL: "https://example.com/check"}},
+		})
+		err := k8sClient.Create(context.TODO(), template)
+		if !apierrors.IsInvalid(err) {
+			t.Fatalf("Create() got error %v, want invalid", err)
+		}
+		test.AssertErrorMatch(t, regexp.QuoteMeta(`spec.gates[0].external: Forbidden: gate External is not enabled in the controller`), err)
+	})
+
+	t.Run("deployers referencing templates are accepted", func(t *testing.T) {
+		deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Name = "template-deployer"
+			d.Spec.Gates = []deployerv1.KustomizationGate{
+				{Name: "standard", TemplateRef: &meta.LocalObjectReference{Name: "standard-gates"}},
+			}
+		})
+		test.AssertNoError(t, k8sClient.Create(context.TODO(), deployer))
+		test.AssertNoError(t, k8sClient.Delete(context.TODO(), deployer))
+	})
+
+	t.Run("templates referencing templates are rejected", func(t *testing.T) {
+		template := newGateTemplate([]deployerv1.KustomizationGate{
+			{Name: "nested", TemplateRef: &meta.LocalObjectReference{Name: "other-gates"}},
+		})
+		err := k8sClient.Create(context.TODO(), template)
+		if !apierrors.IsInvalid(err) {
+			t.Fatalf("Create() got error %v, want invalid", err)
+		}
+		test.AssertErrorMatch(t, regexp.QuoteMeta(`gates in a template cannot reference templates`), err)
+	})
+}
+
+func newGateTemplate(kgs []deployerv1.KustomizationGate) *deployerv1.GateTemplate {
+	return &deployerv1.GateTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "standard-gates", Namespace: test.DefaultNamespace},
+		Spec:       deployerv1.GateTemplateSpec{Gates: kgs},
+	}
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhooks provides the admission webhooks for the deployer
+// resources.
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+)
+
+//+kubebuilder:webhook:path=/mutate-flux-gitops-pro-v1beta1-kustomizationautodeployer,mutating=true,failurePolicy=fail,sideEffects=None,groups=flux.gitops.pro,resources=kustomizationautodeployers,verbs=create;update,versions=v1beta1,name=mkustomizationautodeployer.flux.gitops.pro,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-flux-gitops-pro-v1beta1-kustomizationautodeployer,mutating=false,failurePolicy=fail,sideEffects=None,groups=flux.gitops.pro,resources=kustomizationautodeployers,verbs=create;update,versions=v1beta1,name=vkustomizationautodeployer.flux.gitops.pro,admissionReviewVersions=v1
+
+// MaxBreakGlassDuration is the longest that a BreakGlassAnnotation can bypass
+// DeploymentFreezes for, from when it is set.
+const MaxBreakGlassDuration = time.Hour * 24
+
+// KustomizationAutoDeployerWebhook sets defaults for and validates
+// KustomizationAutoDeployers.
+//
+// The gates are validated with the Registry, and gates that are not enabled in
+// the Registry are rejected.
+//
+// The user that sets the BreakGlassAnnotation is recorded in the
+// BreakGlassUserAnnotation.
+type KustomizationAutoDeployerWebhook struct {
+	Gates *gates.Registry
+
+	// Clock is used to validate the BreakGlassAnnotation, this defaults to
+	// time.Now.
+	Clock func() time.Time
+}
+
+// SetupWebhookWithManager registers the webhooks with the manager, this
+// includes the conversion webhook for the versions of the deployer.
+func (w *KustomizationAutoDeployerWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &deployerv1.KustomizationAutoDeployer{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+// Default sets the defaults for the gates, and records the user that set the
+// BreakGlassAnnotation.
+func (w *KustomizationAutoDeployerWebhook) Default(ctx context.Context, deployer *deployerv1.KustomizationAutoDeployer) error {
+	for i := range deployer.Spec.Gates {
+		w.Gates.Default(&deployer.Spec.Gates[i])
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return nil
+	}
+
+	return defaultBreakGlassUser(req, deployer)
+}
+
+// ValidateCreate validates a new KustomizationAutoDeployer.
+func (w *KustomizationAutoDeployerWebhook) ValidateCreate(ctx context.Context, deployer *deployerv1.KustomizationAutoDeployer) (admission.Warnings, error) {
+	return nil, w.validate(nil, deployer)
+}
+
+// ValidateUpdate validates an updated KustomizationAutoDeployer.
+func (w *KustomizationAutoDeployerWebhook) ValidateUpdate(ctx context.Context, old, deployer *deployerv1.KustomizationAutoDeployer) (admission.Warnings, error) {
+	return nil, w.validate(old, deployer)
+}
+
+// ValidateDelete allows all deletions.
+func (w *KustomizationAutoDeployerWebhook) ValidateDelete(ctx context.Context, deployer *deployerv1.KustomizationAutoDeployer) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (w *KustomizationAutoDeployerWebhook) validate(old, deployer *deployerv1.KustomizationAutoDeployer) error {
+	errs := w.validateSpec(&deployer.Spec, field.NewPath("spec"))
+	errs = append(errs, w.validateBreakGlass(old, deployer)...)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(deployerv1.GroupVersion.WithKind("KustomizationAutoDeployer").GroupKind(), deployer.Name, errs)
+}
+
+func (w *KustomizationAutoDeployerWebhook) validateSpec(spec *deployerv1.KustomizationAutoDeployerSpec, path *field.Path) field.ErrorList {
+	errs := gates.ValidateDuration(spec.GatesTimeout, true, path.Child("gatesTimeout"))
+
+	return append(errs, validateGates(w.Gates, spec.Gates, path.Child("gates"))...)
+}
+
+// validateGates validates the gates with the Registry, and checks that the
+// gate names are unique.
+func validateGates(registry *gates.Registry, kgs []deployerv1.KustomizationGate, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	names := sets.New[string]()
+	for i := range kgs {
+		gate := &kgs[i]
+		gatePath := path.Index(i)
+		switch {
+		case gate.Name == "":
+			errs = append(errs, field.Required(gatePath.Child("name"), ""))
+		case names.Has(gate.Name):
+			errs = append(errs, field.Duplicate(gatePath.Child("name"), gate.Name))
+		}
+		names.Insert(gate.Name)
+
+		errs = append(errs, gates.ValidateDuration(gate.Timeout, true, gatePath.Child("timeout"))...)
+		errs = append(errs, registry.Validate(gate, gatePath)...)
+	}
+
+	return errs
+}
+
+// validateBreakGlass validates the time in the BreakGlassAnnotation, a new or
+// changed time must be in the future, and within the MaxBreakGlassDuration.
+func (w *KustomizationAutoDeployerWebhook) validateBreakGlass(old, deployer *deployerv1.KustomizationAutoDeployer) field.ErrorList {
+	until, ok := deployer.GetAnnotations()[deployerv1.BreakGlassAnnotation]
+	if !ok {
+		return nil
+	}
+
+	path := field.NewPath("metadata", "annotations").Key(deployerv1.BreakGlassAnnotation)
+	expires, err := time.Parse(time.RFC3339, until)
+	if err != nil {
+		return field.ErrorList{field.Invalid(path, until, "must be a time in RFC3339 format")}
+	}
+	if old != nil && old.GetAnnotations()[deployerv1.BreakGlassAnnotation] == until {
+		return nil
+	}
+
+	now := time.Now()
+	if w.Clock != nil {
+		now = w.Clock()
+	}
+	switch {
+	case !expires.After(now):
+		return field.ErrorList{field.Invalid(path, until, "must be in the future")}
+	case expires.Sub(now) > MaxBreakGlassDuration:
+		return field.ErrorList{field.Invalid(path, until, fmt.Sprintf("must be within %s", MaxBreakGlassDuration))}
+	}
+
+	return nil
+}
+
+// defaultBreakGlassUser records the user in the request in the
+// BreakGlassUserAnnotation when the BreakGlassAnnotation is set or changed,
+// otherwise the user is restored from the old deployer, so that it can't be
+// changed.
+func defaultBreakGlassUser(req admission.Request, deployer *deployerv1.KustomizationAutoDeployer) error {
+	var oldAnnotations map[string]string
+	if req.Operation == admissionv1.Update && len(req.OldObject.Raw) > 0 {
+		var old metav1.PartialObjectMetadata
+		if err := json.Unmarshal(req.OldObject.Raw, &old); err != nil {
+			return fmt.Errorf("failed to decode the old deployer: %w", err)
+		}
+		oldAnnotations = old.GetAnnotations()
+	}
+
+	annotations := deployer.GetAnnotations()
+	until, ok := annotations[deployerv1.BreakGlassAnnotation]
+	oldUser, hasOldUser := oldAnnotations[deployerv1.BreakGlassUserAnnotation]
+	switch {
+	case ok && until != oldAnnotations[deployerv1.BreakGlassAnnotation]:
+		annotations[deployerv1.BreakGlassUserAnnotation] = req.UserInfo.Username
+	case ok && hasOldUser:
+		annotations[deployerv1.BreakGlassUserAnnotation] = oldUser
+	default:
+		delete(annotations, deployerv1.BreakGlassUserAnnotation)
+	}
+	deployer.SetAnnotations(annotations)
+
+	return nil
+}
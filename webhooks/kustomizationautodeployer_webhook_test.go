@@ -0,0 +1,484 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/google/go-cmp/cmp"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	deployerv1alpha1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1alpha1"
+	deployerv1 "github.com/gitops-tools/kustomization-auto-deployer/api/v1beta1"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/callback"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/external"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/healthcheck"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/ratelimit"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/scheduled"
+	"github.com/gitops-tools/kustomization-auto-deployer/controllers/gates/soaktime"
+	"github.com/gitops-tools/kustomization-auto-deployer/test"
+)
+
+func TestKustomizationAutoDeployerWebhook(t *testing.T) {
+	k8sClient := startWebhookEnvironment(t)
+
+	t.Run("defaults are set", func(t *testing.T) {
+		deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Name = "defaulted-deployer"
+			d.Spec.Gates = []deployerv1.KustomizationGate{
+				{
+					Name:     "approval",
+					Callback: &deployerv1.CallbackCheck{URL: "https://example.com/approve"},
+				},
+			}
+		})
+		test.AssertNoError(t, k8sClient.Create(context.TODO(), deployer))
+		defer func() {
+			test.AssertNoError(t, k8sClient.Delete(context.TODO(), deployer))
+		}()
+
+		want := &metav1.Duration{Duration: callback.DefaultTimeout}
+		if diff := cmp.Diff(want, deployer.Spec.Gates[0].Callback.Timeout); diff != "" {
+			t.Fatalf("failed to set default timeout:\n%s", diff)
+		}
+	})
+
+	t.Run("valid deployer is accepted", func(t *testing.T) {
+		deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Name = "valid-deployer"
+			d.Spec.Gates = []deployerv1.KustomizationGate{
+				{
+					Name:      "business hours",
+					Scheduled: &deployerv1.ScheduledCheck{Open: "09:00", Close: "17:00"},
+				},
+				{
+					Name: "health-checks",
+					Checks: []deployerv1.GateCheck{
+						{Name: "api", Kind: "HealthCheck", Config: jsonConfig(`{"url":"https://api.example.com/","interval":"5m"}`)},
+						{Name: "ui", Kind: "HealthCheck", Config: jsonConfig(`{"url":"https://ui.example.com/","interval":"5m"}`)},
+					},
+				},
+			}
+		})
+		test.AssertNoError(t, k8sClient.Create(context.TODO(), deployer))
+		test.AssertNoError(t, k8sClient.Delete(context.TODO(), deployer))
+	})
+
+	// These are only rejected by the webhook, other invalid configuration is
+	// rejected by the validation in the CRD before the webhook is called.
+	invalidTests := []struct {
+		name  string
+		gates []deployerv1.KustomizationGate
+		want  string
+	}{
+		{
+			name: "unknown gate kind",
+			gates: []deployerv1.KustomizationGate{
+				{Name: "unknown", Checks: []deployerv1.GateCheck{{Name: "test", Kind: "Unknown"}}},
+			},
+			want: `spec.gates[0].checks[0].kind: Unsupported value: "Unknown": supported values: "HealthCheck", "Scheduled", "External", "Callback"`,
+		},
+		{
+			name: "gate not enabled",
+			gates: []deployerv1.KustomizationGate{
+				{Name: "scheduled", Scheduled: &deployerv1.ScheduledCheck{Open: "09:00", Close: "17:00"}},
+				{Name: "external", External: &deployerv1.ExternalCheck{URL: "https://example.com/check"}},
+			},
+			want: `spec.gates[1].external: Forbidden: gate External is not enabled in the controller`,
+		},
+		{
+			name: "invalid check config",
+			gates: []deployerv1.KustomizationGate{
+				{
+					Name: "health-checks",
+					Checks: []deployerv1.GateCheck{
+						{Name: "api", Kind: "HealthCheck", Config: jsonConfig(`{"url":"api.example.com"}`)},
+					},
+				},
+			},
+			want: `spec.gates[0].checks[0].config.url: Invalid value: "api.example.com": must be an absolute http or https URL`,
+		},
+	}
+
+	for _, tt := range invalidTests {
+		t.Run(tt.name+" is rejected", func(t *testing.T) {
+			deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Name = "invalid-deployer"
+				d.Spec.Gates = tt.gates
+			})
+			err := k8sClient.Create(context.TODO(), deployer)
+			if !apierrors.IsInvalid(err) {
+				t.Fatalf("Create() got error %v, want invalid", err)
+			}
+			test.AssertErrorMatch(t, regexp.QuoteMeta(tt.want), err)
+		})
+	}
+
+	t.Run("invalid update is rejected", func(t *testing.T) {
+		deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Name = "updated-deployer"
+			d.Spec.Gates = []deployerv1.KustomizationGate{
+				{Name: "business hours", Scheduled: &deployerv1.ScheduledCheck{Open: "09:00", Close: "17:00"}},
+			}
+		})
+		test.AssertNoError(t, k8sClient.Create(context.TODO(), deployer))
+		defer func() {
+			test.AssertNoError(t, k8sClient.Delete(context.TODO(), deployer))
+		}()
+
+		deployer.Spec.Gates = append(deployer.Spec.Gates, deployerv1.KustomizationGate{
+			Name:     "health",
+			External: &deployerv1.ExternalCheck{URL: "https://example.com/check"},
+		})
+		err := k8sClient.Update(context.TODO(), deployer)
+		test.AssertErrorMatch(t, regexp.QuoteMeta(`spec.gates[1].external: Forbidden: gate External is not enabled in the controller`), err)
+	})
+
+	t.Run("the break glass user is recorded", func(t *testing.T) {
+		until := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Name = "break-glass-deployer"
+			d.SetAnnotations(map[string]string{
+				deployerv1.BreakGlassAnnotation:     until,
+				deployerv1.BreakGlassUserAnnotation: "someone-else",
+			})
+		})
+		test.AssertNoError(t, k8sClient.Create(context.TODO(), deployer))
+		defer func() {
+			test.AssertNoError(t, k8sClient.Delete(context.TODO(), deployer))
+		}()
+
+		user := deployer.GetAnnotations()[deployerv1.BreakGlassUserAnnotation]
+		if user == "" || user == "someone-else" {
+			t.Fatalf("failed to record the user from the request, got %q", user)
+		}
+
+		// The user can't be changed without changing the break glass.
+		deployer.Annotations[deployerv1.BreakGlassUserAnnotation] = "someone-else"
+		deployer.Spec.Interval = metav1.Duration{Duration: time.Minute * 5}
+		test.AssertNoError(t, k8sClient.Update(context.TODO(), deployer))
+		if got := deployer.GetAnnotations()[deployerv1.BreakGlassUserAnnotation]; got != user {
+			t.Errorf("got break glass user %q, want %q", got, user)
+		}
+
+		// Removing the break glass removes the user.
+		delete(deployer.Annotations, deployerv1.BreakGlassAnnotation)
+		test.AssertNoError(t, k8sClient.Update(context.TODO(), deployer))
+		if got, ok := deployer.GetAnnotations()[deployerv1.BreakGlassUserAnnotation]; ok {
+			t.Errorf("break glass user %q was not removed", got)
+		}
+	})
+
+	breakGlassTests := []struct {
+		name  string
+		until string
+		want  string
+	}{
+		{
+			name:  "invalid break glass time",
+			until: "tomorrow",
+			want:  `metadata.annotations[flux.gitops.pro/break-glass-until]: Invalid value: "tomorrow": must be a time in RFC3339 format`,
+		},
+		{
+			name:  "expired break glass",
+			until: time.Now().Add(-time.Minute).UTC().Format(time.RFC3339),
+			want:  `must be in the future`,
+		},
+		{
+			name:  "break glass longer than the maximum",
+			until: time.Now().Add(MaxBreakGlassDuration + time.Hour).UTC().Format(time.RFC3339),
+			want:  `must be within 24h0m0s`,
+		},
+	}
+	for _, tt := range breakGlassTests {
+		t.Run(tt.name+" is rejected", func(t *testing.T) {
+			deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Name = "invalid-break-glass"
+				d.SetAnnotations(map[string]string{deployerv1.BreakGlassAnnotation: tt.until})
+			})
+			err := k8sClient.Create(context.TODO(), deployer)
+			if !apierrors.IsInvalid(err) {
+				t.Fatalf("Create() got error %v, want invalid", err)
+			}
+			test.AssertErrorMatch(t, regexp.QuoteMeta(tt.want), err)
+		})
+	}
+
+	t.Run("v1alpha1 deployers are converted", func(t *testing.T) {
+		deployer := &deployerv1alpha1.KustomizationAutoDeployer{
+			ObjectMeta: metav1.ObjectMeta{Name: "v1alpha1-deployer", Namespace: "default"},
+			Spec: deployerv1alpha1.KustomizationAutoDeployerSpec{
+				KustomizationRef: meta.LocalObjectReference{Name: "test-kustomization"},
+				Interval:         metav1.Duration{Duration: time.Minute * 3},
+				TargetCommit:     test.CommitIDs[3],
+				Gates: []deployerv1alpha1.KustomizationGate{
+					{
+						Name:     "approval",
+						Callback: &deployerv1alpha1.CallbackCheck{URL: "https://example.com/approve"},
+					},
+				},
+			},
+		}
+		test.AssertNoError(t, k8sClient.Create(context.TODO(), deployer))
+		defer func() {
+			test.AssertNoError(t, k8sClient.Delete(context.TODO(), deployer))
+		}()
+
+		converted := &deployerv1.KustomizationAutoDeployer{}
+		test.AssertNoError(t, k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(deployer), converted))
+
+		if converted.Spec.Strategy.TargetCommit != test.CommitIDs[3] {
+			t.Errorf("failed to convert the target commit, got %q", converted.Spec.Strategy.TargetCommit)
+		}
+		// The webhooks are called with the v1beta1 representation.
+		want := &metav1.Duration{Duration: callback.DefaultTimeout}
+		if diff := cmp.Diff(want, converted.Spec.Gates[0].Callback.Timeout); diff != "" {
+			t.Fatalf("failed to set default timeout:\n%s", diff)
+		}
+	})
+
+	t.Run("v1beta1 fields are preserved when updating v1alpha1 deployers", func(t *testing.T) {
+		deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+			d.Name = "v1beta1-deployer"
+			d.Spec.KustomizationRef.Namespace = "apps"
+			d.Spec.FailurePolicy.GateErrors = deployerv1.IgnoreGateErrorPolicy
+		})
+		test.AssertNoError(t, k8sClient.Create(context.TODO(), deployer))
+		defer func() {
+			test.AssertNoError(t, k8sClient.Delete(context.TODO(), deployer))
+		}()
+
+		old := &deployerv1alpha1.KustomizationAutoDeployer{}
+		test.AssertNoError(t, k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(deployer), old))
+		old.Spec.PinnedCommit = test.CommitIDs[5]
+		test.AssertNoError(t, k8sClient.Update(context.TODO(), old))
+
+		updated := &deployerv1.KustomizationAutoDeployer{}
+		test.AssertNoError(t, k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(deployer), updated))
+
+		wantSpec := deployer.Spec.DeepCopy()
+		wantSpec.Strategy.PinnedCommit = test.CommitIDs[5]
+		if diff := cmp.Diff(wantSpec, &updated.Spec); diff != "" {
+			t.Fatalf("failed to preserve the v1beta1 fields:\n%s", diff)
+		}
+		if _, ok := updated.Annotations[deployerv1alpha1.ConversionAnnotation]; ok {
+			t.Errorf("conversion annotation was not removed: %v", updated.Annotations)
+		}
+	})
+}
+
+func TestKustomizationAutoDeployerWebhook_ValidateCreate(t *testing.T) {
+	registry, err := gates.NewRegistry(
+		healthcheck.Definition(healthcheck.Factory(nil)),
+		scheduled.Definition(scheduled.Factory),
+		ratelimit.Definition(ratelimit.Factory),
+		soaktime.Definition(soaktime.Factory),
+	)
+	test.AssertNoError(t, err)
+	w := &KustomizationAutoDeployerWebhook{Gates: registry}
+
+	validateTests := []struct {
+		name  string
+		gates []deployerv1.KustomizationGate
+		want  []string
+	}{
+		{
+			name: "invalid time",
+			gates: []deployerv1.KustomizationGate{
+				{Name: "business hours", Scheduled: &deployerv1.ScheduledCheck{Open: "9am", Close: "17:00"}},
+			},
+			want: []string{`spec.gates[0].scheduled.open: Invalid value: "9am": must be a time in the format hh:mm`},
+		},
+		{
+			name: "open after close",
+			gates: []deployerv1.KustomizationGate{
+				{Name: "business hours", Scheduled: &deployerv1.ScheduledCheck{Open: "17:00", Close: "09:00"}},
+			},
+			want: []string{`spec.gates[0].scheduled.close: Invalid value: "09:00": must be after open`},
+		},
+		{
+			name: "duplicate gate names",
+			gates: []deployerv1.KustomizationGate{
+				{Name: "business hours", Scheduled: &deployerv1.ScheduledCheck{Open: "09:00", Close: "17:00"}},
+				{Name: "business hours", Scheduled: &deployerv1.ScheduledCheck{Open: "10:00", Close: "16:00"}},
+			},
+			want: []string{`spec.gates[1].name: Duplicate value: "business hours"`},
+		},
+		{
+			name: "gate with no checks",
+			gates: []deployerv1.KustomizationGate{
+				{Name: "empty"},
+			},
+			want: []string{`spec.gates[0]: Required value: exactly one of healthCheck, scheduled, rateLimit, soakTime, templateRef or checks must be set`},
+		},
+		{
+			name: "rate limit out of range",
+			gates: []deployerv1.KustomizationGate{
+				{Name: "hourly", RateLimit: &deployerv1.RateLimitCheck{Limit: 100, Window: metav1.Duration{Duration: time.Hour * 200}}},
+			},
+			want: []string{
+				`spec.gates[0].rateLimit.limit: Invalid value: 100: must be between 1 and 50`,
+				`spec.gates[0].rateLimit.window: Invalid value: "200h0m0s": must be at most 168h0m0s`,
+			},
+		},
+		{
+			name: "zero soak time",
+			gates: []deployerv1.KustomizationGate{
+				{Name: "soak", SoakTime: &deployerv1.SoakTimeCheck{}},
+			},
+			want: []string{`spec.gates[0].soakTime.duration: Invalid value: "0s": must be greater than zero`},
+		},
+		{
+			name: "gate with no name",
+			gates: []deployerv1.KustomizationGate{
+				{Scheduled: &deployerv1.ScheduledCheck{Open: "09:00", Close: "17:00"}},
+			},
+			want: []string{`spec.gates[0].name: Required value`},
+		},
+		{
+			name: "zero gate timeout",
+			gates: []deployerv1.KustomizationGate{
+				{Name: "hours", Timeout: &metav1.Duration{}, Scheduled: &deployerv1.ScheduledCheck{Open: "09:00", Close: "17:00"}},
+			},
+			want: []string{`spec.gates[0].timeout: Invalid value: "0s": must be greater than zero`},
+		},
+	}
+
+	for _, tt := range validateTests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployer := test.NewKustomizationAutoDeployer(func(d *deployerv1.KustomizationAutoDeployer) {
+				d.Spec.Gates = tt.gates
+			})
+			_, err := w.ValidateCreate(context.TODO(), deployer)
+			statusErr, ok := err.(*apierrors.StatusError)
+			if !ok || !apierrors.IsInvalid(err) {
+				t.Fatalf("ValidateCreate() got error %v, want invalid", err)
+			}
+			var got []string
+			for _, cause := range statusErr.ErrStatus.Details.Causes {
+				got = append(got, cause.Field+": "+cause.Message)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("failed to validate:\n%s", diff)
+			}
+		})
+	}
+}
+
+// startWebhookEnvironment starts an API server with the webhooks installed, and
+// a manager that serves the webhooks, the External gate is not enabled.
+func startWebhookEnvironment(t *testing.T) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	test.AssertNoError(t, clientgoscheme.AddToScheme(scheme))
+	test.AssertNoError(t, deployerv1alpha1.AddToScheme(scheme))
+	test.AssertNoError(t, deployerv1.AddToScheme(scheme))
+
+	// The scheme enables the conversion webhook for the CRDs.
+	testEnv := &envtest.Environment{
+		Scheme:                scheme,
+		ErrorIfCRDPathMissing: true,
+		CRDDirectoryPaths:     []string{filepath.Join("..", "config", "crd", "bases")},
+		WebhookInstallOptions: envtest.WebhookInstallOptions{
+			Paths: []string{filepath.Join("..", "config", "webhook")},
+		},
+	}
+	cfg, err := testEnv.Start()
+	test.AssertNoError(t, err)
+	t.Cleanup(func() {
+		if err := testEnv.Stop(); err != nil {
+			t.Errorf("failed to stop the test environment: %s", err)
+		}
+	})
+
+	opts := testEnv.WebhookInstallOptions
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:  scheme,
+		Metrics: metricsserver.Options{BindAddress: "0"},
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Host:    opts.LocalServingHost,
+			Port:    opts.LocalServingPort,
+			CertDir: opts.LocalServingCertDir,
+		}),
+	})
+	test.AssertNoError(t, err)
+
+	registry, err := gates.NewRegistry(
+		healthcheck.Definition(healthcheck.Factory(nil)),
+		scheduled.Definition(scheduled.Factory),
+		external.Definition(nil),
+		callback.Definition(callback.Factory(nil, "")),
+		ratelimit.Definition(ratelimit.Factory),
+		soaktime.Definition(soaktime.Factory),
+	)
+	test.AssertNoError(t, err)
+	test.AssertNoError(t, (&KustomizationAutoDeployerWebhook{Gates: registry}).SetupWebhookWithManager(mgr))
+	test.AssertNoError(t, (&DeploymentPolicyWebhook{Gates: registry}).SetupWebhookWithManager(mgr))
+	test.AssertNoError(t, (&GateTemplateWebhook{Gates: registry}).SetupWebhookWithManager(mgr))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() {
+		if err := mgr.Start(ctx); err != nil {
+			t.Errorf("failed to start the manager: %s", err)
+		}
+	}()
+	waitForWebhookServer(t, opts.LocalServingHost, opts.LocalServingPort)
+
+	k8sClient, err := client.New(cfg, client.Options{Scheme: scheme})
+	test.AssertNoError(t, err)
+
+	return k8sClient
+}
+
+func waitForWebhookServer(t *testing.T, host string, port int) {
+	t.Helper()
+	dialer := &net.Dialer{Timeout: time.Second}
+	addr := net.JoinHostPort(host, fmt.Sprint(port))
+	deadline := time.Now().Add(time.Second * 10)
+	for time.Now().Before(deadline) {
+		conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{InsecureSkipVerify: true})
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(time.Millisecond * 100)
+	}
+	t.Fatalf("webhook server did not start listening on %s", addr)
+}
+
+func jsonConfig(s string) *apiextensionsv1.JSON {
+	return &apiextensionsv1.JSON{Raw: []byte(s)}
+}